@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"linkit/internal/config"
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/database"
+	"linkit/internal/repository"
+	"linkit/internal/usecase"
+)
+
+// apikey 直接在数据库中创建API Key，用于创建首个管理员API Key
+// 之后的API Key可通过 POST /api/v1/api-keys 管理
+func main() {
+	configPath := flag.String("config", "", "配置文件路径，默认读取 $LINKIT_CONFIG 或 ./configs/config.yaml")
+	name := flag.String("name", "bootstrap", "API Key名称")
+	scopes := flag.String("scopes", string(domain.ScopeAdmin), "权限范围，多个以逗号分隔: read,write,admin")
+	userID := flag.Uint("user", 1, "所属用户ID")
+	expires := flag.Duration("expires", 0, "有效期，如 720h，0表示永不过期")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	key := &domain.APIKey{
+		Name:   *name,
+		UserID: *userID,
+	}
+	for _, s := range strings.Split(*scopes, ",") {
+		scope := domain.Scope(strings.TrimSpace(s))
+		if !domain.ValidScope(scope) {
+			log.Fatalf("Invalid scope: %q", s)
+		}
+		key.Scopes = append(key.Scopes, scope)
+	}
+	if *expires > 0 {
+		expiresAt := time.Now().Add(*expires)
+		key.ExpiresAt = &expiresAt
+	}
+
+	db, err := database.NewPostgresDB(cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	if err := db.AutoMigrate(&domain.APIKey{}); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	raw, prefix, hash, err := usecase.GenerateAPIKey()
+	if err != nil {
+		log.Fatal(err)
+	}
+	key.Prefix = prefix
+	key.KeyHash = hash
+
+	if err := repository.NewAPIKeyRepository(db).Create(context.Background(), key); err != nil {
+		log.Fatalf("Failed to create api key: %v", err)
+	}
+
+	log.Printf("Created api key %d (%s) for user %d with scopes %s", key.ID, key.Prefix, key.UserID, *scopes)
+	log.Println("The key is shown only once, store it securely:")
+	fmt.Println(raw)
+}
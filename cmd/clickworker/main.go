@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os/signal"
+	"syscall"
+
+	"linkit/internal/config"
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/cache"
+	"linkit/internal/infrastructure/database"
+	"linkit/internal/infrastructure/lifecycle"
+	"linkit/internal/infrastructure/logger"
+	"linkit/internal/repository"
+)
+
+// clickworker 独立运行的点击事件消费者，从Redis Stream读取点击事件写入 click_logs
+// 与服务端 clicklog.mode: stream 配合使用，可部署多个实例共同消费同一个消费者组
+func main() {
+	configPath := flag.String("config", "", "配置文件路径，默认读取 $LINKIT_CONFIG 或 ./configs/config.yaml")
+	flag.Parse()
+
+	// 加载配置，配置缺失或不合法时立即退出
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// 初始化日志
+	zapLogger, err := logger.NewLogger(cfg.Server.Mode, cfg.Log)
+	if err != nil {
+		log.Fatalf("Failed to create logger: %v", err)
+	}
+	defer zapLogger.Sync()
+
+	sugar := logger.NewSugaredLogger(zapLogger)
+	defer sugar.Sync()
+
+	lc := lifecycle.NewManager(sugar)
+
+	// 初始化数据库连接
+	db, err := database.NewPostgresDB(cfg.Database)
+	if err != nil {
+		sugar.Fatalf("Failed to connect to database: %v", err)
+	}
+	lc.OnShutdown("database", func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	})
+
+	if err := db.AutoMigrate(&domain.ClickLog{}); err != nil {
+		sugar.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	// 初始化Redis连接
+	redisClient, err := cache.NewRedisClient(cfg.Redis)
+	if err != nil {
+		sugar.Fatalf("Failed to connect to redis: %v", err)
+	}
+	lc.OnShutdown("redis", func(ctx context.Context) error {
+		return redisClient.Close()
+	})
+
+	// 启动消费者，关闭时等待当前批次写入并确认
+	streamCfg := cfg.ClickLog.Stream
+	consumer := repository.NewClickStreamConsumer(db, redisClient, repository.ClickStreamConfig{
+		Stream:     streamCfg.Name,
+		Group:      streamCfg.Group,
+		Consumer:   streamCfg.Consumer,
+		DeadLetter: streamCfg.DeadLetter,
+		MaxLen:     streamCfg.MaxLen,
+		BatchSize:  streamCfg.BatchSize,
+		Block:      streamCfg.Block,
+		MinIdle:    streamCfg.MinIdle,
+		MaxRetries: streamCfg.MaxRetries,
+	}, zapLogger)
+	if err := consumer.Start(context.Background()); err != nil {
+		sugar.Fatalf("Failed to start click stream consumer: %v", err)
+	}
+	lc.OnShutdown("click stream consumer", consumer.Close)
+	sugar.Info("Click worker started")
+
+	// 等待退出信号
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	stop()
+	sugar.Info("Received shutdown signal")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := lc.Shutdown(shutdownCtx); err != nil {
+		sugar.Errorf("Shutdown completed with errors: %v", err)
+	}
+	sugar.Info("Click worker exited gracefully")
+}
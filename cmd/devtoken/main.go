@@ -0,0 +1,156 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// devtoken 生成本地测试用的签名密钥、JWKS文件和JWT，用于在没有SSO的环境中测试JWT认证
+// 将 auth.jwt.jwks_file 指向生成的JWKS文件后，即可使用输出的令牌调用 /api/v1
+func main() {
+	keyPath := flag.String("key", "data/jwt-key.pem", "签名私钥（PKCS#8 PEM），不存在时生成ES256密钥")
+	jwksPath := flag.String("jwks", "data/jwks.json", "JWKS输出路径")
+	kid := flag.String("kid", "dev", "密钥ID")
+	sub := flag.String("sub", "1", "用户ID，非数字时需要在 auth.jwt.user_map 中配置映射")
+	workspace := flag.Uint("workspace", 0, "工作空间ID，0表示不指定")
+	scopes := flag.String("scopes", "read write", "权限范围，以空格分隔")
+	issuer := flag.String("issuer", "", "签发者")
+	audience := flag.String("audience", "", "受众")
+	ttl := flag.Duration("ttl", time.Hour, "有效期")
+	flag.Parse()
+
+	key, err := loadOrCreateKey(*keyPath)
+	if err != nil {
+		log.Fatalf("Failed to load signing key: %v", err)
+	}
+
+	jwks, method, err := publicJWKS(key, *kid)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(*jwksPath), 0o755); err != nil {
+		log.Fatalf("Failed to create jwks directory: %v", err)
+	}
+	if err := os.WriteFile(*jwksPath, jwks, 0o644); err != nil {
+		log.Fatalf("Failed to write jwks: %v", err)
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub":   *sub,
+		"scope": *scopes,
+		"iat":   now.Unix(),
+		"exp":   now.Add(*ttl).Unix(),
+	}
+	if *workspace != 0 {
+		claims["workspace_id"] = *workspace
+	}
+	if *issuer != "" {
+		claims["iss"] = *issuer
+	}
+	if *audience != "" {
+		claims["aud"] = *audience
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = *kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		log.Fatalf("Failed to sign token: %v", err)
+	}
+
+	log.Printf("Wrote JWKS to %s, signed with %s key %q", *jwksPath, method.Alg(), *kid)
+	fmt.Println(signed)
+}
+
+// loadOrCreateKey 读取PEM私钥，文件不存在时生成P-256密钥并保存
+func loadOrCreateKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+			return nil, err
+		}
+		log.Printf("Generated new signing key %s", path)
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM file", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return signer, nil
+}
+
+// publicJWKS 生成只包含公钥的JWKS文档
+func publicJWKS(key crypto.Signer, kid string) ([]byte, jwt.SigningMethod, error) {
+	enc := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+	jwk := map[string]string{"kid": kid, "use": "sig"}
+	var method jwt.SigningMethod
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+		jwk["kty"] = "RSA"
+		jwk["alg"] = method.Alg()
+		jwk["n"] = enc(k.N.Bytes())
+		jwk["e"] = enc(big.NewInt(int64(k.E)).Bytes())
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return nil, nil, fmt.Errorf("only P-256 keys are supported for ES256, got %s", k.Curve.Params().Name)
+		}
+		method = jwt.SigningMethodES256
+		size := (k.Curve.Params().BitSize + 7) / 8
+		jwk["kty"] = "EC"
+		jwk["alg"] = method.Alg()
+		jwk["crv"] = "P-256"
+		jwk["x"] = enc(k.X.FillBytes(make([]byte, size)))
+		jwk["y"] = enc(k.Y.FillBytes(make([]byte, size)))
+	default:
+		return nil, nil, fmt.Errorf("unsupported key type %T", key)
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{"keys": []interface{}{jwk}}, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, method, nil
+}
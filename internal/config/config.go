@@ -0,0 +1,539 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix 环境变量前缀
+// 配置项 a.b_c 对应环境变量 LINKIT_A_B_C，LINKIT_A_B_C_FILE 指向的文件内容同样可以覆盖该配置项
+const EnvPrefix = "LINKIT"
+
+// secretFileSuffix 从文件读取配置值的环境变量后缀
+const secretFileSuffix = "_FILE"
+
+// Config 应用配置
+type Config struct {
+	Server    ServerConfig    `mapstructure:"server"`
+	Health    HealthConfig    `mapstructure:"health"`
+	Metrics   MetricsConfig   `mapstructure:"metrics"`
+	Log       LogConfig       `mapstructure:"log"`
+	Auth      AuthConfig      `mapstructure:"auth"`
+	Timeouts  TimeoutsConfig  `mapstructure:"timeouts"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	Redis     RedisConfig     `mapstructure:"redis"`
+	Cache     CacheConfig     `mapstructure:"cache"`
+	Clicks    ClicksConfig    `mapstructure:"clicks"`
+	ClickLog  ClickLogConfig  `mapstructure:"clicklog"`
+	ShortLink ShortLinkConfig `mapstructure:"shortlink"`
+	RateLimit RateLimitConfig `mapstructure:"ratelimit"`
+	Quotas    QuotasConfig    `mapstructure:"quotas"`
+	Metering  MeteringConfig  `mapstructure:"metering"`
+}
+
+// ServerConfig HTTP服务配置
+type ServerConfig struct {
+	Port            int           `mapstructure:"port"`             // HTTP服务端口
+	Mode            string        `mapstructure:"mode"`             // gin运行模式: debug | release | test
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"` // 优雅关闭超时时间
+	MaxBodySize     int64         `mapstructure:"max_body_size"`    // 允许的最大请求体大小(MB)，0表示不限制
+	TrustedProxies  []string      `mapstructure:"trusted_proxies"`  // 可信代理的IP或CIDR，仅信任这些代理传递的 X-Forwarded-For，为空时不信任任何代理
+}
+
+// HealthConfig 健康检查配置
+type HealthConfig struct {
+	Timeout time.Duration `mapstructure:"timeout"` // 单个依赖检查的超时时间
+}
+
+// MetricsConfig 监控配置
+type MetricsConfig struct {
+	Enabled bool `mapstructure:"enabled"` // 是否暴露 /metrics
+}
+
+// LogConfig 日志配置
+type LogConfig struct {
+	Level string `mapstructure:"level"` // 日志级别，为空时按运行模式选择
+}
+
+// AuthConfig 认证配置
+type AuthConfig struct {
+	Enabled bool      `mapstructure:"enabled"` // 是否要求API请求携带API Key或JWT，关闭后所有请求视为管理员
+	JWT     JWTConfig `mapstructure:"jwt"`
+}
+
+// JWTConfig SSO签发的JWT认证配置，支持RS256和ES256
+type JWTConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`          // 是否接受JWT
+	JWKSFile        string        `mapstructure:"jwks_file"`        // 本地JWKS文件，与 jwks_url 二选一
+	JWKSURL         string        `mapstructure:"jwks_url"`         // JWKS地址
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"` // JWKS缓存时间
+	Issuer          string        `mapstructure:"issuer"`           // 要求的签发者(iss)，为空不校验
+	Audience        string        `mapstructure:"audience"`         // 要求的受众(aud)，为空不校验
+	Leeway          time.Duration `mapstructure:"leeway"`           // 校验时间的容差
+	UserClaim       string        `mapstructure:"user_claim"`       // 用户ID声明
+	UserMap         []JWTUserMap  `mapstructure:"user_map"`         // 用户声明到Linkit用户ID的映射，用于非数字的用户声明
+	WorkspaceClaim  string        `mapstructure:"workspace_claim"`  // 工作空间ID声明，为空不读取
+	ScopeClaim      string        `mapstructure:"scope_claim"`      // 权限范围声明
+	DefaultScopes   []string      `mapstructure:"default_scopes"`   // 令牌未包含权限范围声明时授予的权限
+}
+
+// JWTUserMap 将SSO的用户声明映射为Linkit用户ID
+type JWTUserMap struct {
+	Subject string `mapstructure:"subject"` // 用户声明的值，区分大小写
+	UserID  uint   `mapstructure:"user_id"` // Linkit用户ID
+}
+
+// TimeoutsConfig 操作超时配置，0表示不设置超时
+type TimeoutsConfig struct {
+	Redirect time.Duration `mapstructure:"redirect"` // 跳转
+	Read     time.Duration `mapstructure:"read"`     // 查询
+	Write    time.Duration `mapstructure:"write"`    // 创建、更新、删除
+}
+
+// DatabaseConfig 数据库配置
+type DatabaseConfig struct {
+	Driver          string        `mapstructure:"driver"` // 数据库驱动，目前仅支持postgres
+	Host            string        `mapstructure:"host"`
+	Port            int           `mapstructure:"port"`
+	User            string        `mapstructure:"user"`
+	Password        string        `mapstructure:"password"`
+	DBName          string        `mapstructure:"dbname"`
+	SSLMode         string        `mapstructure:"sslmode"`
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`    // 最大空闲连接数
+	MaxOpenConns    int           `mapstructure:"max_open_conns"`    // 最大打开连接数
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"` // 连接最长存活时间，0表示不限制
+}
+
+// DSN 返回 key=value 格式的连接字符串
+func (c DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		quoteDSNValue(c.Host), c.Port, quoteDSNValue(c.User), quoteDSNValue(c.Password),
+		quoteDSNValue(c.DBName), quoteDSNValue(c.SSLMode))
+}
+
+// URL 返回 postgres:// 格式的连接字符串
+func (c DatabaseConfig) URL() string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     fmt.Sprintf("%s:%d", c.Host, c.Port),
+		Path:     c.DBName,
+		RawQuery: url.Values{"sslmode": {c.SSLMode}}.Encode(),
+	}
+	return u.String()
+}
+
+// quoteDSNValue 转义连接字符串中的值，空值或包含空格、引号的值需要加引号
+func quoteDSNValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
+
+// RedisConfig Redis配置
+type RedisConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Password string `mapstructure:"password"`
+	DB       int    `mapstructure:"db"`
+	PoolSize int    `mapstructure:"pool_size"` // 连接池大小，0表示使用默认值
+}
+
+// Addr 返回Redis地址
+func (c RedisConfig) Addr() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+// CacheConfig 缓存配置
+type CacheConfig struct {
+	Driver string            `mapstructure:"driver"` // redis | memory
+	Memory MemoryCacheConfig `mapstructure:"memory"`
+}
+
+// MemoryCacheConfig 内存缓存配置
+type MemoryCacheConfig struct {
+	MaxEntries int `mapstructure:"max_entries"` // 最大缓存条目数
+}
+
+// ClicksConfig 点击数同步配置
+type ClicksConfig struct {
+	FlushInterval  time.Duration `mapstructure:"flush_interval"`   // 同步间隔
+	FlushBatchSize int           `mapstructure:"flush_batch_size"` // 每批同步的短码数量
+}
+
+// ClickLogConfig 点击日志写入配置
+type ClickLogConfig struct {
+	Mode          string            `mapstructure:"mode"`           // queue | stream
+	QueueSize     int               `mapstructure:"queue_size"`     // 队列容量
+	BatchSize     int               `mapstructure:"batch_size"`     // 每批写入的最大条数
+	FlushInterval time.Duration     `mapstructure:"flush_interval"` // 未达到批量大小时的最长等待时间
+	Overflow      string            `mapstructure:"overflow"`       // block | drop | spill
+	BlockTimeout  time.Duration     `mapstructure:"block_timeout"`  // block策略下的最长等待时间
+	SpillPath     string            `mapstructure:"spill_path"`     // spill策略下的溢出文件路径
+	Stream        ClickStreamConfig `mapstructure:"stream"`
+}
+
+// ClickStreamConfig 点击事件流配置
+type ClickStreamConfig struct {
+	Name        string        `mapstructure:"name"`         // 流名称
+	Group       string        `mapstructure:"group"`        // 消费者组名称
+	Consumer    string        `mapstructure:"consumer"`     // 消费者名称
+	DeadLetter  string        `mapstructure:"dead_letter"`  // 死信流名称
+	MaxLen      int64         `mapstructure:"max_len"`      // 流的近似最大长度
+	BatchSize   int           `mapstructure:"batch_size"`   // 每次读取的最大事件数
+	Block       time.Duration `mapstructure:"block"`        // 读取事件时的最长阻塞时间
+	MinIdle     time.Duration `mapstructure:"min_idle"`     // 未确认事件被重新认领的空闲时间
+	MaxRetries  int64         `mapstructure:"max_retries"`  // 最大投递次数
+	RunConsumer bool          `mapstructure:"run_consumer"` // 是否在服务进程内运行消费者
+}
+
+// ShortLinkConfig 短链接配置
+type ShortLinkConfig struct {
+	Domain            string `mapstructure:"domain"`              // 短链接域名
+	CodeLength        int    `mapstructure:"code_length"`         // 短码长度
+	DefaultExpireDays int    `mapstructure:"default_expire_days"` // 默认过期时间(天)，0表示永不过期
+
+	Password LinkPasswordConfig `mapstructure:"password"` // 密码保护的短链接
+}
+
+// LinkPasswordConfig 密码保护短链接的配置
+type LinkPasswordConfig struct {
+	Secret      string        `mapstructure:"secret"`       // 解锁Cookie的签名密钥，为空时启动时随机生成，重启后需要重新输入密码
+	UnlockTTL   time.Duration `mapstructure:"unlock_ttl"`   // 输入密码后免密访问的有效期
+	MaxAttempts int           `mapstructure:"max_attempts"` // 每个IP在锁定窗口内对同一短链接的最大尝试次数，0表示不限制
+	Lockout     time.Duration `mapstructure:"lockout"`      // 锁定窗口
+}
+
+// RateLimitConfig 限流配置，计数保存在缓存中，使用Redis时多个实例共享限额
+type RateLimitConfig struct {
+	Enabled  bool            `mapstructure:"enabled"`  // 是否启用限流
+	API      RateLimitBudget `mapstructure:"api"`      // 管理API，按API Key、用户或客户端IP计数
+	Create   RateLimitBudget `mapstructure:"create"`   // 创建短链接，按API Key、用户或客户端IP计数
+	Redirect RateLimitBudget `mapstructure:"redirect"` // 短链接跳转，按客户端IP计数
+}
+
+// RateLimitBudget 单项限额，滑动窗口内最多允许 requests 个请求，0表示不限制
+type RateLimitBudget struct {
+	Requests int           `mapstructure:"requests"` // 窗口内允许的最大请求数
+	Window   time.Duration `mapstructure:"window"`   // 滑动窗口大小
+}
+
+// QuotasConfig 用量配额配置，个人空间按用户计算，工作空间按空间计算
+type QuotasConfig struct {
+	Enabled   bool        `mapstructure:"enabled"`   // 是否启用配额
+	User      QuotaLimits `mapstructure:"user"`      // 个人空间的配额
+	Workspace QuotaLimits `mapstructure:"workspace"` // 工作空间的配额
+}
+
+// QuotaLimits 单个用户或工作空间的配额，0表示不限制
+type QuotaLimits struct {
+	MaxActiveLinks   int64 `mapstructure:"max_active_links"`   // 未过期的短链接数上限
+	MaxMonthlyClicks int64 `mapstructure:"max_monthly_clicks"` // 每月记录访问日志的点击数上限
+}
+
+// MeteringConfig 用量计量配置，按工作空间和日期汇总创建数、跳转数和访问日志数
+type MeteringConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`  // 是否计量跳转次数并定期汇总
+	Interval time.Duration `mapstructure:"interval"` // 汇总间隔
+}
+
+// renamedKeys 已重命名的配置项
+var renamedKeys = map[string]string{
+	"server.enable_rate_limit": "ratelimit.enabled",
+	"server.rate_limit":        "ratelimit.api.requests", // 原为每秒请求数，现在配合 ratelimit.api.window 使用
+	"shortlink.length":         "shortlink.code_length",
+	"shortlink.expiration":     "shortlink.default_expire_days",
+	"ratelimit.requests":       "ratelimit.api.requests",
+	"ratelimit.duration":       "ratelimit.api.window",
+}
+
+// setDefaults 设置默认值
+// 所有配置项都需要设置默认值，环境变量才能覆盖配置文件中未出现的配置项
+func setDefaults(v *viper.Viper) {
+	defaults := map[string]interface{}{
+		"server.port":             8080,
+		"server.mode":             "release",
+		"server.shutdown_timeout": 15 * time.Second,
+		"server.max_body_size":    4,
+		"server.trusted_proxies":  []string{},
+
+		"health.timeout":  2 * time.Second,
+		"metrics.enabled": true,
+		"log.level":       "",
+		"auth.enabled":    true,
+
+		"auth.jwt.enabled":          false,
+		"auth.jwt.jwks_file":        "",
+		"auth.jwt.jwks_url":         "",
+		"auth.jwt.refresh_interval": time.Hour,
+		"auth.jwt.issuer":           "",
+		"auth.jwt.audience":         "",
+		"auth.jwt.leeway":           30 * time.Second,
+		"auth.jwt.user_claim":       "sub",
+		"auth.jwt.workspace_claim":  "workspace_id",
+		"auth.jwt.scope_claim":      "scope",
+		"auth.jwt.default_scopes":   []string{"read", "write"},
+
+		"timeouts.redirect": 500 * time.Millisecond,
+		"timeouts.read":     3 * time.Second,
+		"timeouts.write":    5 * time.Second,
+
+		"database.driver":            "postgres",
+		"database.host":              "localhost",
+		"database.port":              5432,
+		"database.user":              "postgres",
+		"database.password":          "",
+		"database.dbname":            "linkit",
+		"database.sslmode":           "disable",
+		"database.max_idle_conns":    10,
+		"database.max_open_conns":    25,
+		"database.conn_max_lifetime": time.Duration(0),
+
+		"redis.host":      "localhost",
+		"redis.port":      6379,
+		"redis.password":  "",
+		"redis.db":        0,
+		"redis.pool_size": 0,
+
+		"cache.driver":             "redis",
+		"cache.memory.max_entries": 100000,
+
+		"clicks.flush_interval":   10 * time.Second,
+		"clicks.flush_batch_size": 500,
+
+		"clicklog.mode":                "queue",
+		"clicklog.queue_size":          10000,
+		"clicklog.batch_size":          500,
+		"clicklog.flush_interval":      time.Second,
+		"clicklog.overflow":            "block",
+		"clicklog.block_timeout":       100 * time.Millisecond,
+		"clicklog.spill_path":          "data/click_logs.spill",
+		"clicklog.stream.name":         "linkit:clicks",
+		"clicklog.stream.group":        "linkit-click-writers",
+		"clicklog.stream.consumer":     "",
+		"clicklog.stream.dead_letter":  "linkit:clicks:dead",
+		"clicklog.stream.max_len":      1000000,
+		"clicklog.stream.batch_size":   500,
+		"clicklog.stream.block":        2 * time.Second,
+		"clicklog.stream.min_idle":     time.Minute,
+		"clicklog.stream.max_retries":  5,
+		"clicklog.stream.run_consumer": true,
+
+		"shortlink.domain":                "http://localhost:8080",
+		"shortlink.code_length":           6,
+		"shortlink.default_expire_days":   0,
+		"shortlink.password.secret":       "",
+		"shortlink.password.unlock_ttl":   time.Hour,
+		"shortlink.password.max_attempts": 5,
+		"shortlink.password.lockout":      15 * time.Minute,
+
+		"ratelimit.enabled":           false,
+		"ratelimit.api.requests":      1000,
+		"ratelimit.api.window":        time.Minute,
+		"ratelimit.create.requests":   60,
+		"ratelimit.create.window":     time.Minute,
+		"ratelimit.redirect.requests": 600,
+		"ratelimit.redirect.window":   time.Minute,
+
+		"quotas.enabled":                      false,
+		"quotas.user.max_active_links":        10000,
+		"quotas.user.max_monthly_clicks":      1000000,
+		"quotas.workspace.max_active_links":   10000,
+		"quotas.workspace.max_monthly_clicks": 1000000,
+
+		"metering.enabled":  true,
+		"metering.interval": 5 * time.Minute,
+	}
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+}
+
+// Load 加载配置
+// path为空时依次使用环境变量 LINKIT_CONFIG 和 ./configs/config.yaml；
+// 配置文件中的值可被环境变量和 *_FILE 指向的密钥文件覆盖
+func Load(path string) (*Config, error) {
+	v := viper.New()
+	setDefaults(v)
+
+	if path == "" {
+		path = os.Getenv(EnvPrefix + "_CONFIG")
+	}
+	if path != "" {
+		v.SetConfigFile(path)
+	} else {
+		v.SetConfigName("config")
+		v.SetConfigType("yaml")
+		v.AddConfigPath("./configs")
+	}
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	for old, key := range renamedKeys {
+		if v.InConfig(old) {
+			return nil, fmt.Errorf("config key %s has been renamed to %s", old, key)
+		}
+	}
+
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	if err := applySecretFiles(v); err != nil {
+		return nil, err
+	}
+
+	// 拒绝未知的配置项，避免拼写错误的配置被静默忽略
+	var cfg Config
+	if err := v.UnmarshalExact(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// envName 返回配置项对应的环境变量名
+func envName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// applySecretFiles 读取 *_FILE 环境变量指向的文件内容覆盖对应的配置项，用于挂载的密钥文件
+func applySecretFiles(v *viper.Viper) error {
+	for _, key := range v.AllKeys() {
+		env := envName(key) + secretFileSuffix
+		path := os.Getenv(env)
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", env, err)
+		}
+		v.Set(key, strings.TrimRight(string(data), "\r\n"))
+	}
+	return nil
+}
+
+// Validate 校验配置，返回所有不合法的配置项
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port must be between 1 and 65535, got %d", c.Server.Port)
+	check(oneOf(c.Server.Mode, "debug", "release", "test"), "server.mode must be one of debug, release, test, got %q", c.Server.Mode)
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	check(c.Server.MaxBodySize >= 0, "server.max_body_size must not be negative")
+	check(c.Health.Timeout > 0, "health.timeout must be positive")
+	check(oneOf(c.Log.Level, "", "debug", "info", "warn", "error"), "log.level must be one of debug, info, warn, error, got %q", c.Log.Level)
+	if jwt := c.Auth.JWT; jwt.Enabled {
+		check((jwt.JWKSFile == "") != (jwt.JWKSURL == ""), "exactly one of auth.jwt.jwks_file and auth.jwt.jwks_url is required")
+		if jwt.JWKSURL != "" {
+			u, err := url.Parse(jwt.JWKSURL)
+			check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+				"auth.jwt.jwks_url must be an absolute http(s) URL, got %q", jwt.JWKSURL)
+		}
+		check(jwt.RefreshInterval >= time.Minute, "auth.jwt.refresh_interval must be at least 1m")
+		check(jwt.Leeway >= 0, "auth.jwt.leeway must not be negative")
+		check(jwt.UserClaim != "", "auth.jwt.user_claim is required")
+		subjects := make(map[string]bool, len(jwt.UserMap))
+		for i, m := range jwt.UserMap {
+			check(m.Subject != "", "auth.jwt.user_map[%d].subject is required", i)
+			check(m.UserID > 0, "auth.jwt.user_map[%d].user_id must be positive", i)
+			check(!subjects[m.Subject], "auth.jwt.user_map[%d].subject %q is duplicated", i, m.Subject)
+			subjects[m.Subject] = true
+		}
+		check(jwt.ScopeClaim != "", "auth.jwt.scope_claim is required")
+		for _, s := range jwt.DefaultScopes {
+			check(oneOf(s, "read", "write", "admin"), "auth.jwt.default_scopes must only contain read, write, admin, got %q", s)
+		}
+	}
+	check(c.Timeouts.Redirect >= 0 && c.Timeouts.Read >= 0 && c.Timeouts.Write >= 0, "timeouts must not be negative")
+
+	check(c.Database.Driver == "postgres", "database.driver must be postgres, got %q", c.Database.Driver)
+	check(c.Database.Host != "", "database.host is required")
+	check(c.Database.Port > 0 && c.Database.Port <= 65535, "database.port must be between 1 and 65535, got %d", c.Database.Port)
+	check(c.Database.User != "", "database.user is required")
+	check(c.Database.DBName != "", "database.dbname is required")
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns must not be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.max_idle_conns (%d) must not exceed database.max_open_conns (%d)", c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+
+	check(oneOf(c.Cache.Driver, "redis", "memory"), "cache.driver must be redis or memory, got %q", c.Cache.Driver)
+	check(oneOf(c.ClickLog.Mode, "queue", "stream"), "clicklog.mode must be queue or stream, got %q", c.ClickLog.Mode)
+	if c.Cache.Driver == "redis" || c.ClickLog.Mode == "stream" {
+		check(c.Redis.Host != "", "redis.host is required")
+		check(c.Redis.Port > 0 && c.Redis.Port <= 65535, "redis.port must be between 1 and 65535, got %d", c.Redis.Port)
+		check(c.Redis.PoolSize >= 0, "redis.pool_size must not be negative")
+	}
+	check(c.Cache.Memory.MaxEntries >= 0, "cache.memory.max_entries must not be negative")
+
+	check(c.Clicks.FlushInterval > 0, "clicks.flush_interval must be positive")
+	check(c.Clicks.FlushBatchSize > 0, "clicks.flush_batch_size must be positive")
+	check(c.ClickLog.QueueSize > 0, "clicklog.queue_size must be positive")
+	check(c.ClickLog.BatchSize > 0, "clicklog.batch_size must be positive")
+	check(oneOf(c.ClickLog.Overflow, "block", "drop", "spill"), "clicklog.overflow must be one of block, drop, spill, got %q", c.ClickLog.Overflow)
+	check(c.ClickLog.Overflow != "spill" || c.ClickLog.SpillPath != "", "clicklog.spill_path is required when clicklog.overflow is spill")
+
+	check(c.ShortLink.CodeLength >= 4 && c.ShortLink.CodeLength <= 16, "shortlink.code_length must be between 4 and 16, got %d", c.ShortLink.CodeLength)
+	check(c.ShortLink.DefaultExpireDays >= 0, "shortlink.default_expire_days must not be negative")
+	if c.ShortLink.Domain != "" {
+		u, err := url.Parse(c.ShortLink.Domain)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"shortlink.domain must be an absolute http(s) URL, got %q", c.ShortLink.Domain)
+	}
+	check(c.ShortLink.Password.UnlockTTL >= time.Minute, "shortlink.password.unlock_ttl must be at least 1m")
+	check(c.ShortLink.Password.MaxAttempts >= 0, "shortlink.password.max_attempts must not be negative")
+	check(c.ShortLink.Password.MaxAttempts == 0 || c.ShortLink.Password.Lockout >= time.Second, "shortlink.password.lockout must be at least 1s")
+	check(c.ShortLink.Password.Secret == "" || len(c.ShortLink.Password.Secret) >= 32, "shortlink.password.secret must be at least 32 bytes")
+
+	if c.RateLimit.Enabled {
+		for _, b := range []struct {
+			name   string
+			budget RateLimitBudget
+		}{{"api", c.RateLimit.API}, {"create", c.RateLimit.Create}, {"redirect", c.RateLimit.Redirect}} {
+			check(b.budget.Requests >= 0, "ratelimit.%s.requests must not be negative", b.name)
+			check(b.budget.Requests == 0 || b.budget.Window >= time.Second, "ratelimit.%s.window must be at least 1s", b.name)
+		}
+	}
+
+	for _, q := range []struct {
+		name   string
+		limits QuotaLimits
+	}{{"user", c.Quotas.User}, {"workspace", c.Quotas.Workspace}} {
+		check(q.limits.MaxActiveLinks >= 0, "quotas.%s.max_active_links must not be negative", q.name)
+		check(q.limits.MaxMonthlyClicks >= 0, "quotas.%s.max_monthly_clicks must not be negative", q.name)
+	}
+
+	if c.Metering.Enabled {
+		check(c.Metering.Interval >= 10*time.Second, "metering.interval must be at least 10s")
+	}
+
+	return errors.Join(errs...)
+}
+
+// oneOf 判断值是否在候选列表中
+func oneOf(v string, candidates ...string) bool {
+	for _, c := range candidates {
+		if v == c {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig 在临时目录中写入配置文件并返回路径
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load(writeConfig(t, "server:\n  port: 9000\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Server.Port != 9000 {
+		t.Errorf("Server.Port = %d, want 9000", cfg.Server.Port)
+	}
+	if cfg.Server.Mode != "release" || cfg.Server.ShutdownTimeout != 15*time.Second {
+		t.Errorf("Server = %+v, want release mode and 15s shutdown timeout", cfg.Server)
+	}
+	if cfg.Database.Host != "localhost" || cfg.Database.Port != 5432 || cfg.Database.DBName != "linkit" {
+		t.Errorf("Database = %+v, want localhost:5432/linkit", cfg.Database)
+	}
+	if cfg.ShortLink.CodeLength != 6 || cfg.ShortLink.Password.MaxAttempts != 5 {
+		t.Errorf("ShortLink = %+v, want code length 6 and 5 password attempts", cfg.ShortLink)
+	}
+	if cfg.RateLimit.API.Requests != 1000 || cfg.RateLimit.API.Window != time.Minute {
+		t.Errorf("RateLimit.API = %+v, want 1000 per minute", cfg.RateLimit.API)
+	}
+	if len(cfg.Auth.JWT.DefaultScopes) != 2 {
+		t.Errorf("Auth.JWT.DefaultScopes = %v, want read, write", cfg.Auth.JWT.DefaultScopes)
+	}
+}
+
+func TestLoadShippedConfigs(t *testing.T) {
+	for _, name := range []string{"config.yaml", "config.example.yaml"} {
+		if _, err := Load(filepath.Join("..", "..", "configs", name)); err != nil {
+			t.Errorf("Load(%s) error = %v", name, err)
+		}
+	}
+}
+
+func TestLoadEnvOverrides(t *testing.T) {
+	path := writeConfig(t, "server:\n  port: 9000\ndatabase:\n  host: db.internal\n")
+	t.Setenv("LINKIT_SERVER_PORT", "9100")
+	t.Setenv("LINKIT_REDIS_HOST", "cache.internal")
+	t.Setenv("LINKIT_CLICKLOG_STREAM_BATCH_SIZE", "50")
+	t.Setenv("LINKIT_TIMEOUTS_READ", "1s")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	// 环境变量覆盖配置文件中的值，也覆盖配置文件中未出现的配置项
+	if cfg.Server.Port != 9100 {
+		t.Errorf("Server.Port = %d, want 9100", cfg.Server.Port)
+	}
+	if cfg.Database.Host != "db.internal" {
+		t.Errorf("Database.Host = %q, want db.internal", cfg.Database.Host)
+	}
+	if cfg.Redis.Host != "cache.internal" {
+		t.Errorf("Redis.Host = %q, want cache.internal", cfg.Redis.Host)
+	}
+	if cfg.ClickLog.Stream.BatchSize != 50 {
+		t.Errorf("ClickLog.Stream.BatchSize = %d, want 50", cfg.ClickLog.Stream.BatchSize)
+	}
+	if cfg.Timeouts.Read != time.Second {
+		t.Errorf("Timeouts.Read = %v, want 1s", cfg.Timeouts.Read)
+	}
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	path := writeConfig(t, "database:\n  password: from-file\n")
+	secret := filepath.Join(t.TempDir(), "db_password")
+	if err := os.WriteFile(secret, []byte("s3cret value\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	t.Setenv("LINKIT_DATABASE_PASSWORD", "from-env")
+	t.Setenv("LINKIT_DATABASE_PASSWORD_FILE", secret)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	// 密钥文件优先于环境变量，去掉末尾换行
+	if cfg.Database.Password != "s3cret value" {
+		t.Errorf("Database.Password = %q, want %q", cfg.Database.Password, "s3cret value")
+	}
+
+	t.Setenv("LINKIT_DATABASE_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "LINKIT_DATABASE_PASSWORD_FILE") {
+		t.Errorf("Load() with missing secret file error = %v, want error naming the variable", err)
+	}
+}
+
+func TestLoadConfigPath(t *testing.T) {
+	fromEnv := writeConfig(t, "server:\n  port: 9001\n")
+	fromFlag := writeConfig(t, "server:\n  port: 9002\n")
+	t.Setenv("LINKIT_CONFIG", fromEnv)
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Server.Port != 9001 {
+		t.Errorf("Load() from LINKIT_CONFIG port = %d, want 9001", cfg.Server.Port)
+	}
+
+	// --config 优先于 LINKIT_CONFIG
+	cfg, err = Load(fromFlag)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Server.Port != 9002 {
+		t.Errorf("Load(--config) port = %d, want 9002", cfg.Server.Port)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Load() with missing config file error = nil")
+	}
+}
+
+func TestLoadRejectsUnknownAndRenamedKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unknown key", "server:\n  prot: 8080\n", "prot"},
+		{"unknown section", "ratelimits:\n  enabled: true\n", "ratelimits"},
+		{"unknown nested key", "clicklog:\n  stream:\n    max_length: 10\n", "max_length"},
+		{"renamed code length", "shortlink:\n  length: 6\n", "shortlink.code_length"},
+		{"renamed rate limit switch", "server:\n  enable_rate_limit: true\n", "ratelimit.enabled"},
+		{"renamed rate limit", "server:\n  rate_limit: 10\n", "ratelimit.api.requests"},
+		{"renamed rate limit window", "ratelimit:\n  duration: 1m\n", "ratelimit.api.window"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want error mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := func(t *testing.T) *Config {
+		t.Helper()
+		cfg, err := Load(writeConfig(t, "{}\n"))
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		return cfg
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   string
+	}{
+		{"port", func(c *Config) { c.Server.Port = 70000 }, "server.port"},
+		{"mode", func(c *Config) { c.Server.Mode = "prod" }, "server.mode"},
+		{"cache driver", func(c *Config) { c.Cache.Driver = "memcached" }, "cache.driver"},
+		{"spill path", func(c *Config) { c.ClickLog.Overflow = "spill"; c.ClickLog.SpillPath = "" }, "clicklog.spill_path"},
+		{"idle conns", func(c *Config) { c.Database.MaxOpenConns = 5; c.Database.MaxIdleConns = 10 }, "database.max_idle_conns"},
+		{"domain", func(c *Config) { c.ShortLink.Domain = "example.com" }, "shortlink.domain"},
+		{"password secret", func(c *Config) { c.ShortLink.Password.Secret = "short" }, "shortlink.password.secret"},
+		{"jwks source", func(c *Config) { c.Auth.JWT.Enabled = true }, "auth.jwt.jwks_file"},
+		{"jwt user map", func(c *Config) {
+			c.Auth.JWT.Enabled = true
+			c.Auth.JWT.JWKSFile = "jwks.json"
+			c.Auth.JWT.UserMap = []JWTUserMap{{Subject: "alice", UserID: 1}, {Subject: "alice", UserID: 2}}
+		}, "auth.jwt.user_map[1].subject"},
+		{"rate limit window", func(c *Config) { c.RateLimit.Enabled = true; c.RateLimit.Redirect.Window = 0 }, "ratelimit.redirect.window"},
+		{"quota", func(c *Config) { c.Quotas.Workspace.MaxActiveLinks = -1 }, "quotas.workspace.max_active_links"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid(t)
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want error mentioning %q", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("reports every invalid key", func(t *testing.T) {
+		cfg := valid(t)
+		cfg.Server.Port = 0
+		cfg.Database.Host = ""
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), "server.port") || !strings.Contains(err.Error(), "database.host") {
+			t.Errorf("Validate() error = %v, want both server.port and database.host", err)
+		}
+	})
+}
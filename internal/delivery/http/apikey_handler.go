@@ -0,0 +1,127 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"linkit/internal/delivery/http/middleware"
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// APIKeyHandler 处理API Key相关的HTTP请求
+type APIKeyHandler struct {
+	useCase domain.APIKeyUseCase
+	logger  *zap.Logger
+}
+
+// NewAPIKeyHandler 创建API Key处理器
+func NewAPIKeyHandler(useCase domain.APIKeyUseCase, logger *zap.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		useCase: useCase,
+		logger:  logger.Named("handler"),
+	}
+}
+
+// Register 注册API路由，API Key管理需要 admin 权限
+func (h *APIKeyHandler) Register(r *gin.RouterGroup) {
+	keys := r.Group("/api-keys", middleware.RequireScope(domain.ScopeAdmin))
+	keys.GET("", h.List)
+	keys.POST("", h.Create)
+	keys.DELETE("/:id", h.Revoke)
+}
+
+// RegisterRoot 注册根路由
+func (h *APIKeyHandler) RegisterRoot(r *gin.Engine) {}
+
+// handleError 统一错误处理
+func (h *APIKeyHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidScope):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400006,
+			"message": "无效的权限范围",
+			"details": "权限范围只能是 read、write 或 admin",
+		})
+	case errors.Is(err, domain.ErrInvalidExpiration):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400007,
+			"message": "无效的过期时间",
+			"details": "过期时间不能早于当前时间",
+		})
+	case errors.Is(err, domain.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404002,
+			"message": "API Key不存在",
+			"details": "请检查API Key ID是否正确",
+		})
+	case errors.Is(err, domain.ErrUnauthorized):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401001,
+			"message": "未认证",
+			"details": "请在 Authorization 头中携带有效的API Key: Bearer <key>",
+		})
+	case errors.Is(err, domain.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":    403002,
+			"message": "权限不足",
+			"details": "当前API Key无权执行该操作",
+		})
+	default:
+		if handleContextError(c, err) {
+			return
+		}
+		logger.FromContext(c.Request.Context(), h.logger).Error("request failed",
+			zap.String("path", c.FullPath()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500001,
+			"message": "服务器内部错误",
+			"details": "请稍后重试，如果问题持续存在请联系管理员",
+		})
+	}
+}
+
+// List 获取API Key列表
+func (h *APIKeyHandler) List(c *gin.Context) {
+	keys, err := h.useCase.List(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// Create 创建API Key，完整密钥只在响应中返回一次
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var input domain.CreateAPIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求参数", "details": err.Error()})
+		return
+	}
+
+	key, err := h.useCase.Create(c.Request.Context(), &input)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, key)
+}
+
+// Revoke 吊销API Key
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的API Key ID", "details": err.Error()})
+		return
+	}
+
+	if err := h.useCase.Revoke(c.Request.Context(), uint(id)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
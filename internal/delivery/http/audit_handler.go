@@ -0,0 +1,133 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"linkit/internal/delivery/http/middleware"
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AuditHandler 处理审计日志相关的HTTP请求
+type AuditHandler struct {
+	useCase domain.AuditUseCase
+	logger  *zap.Logger
+}
+
+// NewAuditHandler 创建审计日志处理器
+func NewAuditHandler(useCase domain.AuditUseCase, logger *zap.Logger) *AuditHandler {
+	return &AuditHandler{
+		useCase: useCase,
+		logger:  logger.Named("handler"),
+	}
+}
+
+// Register 注册API路由
+func (h *AuditHandler) Register(r *gin.RouterGroup) {
+	r.GET("/audit", middleware.RequireScope(domain.ScopeRead), h.List)
+}
+
+// RegisterRoot 注册根路由
+func (h *AuditHandler) RegisterRoot(r *gin.Engine) {}
+
+// handleError 统一错误处理
+func (h *AuditHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrUnauthorized):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401001,
+			"message": "未认证",
+			"details": "请在 Authorization 头中携带有效的API Key: Bearer <key>",
+		})
+	case errors.Is(err, domain.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":    403003,
+			"message": "权限不足",
+			"details": "只有工作空间所有者可以查看审计日志",
+		})
+	default:
+		if handleContextError(c, err) {
+			return
+		}
+		logger.FromContext(c.Request.Context(), h.logger).Error("request failed",
+			zap.String("path", c.FullPath()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500001,
+			"message": "服务器内部错误",
+			"details": "请稍后重试，如果问题持续存在请联系管理员",
+		})
+	}
+}
+
+// List 查询审计日志，支持按短码、操作者、操作类型和时间范围过滤
+func (h *AuditHandler) List(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400004,
+			"message": "无效的页码",
+			"details": "页码必须是大于0的整数",
+		})
+		return
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400005,
+			"message": "无效的每页数量",
+			"details": "每页数量必须是1-100之间的整数",
+		})
+		return
+	}
+
+	filter := &domain.AuditFilter{}
+	if code := c.Query("code"); code != "" {
+		filter.ShortCode = &code
+	}
+	if action := c.Query("action"); action != "" {
+		a := domain.AuditAction(action)
+		filter.Action = &a
+	}
+	if actorStr := c.Query("actor_id"); actorStr != "" {
+		actorID, err := strconv.ParseUint(actorStr, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的操作者ID", "details": err.Error()})
+			return
+		}
+		id := uint(actorID)
+		filter.ActorID = &id
+	}
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"start_time", &filter.StartTime}, {"end_time", &filter.EndTime}} {
+		value := c.Query(p.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的时间格式", "details": p.name + " 必须是RFC3339格式"})
+			return
+		}
+		*p.dst = &t
+	}
+
+	logs, err := h.useCase.List(c.Request.Context(), &domain.AuditQuery{
+		Page:     page,
+		PageSize: pageSize,
+		Filter:   filter,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, logs)
+}
@@ -0,0 +1,27 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleContextError 处理超时和请求取消错误，已处理时返回true
+func handleContextError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"code":    504001,
+			"message": "请求处理超时",
+			"details": "服务繁忙，请稍后重试",
+		})
+		return true
+	case errors.Is(err, context.Canceled):
+		// 客户端已断开连接，无需返回响应内容
+		c.AbortWithStatus(499)
+		return true
+	}
+	return false
+}
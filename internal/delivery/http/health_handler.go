@@ -0,0 +1,143 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultHealthTimeout 单个依赖检查的默认超时时间
+const defaultHealthTimeout = 2 * time.Second
+
+// 健康状态
+const (
+	HealthStatusUp          = "up"
+	HealthStatusDown        = "down"
+	HealthStatusOK          = "ok"
+	HealthStatusDegraded    = "degraded"
+	HealthStatusUnavailable = "unavailable"
+)
+
+// HealthCheck 表示一个依赖的健康检查
+type HealthCheck struct {
+	Name     string                          // 依赖名称
+	Critical bool                            // 是否为跳转路径必需的依赖，失败时就绪检查返回503
+	Check    func(ctx context.Context) error // 检查函数
+}
+
+// ComponentHealth 表示单个依赖的检查结果
+type ComponentHealth struct {
+	Status    string  `json:"status"`          // up | down
+	Critical  bool    `json:"critical"`        // 是否为必需依赖
+	LatencyMS float64 `json:"latency_ms"`      // 检查耗时(毫秒)
+	Error     string  `json:"error,omitempty"` // 失败原因
+}
+
+// HealthHandler 处理存活和就绪检查
+type HealthHandler struct {
+	checks  []HealthCheck
+	timeout time.Duration
+}
+
+// NewHealthHandler 创建健康检查处理器
+func NewHealthHandler(timeout time.Duration, checks ...HealthCheck) *HealthHandler {
+	if timeout <= 0 {
+		timeout = defaultHealthTimeout
+	}
+	return &HealthHandler{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// Register 注册API路由
+func (h *HealthHandler) Register(r *gin.RouterGroup) {}
+
+// RegisterRoot 注册根路由
+func (h *HealthHandler) RegisterRoot(r *gin.Engine) {
+	r.GET("/health", h.Ready)
+	r.GET("/health/live", h.Live)
+	r.GET("/health/ready", h.Ready)
+}
+
+// Live 存活检查，进程能够处理请求即返回200，不检查外部依赖
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": HealthStatusOK,
+	})
+}
+
+// Ready 就绪检查，并发检查所有依赖
+// 必需依赖不可用时返回503；仅非必需依赖不可用时返回200并标记为degraded
+func (h *HealthHandler) Ready(c *gin.Context) {
+	results := make(map[string]ComponentHealth, len(h.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+			result := h.run(c.Request.Context(), check)
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	status := HealthStatusOK
+	for _, result := range results {
+		if result.Status == HealthStatusUp {
+			continue
+		}
+		if result.Critical {
+			status = HealthStatusUnavailable
+			break
+		}
+		status = HealthStatusDegraded
+	}
+
+	code := http.StatusOK
+	if status == HealthStatusUnavailable {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{
+		"status":     status,
+		"components": results,
+	})
+}
+
+// run 在超时时间内执行单个检查
+func (h *HealthHandler) run(ctx context.Context, check HealthCheck) ComponentHealth {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	started := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- check.Check(ctx)
+	}()
+
+	// 检查函数不响应取消时也按超时返回
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := ComponentHealth{
+		Status:    HealthStatusUp,
+		Critical:  check.Critical,
+		LatencyMS: float64(time.Since(started).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = HealthStatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,22 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsHandler 暴露Prometheus指标
+type MetricsHandler struct{}
+
+// NewMetricsHandler 创建指标处理器
+func NewMetricsHandler() *MetricsHandler {
+	return &MetricsHandler{}
+}
+
+// Register 注册API路由
+func (h *MetricsHandler) Register(r *gin.RouterGroup) {}
+
+// RegisterRoot 注册根路由
+func (h *MetricsHandler) RegisterRoot(r *gin.Engine) {
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Authenticator 校验请求携带的凭证并返回调用者
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*domain.Principal, error)
+}
+
+// tokenAuthenticator 按凭证格式选择认证方式
+type tokenAuthenticator struct {
+	apiKeys Authenticator
+	jwt     Authenticator
+}
+
+// Tokens 组合API Key和JWT认证，由三段以 . 分隔的凭证按JWT校验，其余按API Key校验
+func Tokens(apiKeys, jwt Authenticator) Authenticator {
+	return &tokenAuthenticator{apiKeys: apiKeys, jwt: jwt}
+}
+
+// Authenticate 校验凭证并返回调用者
+func (a *tokenAuthenticator) Authenticate(ctx context.Context, token string) (*domain.Principal, error) {
+	if strings.Count(token, ".") == 2 {
+		return a.jwt.Authenticate(ctx, token)
+	}
+	return a.apiKeys.Authenticate(ctx, token)
+}
+
+// bearerToken 从 Authorization 头中解析 Bearer 凭证
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// unauthorized 返回401响应
+func unauthorized(c *gin.Context) {
+	c.Header("WWW-Authenticate", `Bearer realm="linkit"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"code":    401001,
+		"message": "未认证",
+		"details": "请在 Authorization 头中携带有效的API Key: Bearer <key>",
+	})
+}
+
+// Auth 认证中间件，校验 Authorization: Bearer <key> 并将调用者写入请求context
+func Auth(authenticator Authenticator, log *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := bearerToken(c)
+		if token == "" {
+			unauthorized(c)
+			return
+		}
+
+		principal, err := authenticator.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			if errors.Is(err, domain.ErrUnauthorized) {
+				unauthorized(c)
+				return
+			}
+			logger.FromContext(c.Request.Context(), log).Error("failed to authenticate request", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"code":    503001,
+				"message": "认证服务暂不可用",
+				"details": "请稍后重试",
+			})
+			return
+		}
+
+		principal.IP = c.ClientIP()
+		c.Request = c.Request.WithContext(domain.WithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// Anonymous 未启用认证时使用，将所有请求视为管理员
+func Anonymous() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := &domain.Principal{Scopes: []domain.Scope{domain.ScopeAdmin}, IP: c.ClientIP()}
+		c.Request = c.Request.WithContext(domain.WithPrincipal(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// RequireScope 权限检查中间件，需在 Auth 之后注册
+func RequireScope(scope domain.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := domain.PrincipalFromContext(c.Request.Context())
+		if !ok {
+			unauthorized(c)
+			return
+		}
+		if !principal.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code":    403002,
+				"message": "权限不足",
+				"details": "当前API Key缺少 " + string(scope) + " 权限",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"linkit/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// stubAuthenticator 按凭证返回固定的调用者或错误
+type stubAuthenticator struct {
+	principals map[string]*domain.Principal
+	err        error
+	calls      []string
+}
+
+func (a *stubAuthenticator) Authenticate(ctx context.Context, token string) (*domain.Principal, error) {
+	a.calls = append(a.calls, token)
+	if a.err != nil {
+		return nil, a.err
+	}
+	p, ok := a.principals[token]
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+	copied := *p
+	return &copied, nil
+}
+
+// newAuthRouter 创建注册了认证和权限中间件的路由，处理函数返回调用者的用户ID
+func newAuthRouter(auth gin.HandlerFunc, scope domain.Scope) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", auth, RequireScope(scope), func(c *gin.Context) {
+		p, _ := domain.PrincipalFromContext(c.Request.Context())
+		c.JSON(http.StatusOK, gin.H{"user_id": p.UserID, "ip": p.IP})
+	})
+	return r
+}
+
+// serve 发送带 Authorization 头的请求
+func serve(r *gin.Engine, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.10:1234"
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuth(t *testing.T) {
+	stub := &stubAuthenticator{principals: map[string]*domain.Principal{
+		"lk_reader": {UserID: 1, Scopes: []domain.Scope{domain.ScopeRead}},
+	}}
+	r := newAuthRouter(Auth(stub, zap.NewNop()), domain.ScopeRead)
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+	}{
+		{"valid", "Bearer lk_reader", http.StatusOK},
+		{"scheme is case insensitive", "bearer lk_reader", http.StatusOK},
+		{"missing header", "", http.StatusUnauthorized},
+		{"wrong scheme", "Basic lk_reader", http.StatusUnauthorized},
+		{"empty token", "Bearer ", http.StatusUnauthorized},
+		{"no scheme", "lk_reader", http.StatusUnauthorized},
+		{"unknown token", "Bearer lk_unknown", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, tt.authorization)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 response without WWW-Authenticate header")
+			}
+			if w.Code == http.StatusOK && w.Body.String() != `{"ip":"192.0.2.10","user_id":1}` {
+				t.Errorf("body = %s, want the authenticated principal with the client IP", w.Body)
+			}
+		})
+	}
+
+	// 认证服务不可用时返回503，不按未认证处理
+	stub.err = errors.New("connection refused")
+	if w := serve(r, "Bearer lk_reader"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status with failing authenticator = %d, want 503", w.Code)
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	stub := &stubAuthenticator{principals: map[string]*domain.Principal{
+		"read":  {UserID: 1, Scopes: []domain.Scope{domain.ScopeRead}},
+		"write": {UserID: 2, Scopes: []domain.Scope{domain.ScopeWrite}},
+		"admin": {UserID: 3, Scopes: []domain.Scope{domain.ScopeAdmin}},
+		"none":  {UserID: 4},
+	}}
+
+	// read ⊂ write ⊂ admin
+	tests := []struct {
+		token    string
+		required domain.Scope
+		want     int
+	}{
+		{"read", domain.ScopeRead, http.StatusOK},
+		{"read", domain.ScopeWrite, http.StatusForbidden},
+		{"read", domain.ScopeAdmin, http.StatusForbidden},
+		{"write", domain.ScopeRead, http.StatusOK},
+		{"write", domain.ScopeWrite, http.StatusOK},
+		{"write", domain.ScopeAdmin, http.StatusForbidden},
+		{"admin", domain.ScopeRead, http.StatusOK},
+		{"admin", domain.ScopeWrite, http.StatusOK},
+		{"admin", domain.ScopeAdmin, http.StatusOK},
+		{"none", domain.ScopeRead, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.token+" requires "+string(tt.required), func(t *testing.T) {
+			r := newAuthRouter(Auth(stub, zap.NewNop()), tt.required)
+			if w := serve(r, "Bearer "+tt.token); w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+
+	t.Run("without auth", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.GET("/", RequireScope(domain.ScopeRead), func(c *gin.Context) { c.Status(http.StatusOK) })
+		if w := serve(r, ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want 401", w.Code)
+		}
+	})
+
+	t.Run("anonymous is admin", func(t *testing.T) {
+		r := newAuthRouter(Anonymous(), domain.ScopeAdmin)
+		if w := serve(r, ""); w.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200", w.Code)
+		}
+	})
+}
+
+func TestTokens(t *testing.T) {
+	apiKeys := &stubAuthenticator{principals: map[string]*domain.Principal{"lk_key": {UserID: 1}}}
+	jwt := &stubAuthenticator{principals: map[string]*domain.Principal{"a.b.c": {UserID: 2}}}
+	auth := Tokens(apiKeys, jwt)
+
+	if p, err := auth.Authenticate(context.Background(), "lk_key"); err != nil || p.UserID != 1 {
+		t.Errorf("Authenticate(api key) = %+v, %v", p, err)
+	}
+	if p, err := auth.Authenticate(context.Background(), "a.b.c"); err != nil || p.UserID != 2 {
+		t.Errorf("Authenticate(jwt) = %+v, %v", p, err)
+	}
+	if _, err := auth.Authenticate(context.Background(), "a.b"); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("Authenticate(a.b) error = %v, want ErrUnauthorized", err)
+	}
+	if len(apiKeys.calls) != 2 || len(jwt.calls) != 1 {
+		t.Errorf("api key calls = %v, jwt calls = %v", apiKeys.calls, jwt.calls)
+	}
+}
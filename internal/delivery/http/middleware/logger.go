@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"time"
+
+	"linkit/internal/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AccessLog 访问日志中间件，以结构化字段记录每个请求，需注册在 RequestID 之后
+func AccessLog(log *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		fields := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.String("route", c.FullPath()),
+			zap.Int("status", c.Writer.Status()),
+			zap.Duration("latency", time.Since(start)),
+			zap.String("client_ip", c.ClientIP()),
+			zap.Int("size", c.Writer.Size()),
+		}
+		if len(c.Errors) > 0 {
+			fields = append(fields, zap.String("errors", c.Errors.String()))
+		}
+
+		l := logger.FromContext(c.Request.Context(), log)
+		switch status := c.Writer.Status(); {
+		case status >= 500:
+			l.Error("request", fields...)
+		case status >= 400:
+			l.Warn("request", fields...)
+		default:
+			l.Info("request", fields...)
+		}
+	}
+}
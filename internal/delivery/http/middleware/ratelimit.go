@@ -1,28 +1,76 @@
 package middleware
 
 import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
 	"time"
 
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/cache"
+	"linkit/internal/infrastructure/logger"
+	"linkit/internal/infrastructure/metrics"
+
 	"github.com/gin-gonic/gin"
-	"github.com/spf13/viper"
-	"golang.org/x/time/rate"
+	"go.uber.org/zap"
 )
 
-// RateLimiter 限流中间件
-func RateLimiter() gin.HandlerFunc {
-	// 从配置中获取限流参数
-	requests := viper.GetInt("ratelimit.requests")
-	duration := viper.GetDuration("ratelimit.duration")
+// RateLimitCounter 滑动窗口限流计数器
+type RateLimitCounter interface {
+	SlidingWindow(ctx context.Context, key string, limit int64, window time.Duration) (cache.RateLimitResult, error)
+}
+
+// RateLimitKey 返回请求的限流键
+type RateLimitKey func(c *gin.Context) string
+
+// ClientKey 按调用者计数：优先使用API Key，其次是JWT用户，未认证时使用客户端IP
+func ClientKey(c *gin.Context) string {
+	if p, ok := domain.PrincipalFromContext(c.Request.Context()); ok {
+		switch {
+		case p.APIKeyID != 0:
+			return "key:" + strconv.FormatUint(uint64(p.APIKeyID), 10)
+		case p.UserID != 0:
+			return "user:" + strconv.FormatUint(uint64(p.UserID), 10)
+		}
+	}
+	return IPKey(c)
+}
+
+// IPKey 按客户端IP计数
+func IPKey(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
 
-	// 创建限流器
-	limiter := rate.NewLimiter(rate.Every(duration/time.Duration(requests)), requests)
+// seconds 将时间向上取整为秒
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
 
+// RateLimit 分布式限流中间件，在 window 滑动窗口内每个键最多允许 requests 个请求
+// budget 为限额名称，不同限额分别计数；计数器不可用时放行请求，避免缓存故障影响跳转
+func RateLimit(counter RateLimitCounter, budget string, requests int, window time.Duration, key RateLimitKey, log *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if !limiter.Allow() {
-			c.JSON(429, gin.H{
-				"error": "请求过于频繁，请稍后再试",
+		result, err := counter.SlidingWindow(c.Request.Context(), budget+":"+key(c), int64(requests), window)
+		if err != nil {
+			logger.FromContext(c.Request.Context(), log).Warn("rate limit check failed, request allowed",
+				zap.String("budget", budget), zap.Error(err))
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.FormatInt(result.Limit, 10))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+		c.Header("X-RateLimit-Reset", seconds(result.Reset))
+
+		if !result.Allowed {
+			metrics.RateLimited.WithLabelValues(budget).Inc()
+			c.Header("Retry-After", seconds(result.RetryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"code":    429001,
+				"message": "请求频率超限",
+				"details": "请稍后再试",
 			})
-			c.Abort()
 			return
 		}
 		c.Next()
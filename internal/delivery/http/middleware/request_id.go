@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"linkit/internal/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader 请求ID的HTTP头
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength 客户端传入请求ID的最大长度，超出时重新生成
+const maxRequestIDLength = 128
+
+// RequestID 请求ID中间件
+// 优先使用客户端传入的 X-Request-ID，否则生成新的请求ID，写入请求context和响应头
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = newRequestID()
+		}
+
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), requestID))
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// newRequestID 生成随机请求ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
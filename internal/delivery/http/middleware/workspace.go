@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// WorkspaceHeader 指定请求所在工作空间的请求头
+const WorkspaceHeader = "X-Workspace-ID"
+
+// WorkspaceResolver 解析调用者在工作空间中的角色
+type WorkspaceResolver interface {
+	Resolve(ctx context.Context, workspaceID uint) (domain.Role, error)
+}
+
+// Workspace 工作空间中间件，需在 Auth 之后注册
+// 请求携带 X-Workspace-ID 或JWT指定了工作空间时，将调用者切换到该工作空间并按成员角色授权；
+// 否则调用者处于个人空间
+func Workspace(resolver WorkspaceResolver, log *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := domain.PrincipalFromContext(c.Request.Context())
+		if !ok {
+			unauthorized(c)
+			return
+		}
+
+		// JWT可以指定工作空间，此时请求头只能省略或与之相同
+		header := c.GetHeader(WorkspaceHeader)
+		id := uint64(principal.WorkspaceID)
+		if header != "" {
+			parsed, err := strconv.ParseUint(header, 10, 32)
+			if err != nil || parsed == 0 {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"code":    400008,
+					"message": "无效的工作空间ID",
+					"details": WorkspaceHeader + " 必须是正整数",
+				})
+				return
+			}
+			if id != 0 && parsed != id {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"code":    403003,
+					"message": "权限不足",
+					"details": "当前令牌仅限工作空间 " + strconv.FormatUint(id, 10),
+				})
+				return
+			}
+			id = parsed
+		}
+		if id == 0 {
+			c.Next()
+			return
+		}
+
+		role, err := resolver.Resolve(c.Request.Context(), uint(id))
+		if err != nil {
+			if errors.Is(err, domain.ErrWorkspaceNotFound) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+					"code":    404004,
+					"message": "工作空间不存在",
+					"details": "工作空间不存在或您不是该工作空间的成员",
+				})
+				return
+			}
+			logger.FromContext(c.Request.Context(), log).Error("failed to resolve workspace",
+				zap.Uint64("workspace_id", id), zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"code":    503001,
+				"message": "认证服务暂不可用",
+				"details": "请稍后重试",
+			})
+			return
+		}
+
+		ctx := domain.WithPrincipal(c.Request.Context(), principal.InWorkspace(uint(id), role))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
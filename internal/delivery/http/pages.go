@@ -0,0 +1,168 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+	"time"
+
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// pageStyle 访问者页面的公共样式
+const pageStyle = `
+body{margin:0;min-height:100vh;display:flex;align-items:center;justify-content:center;background:#f5f6f8;font-family:-apple-system,BlinkMacSystemFont,"Segoe UI","PingFang SC","Microsoft YaHei",sans-serif;color:#1f2329}
+main{width:100%;max-width:360px;margin:16px;padding:32px 28px;background:#fff;border-radius:12px;box-shadow:0 4px 24px rgba(0,0,0,.08)}
+h1{margin:0 0 8px;font-size:20px}
+p{margin:0 0 20px;color:#646a73;font-size:14px;line-height:1.6}
+.error{color:#d83931}
+dl{margin:0 0 20px;font-size:14px}
+dt{color:#646a73;margin-bottom:4px}
+dd{margin:0 0 12px;word-break:break-all}
+.domain{font-size:18px;font-weight:600}
+.notice{padding:10px 12px;background:#fff7e8;border-radius:8px;color:#8f5c00}
+input{box-sizing:border-box;width:100%;padding:10px 12px;margin-bottom:16px;border:1px solid #d0d3d6;border-radius:8px;font-size:15px}
+button,.button{display:block;box-sizing:border-box;width:100%;padding:10px 12px;border:0;border-radius:8px;background:#3370ff;color:#fff;font-size:15px;text-align:center;text-decoration:none;cursor:pointer}
+.secondary{margin:16px 0 0;text-align:center}
+.secondary a{color:#3370ff;text-decoration:none}
+`
+
+// passwordPage 密码保护短链接的密码输入页
+var passwordPage = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>需要访问密码</title>
+<style>` + pageStyle + `</style>
+</head>
+<body>
+<main>
+<form method="post" action="{{.Action}}">
+<h1>此链接受密码保护</h1>
+{{if .Error}}<p class="error">{{.Error}}</p>{{else}}<p>请输入访问密码后继续</p>{{end}}
+<input type="password" name="password" placeholder="访问密码" autocomplete="current-password" maxlength="72" required autofocus>
+<button type="submit">继续访问</button>
+</form>
+</main>
+</body>
+</html>
+`))
+
+// previewPage 短链接预览页，展示目标地址后由访问者决定是否继续访问
+var previewPage = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>链接预览</title>
+<style>` + pageStyle + `</style>
+</head>
+<body>
+<main>
+<h1>即将访问以下地址</h1>
+<p>请确认目标地址可信后再继续访问</p>
+<dl>
+<dt>目标域名</dt>
+<dd class="domain">{{.Domain}}</dd>
+<dt>完整地址</dt>
+<dd>{{.LongURL}}</dd>
+<dt>创建时间</dt>
+<dd>{{.CreatedAt.UTC.Format "2006-01-02"}}</dd>
+<dt>所属空间</dt>
+<dd>{{if eq .WorkspaceID 0}}个人空间{{else if .WorkspaceName}}{{.WorkspaceName}}{{else}}工作空间 #{{.WorkspaceID}}{{end}}</dd>
+</dl>
+{{if .HasRules}}<p class="notice">该链接配置了跳转规则，实际访问的地址可能因设备、地区或时间不同而与上述地址不同</p>{{end}}
+<a class="button" href="{{.Continue}}">继续访问</a>
+</main>
+</body>
+</html>
+`))
+
+// appPage 打开App的中间页，先尝试打开深度链接，超时后仍停留在页面上说明未安装App，跳转到 Fallback
+var appPage = template.Must(template.New("app").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>正在打开App</title>
+<style>` + pageStyle + `</style>
+</head>
+<body>
+<main>
+<h1>正在打开App</h1>
+<p>如果没有自动打开，请点击下方按钮；未安装App时将自动跳转</p>
+<a class="button" href="{{.AppURL}}">打开App</a>
+<p class="secondary"><a href="{{.Fallback}}">未安装App？继续访问</a></p>
+</main>
+<script nonce="{{.Nonce}}">
+(function () {
+  var fallback = {{.Fallback}};
+  var timer = setTimeout(function () { window.location.replace(fallback); }, {{.DelayMillis}});
+  document.addEventListener("visibilitychange", function () {
+    if (document.hidden) { clearTimeout(timer); }
+  });
+  window.location.href = {{.AppURL}};
+})();
+</script>
+</body>
+</html>
+`))
+
+// appFallbackDelay 中间页尝试打开App后等待的时间，超时仍未离开页面时跳转到 Fallback
+const appFallbackDelay = 1500 * time.Millisecond
+
+// appPageData 打开App中间页的数据
+type appPageData struct {
+	AppURL      template.URL // App深度链接，创建时已校验scheme
+	Fallback    template.URL // 未安装App时的跳转地址
+	Nonce       string       // 内联脚本的CSP nonce
+	DelayMillis int64        // 跳转到 Fallback 前等待的毫秒数
+}
+
+// renderAppPage 渲染打开App的中间页，内联脚本通过每次请求生成的nonce放行
+func renderAppPage(c *gin.Context, app *domain.AppLaunch, log *zap.Logger) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		// 无法生成nonce时直接跳转到 Fallback
+		logger.FromContext(c.Request.Context(), log).Warn("failed to generate nonce", zap.Error(err))
+		c.Redirect(http.StatusFound, app.Fallback)
+		return
+	}
+	data := appPageData{
+		AppURL:      template.URL(app.URL),
+		Fallback:    template.URL(app.Fallback),
+		Nonce:       base64.StdEncoding.EncodeToString(nonce),
+		DelayMillis: appFallbackDelay.Milliseconds(),
+	}
+	writePage(c, http.StatusOK, pageCSP+"; script-src 'nonce-"+data.Nonce+"'", appPage, data, log)
+}
+
+// pageCSP 访问者页面的内容安全策略，页面不加载外部资源
+const pageCSP = "default-src 'none'; style-src 'unsafe-inline'; form-action 'self'"
+
+// renderPage 渲染面向访问者的HTML页面，页面不允许缓存和嵌入
+func renderPage(c *gin.Context, status int, page *template.Template, data interface{}, log *zap.Logger) {
+	writePage(c, status, pageCSP, page, data, log)
+}
+
+// writePage 使用指定的内容安全策略渲染面向访问者的HTML页面
+func writePage(c *gin.Context, status int, csp string, page *template.Template, data interface{}, log *zap.Logger) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Security-Policy", csp)
+	c.Status(status)
+	if err := page.Execute(c.Writer, data); err != nil {
+		logger.FromContext(c.Request.Context(), log).Warn("failed to render page",
+			zap.String("page", page.Name()), zap.Error(err))
+	}
+}
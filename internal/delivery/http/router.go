@@ -12,24 +12,14 @@ type Handler interface {
 	RegisterRoot(r *gin.Engine)
 }
 
-// RegisterRoutes 注册所有路由
-func RegisterRoutes(r *gin.Engine, handlers ...Handler) {
-	// API版本分组
-	v1 := r.Group("/api/v1")
+// RegisterRoutes 注册所有路由，middlewares 为API路由的认证和工作空间中间件
+func RegisterRoutes(r *gin.Engine, middlewares gin.HandlersChain, handlers ...Handler) {
+	// API版本分组，所有API路由均需认证
+	v1 := r.Group("/api/v1", middlewares...)
 
-	// 注册API处理器
+	// 注册API处理器，健康检查路由由 HealthHandler 注册
 	for _, h := range handlers {
 		h.Register(v1)
 		h.RegisterRoot(r)
 	}
-
-	// 健康检查路由
-	r.GET("/health", healthCheck)
-}
-
-// healthCheck 健康检查处理函数
-func healthCheck(c *gin.Context) {
-	c.JSON(200, gin.H{
-		"status": "ok",
-	})
 }
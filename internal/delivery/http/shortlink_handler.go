@@ -1,52 +1,88 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"linkit/internal/delivery/http/middleware"
 	"linkit/internal/domain"
+	"linkit/internal/infrastructure/logger"
 	"linkit/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 )
 
+// unlockCookie 密码保护短链接解锁令牌的Cookie名，Cookie路径限定为短链接本身
+const unlockCookie = "linkit_unlock"
+
+// RateLimits 短链接路由的限流中间件，为nil表示不限流
+type RateLimits struct {
+	Create   gin.HandlerFunc // 创建短链接
+	Redirect gin.HandlerFunc // 短链接跳转
+}
+
 // ShortLinkHandler 处理短链接相关的HTTP请求
 type ShortLinkHandler struct {
 	useCase domain.ShortLinkUseCase
+	limits  RateLimits
+	logger  *zap.Logger
 }
 
 // NewShortLinkHandler 创建短链接处理器
-func NewShortLinkHandler(useCase domain.ShortLinkUseCase) *ShortLinkHandler {
+func NewShortLinkHandler(useCase domain.ShortLinkUseCase, limits RateLimits, logger *zap.Logger) *ShortLinkHandler {
 	return &ShortLinkHandler{
 		useCase: useCase,
+		limits:  limits,
+		logger:  logger.Named("handler"),
+	}
+}
+
+// chain 组合处理函数，跳过为nil的中间件
+func chain(handlers ...gin.HandlerFunc) gin.HandlersChain {
+	result := make(gin.HandlersChain, 0, len(handlers))
+	for _, h := range handlers {
+		if h != nil {
+			result = append(result, h)
+		}
 	}
+	return result
 }
 
 // Register 注册API路由
 func (h *ShortLinkHandler) Register(r *gin.RouterGroup) {
-	r.GET("/links", h.List) // 获取短链接列表
-	r.POST("/links", h.Create)
-	r.GET("/links/:code", h.Get)
-	r.DELETE("/links/:code", h.Delete)
-	r.PUT("/links/:code", h.Update)             // 新增: 更新短链接
-	r.GET("/links/:code/logs", h.ListClickLogs) // 新增：获取访问记录列表
+	read := middleware.RequireScope(domain.ScopeRead)
+	write := middleware.RequireScope(domain.ScopeWrite)
+
+	r.GET("/links", read, h.List) // 获取短链接列表
+	r.POST("/links", chain(write, h.limits.Create, h.Create)...)
+	r.GET("/links/:code", read, h.Get)
+	r.DELETE("/links/:code", write, h.Delete)
+	r.PUT("/links/:code", write, h.Update)            // 新增: 更新短链接
+	r.GET("/links/:code/logs", read, h.ListClickLogs) // 新增：获取访问记录列表
+	r.GET("/analytics/campaigns", read, h.CampaignStats)
 
 	// 规则相关路由
-	r.POST("/links/:code/rules", h.CreateRule)
-	r.GET("/links/:code/rules", h.GetRules)
-	r.PUT("/links/:code/rules/:ruleId", h.UpdateRule)
-	r.DELETE("/links/:code/rules/:ruleId", h.DeleteRule)
-	r.PUT("/links/:code/rules", h.UpdateRules) // 新增: 批量更新规则
+	r.POST("/links/:code/rules", write, h.CreateRule)
+	r.GET("/links/:code/rules", read, h.GetRules)
+	r.PUT("/links/:code/rules/:ruleId", write, h.UpdateRule)
+	r.DELETE("/links/:code/rules/:ruleId", write, h.DeleteRule)
+	r.PUT("/links/:code/rules", write, h.UpdateRules) // 新增: 批量更新规则
 }
 
 // RegisterRoot 注册根路由
 func (h *ShortLinkHandler) RegisterRoot(r *gin.Engine) {
-	// 注册重定向路由
-	r.GET("/:code", h.Redirect)
+	// 注册重定向路由，/<code>+ 或 ?preview=1 显示预览页；/<code>/<path> 用于开启了路径转发的短链接
+	r.GET("/:code", chain(h.limits.Redirect, h.Redirect)...)
+	r.GET("/:code/*path", chain(h.limits.Redirect, h.Redirect)...)
+	// 提交密码保护短链接的访问密码
+	r.POST("/:code", chain(h.limits.Redirect, h.Unlock)...)
+	r.POST("/:code/*path", chain(h.limits.Redirect, h.Unlock)...)
 }
 
 // validateCode 验证短码
@@ -62,44 +98,102 @@ func (h *ShortLinkHandler) validateCode(code string) error {
 
 // handleError 统一错误处理
 func (h *ShortLinkHandler) handleError(c *gin.Context, err error) {
-	switch err {
-	case domain.ErrInvalidURL:
+	switch {
+	case errors.Is(err, domain.ErrInvalidURL):
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code":    400001,
 			"message": "无效的URL格式",
 			"details": "请检查URL是否正确，必须是以http://或https://开头的完整URL",
 		})
-	case domain.ErrCustomCodeExists:
+	case errors.Is(err, domain.ErrCustomCodeExists):
 		c.JSON(http.StatusConflict, gin.H{
 			"code":    409001,
 			"message": "自定义短码已被使用",
 			"details": "请尝试使用其他短码，或让系统自动生成短码",
 		})
-	case domain.ErrInvalidCustomCode:
+	case errors.Is(err, domain.ErrInvalidCustomCode):
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code":    400002,
 			"message": "无效的自定义短码",
 			"details": "短码只能包含字母、数字、下划线和中划线，长度在4-16个字符之间",
 		})
-	case domain.ErrShortLinkNotFound:
+	case errors.Is(err, domain.ErrInvalidPassword):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400011,
+			"message": "无效的访问密码",
+			"details": "访问密码长度必须在4-72个字节之间，传入空字符串取消密码",
+		})
+	case errors.Is(err, domain.ErrInvalidQueryPassthrough):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400013,
+			"message": "无效的查询参数转发方式",
+			"details": "query_passthrough 必须是 off、append 或 override",
+		})
+	case errors.Is(err, domain.ErrInvalidAppLink):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400015,
+			"message": "无效的App深度链接",
+			"details": "深度链接和应用商店地址必须是包含scheme的完整地址，不允许 javascript、data、file 等scheme，应用商店地址需要和同一系统的深度链接一起设置",
+		})
+	case errors.Is(err, domain.ErrInvalidUTM):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400014,
+			"message": "无效的UTM参数",
+			"details": "UTM参数值的长度不能超过255个字节",
+		})
+	case errors.Is(err, domain.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400017,
+			"message": "无效的请求参数",
+			"details": err.Error(),
+		})
+	case errors.Is(err, domain.ErrShortLinkNotFound):
 		c.JSON(http.StatusNotFound, gin.H{
 			"code":    404001,
 			"message": "短链接不存在",
 			"details": "请检查短码是否正确，或者该链接可能已被删除",
 		})
-	case domain.ErrShortLinkExpired:
+	case errors.Is(err, domain.ErrShortLinkExpired):
 		c.JSON(http.StatusGone, gin.H{
 			"code":    410001,
 			"message": "短链接已过期",
 			"details": "该链接已超过设定的有效期，无法访问",
 		})
-	case domain.ErrRateLimitExceeded:
+	case errors.Is(err, domain.ErrRuleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404003,
+			"message": "跳转规则不存在",
+			"details": "请检查规则ID是否正确，或者该规则可能已被删除",
+		})
+	case errors.Is(err, domain.ErrRateLimitExceeded):
 		c.JSON(http.StatusTooManyRequests, gin.H{
 			"code":    429001,
 			"message": "请求频率超限",
 			"details": "请稍后再试",
 		})
+	case errors.Is(err, domain.ErrUnauthorized):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401001,
+			"message": "未认证",
+			"details": "请在 Authorization 头中携带有效的API Key: Bearer <key>",
+		})
+	case errors.Is(err, domain.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":    403003,
+			"message": "权限不足",
+			"details": "当前工作空间角色无权执行该操作",
+		})
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":    403004,
+			"message": "配额已用尽",
+			"details": "有效短链接数已达到配额上限，请删除不再使用的短链接或联系管理员提高配额，当前用量可通过 /api/v1/usage 查询",
+		})
 	default:
+		if handleContextError(c, err) {
+			return
+		}
+
 		// 检查是否包含特定错误信息
 		if strings.Contains(err.Error(), "failed to check custom code") {
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -111,6 +205,8 @@ func (h *ShortLinkHandler) handleError(c *gin.Context, err error) {
 		}
 
 		// 其他未预期的错误
+		logger.FromContext(c.Request.Context(), h.logger).Error("request failed",
+			zap.String("path", c.FullPath()), zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":    500001,
 			"message": "服务器内部错误",
@@ -119,6 +215,18 @@ func (h *ShortLinkHandler) handleError(c *gin.Context, err error) {
 	}
 }
 
+// detectOS 根据User-Agent识别访问者的移动操作系统
+func detectOS(userAgent string) domain.MobileOS {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case strings.Contains(ua, "android"):
+		return domain.OSAndroid
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"), strings.Contains(ua, "ipod"):
+		return domain.OSIOS
+	}
+	return domain.OSOther
+}
+
 // detectDevice 检测设备类型
 func (h *ShortLinkHandler) detectDevice(userAgent string) domain.DeviceType {
 	ua := strings.ToLower(userAgent)
@@ -150,7 +258,7 @@ func (h *ShortLinkHandler) Create(c *gin.Context) {
 		input.DefaultRedirect = domain.RedirectPermanent
 	}
 
-	shortLink, err := h.useCase.Create(&input)
+	shortLink, err := h.useCase.Create(c.Request.Context(), &input)
 	if err != nil {
 		h.handleError(c, err)
 		return
@@ -167,7 +275,7 @@ func (h *ShortLinkHandler) Get(c *gin.Context) {
 		return
 	}
 
-	shortLink, err := h.useCase.Get(code)
+	shortLink, err := h.useCase.Get(c.Request.Context(), code)
 	if err != nil {
 		h.handleError(c, err)
 		return
@@ -184,7 +292,7 @@ func (h *ShortLinkHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.useCase.Delete(code); err != nil {
+	if err := h.useCase.Delete(c.Request.Context(), code); err != nil {
 		h.handleError(c, err)
 		return
 	}
@@ -192,9 +300,29 @@ func (h *ShortLinkHandler) Delete(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
-// Redirect 重定向到原始URL
+// previewSuffix 短码后追加该后缀时显示预览页而不跳转
+const previewSuffix = "+"
+
+// visitCode 获取访问者请求的短码，去掉预览后缀
+func visitCode(c *gin.Context) string {
+	return strings.TrimSuffix(c.Param("code"), previewSuffix)
+}
+
+// forwardedPath 获取短码之后的路径，保持原始编码以便区分编码的斜杠，没有额外路径时为空
+func forwardedPath(c *gin.Context) string {
+	if c.Param("path") == "" {
+		return ""
+	}
+	escaped := c.Request.URL.EscapedPath()
+	if i := strings.Index(escaped[1:], "/"); i >= 0 {
+		return escaped[i+1:]
+	}
+	return ""
+}
+
+// Redirect 重定向到原始URL，请求预览时显示预览页
 func (h *ShortLinkHandler) Redirect(c *gin.Context) {
-	code := c.Param("code")
+	code := visitCode(c)
 	if err := h.validateCode(code); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的短码", "details": err.Error()})
 		return
@@ -213,40 +341,40 @@ func (h *ShortLinkHandler) Redirect(c *gin.Context) {
 		CreatedAt: time.Now(),
 	}
 
-	url, redirectType, err := h.useCase.Redirect(code, clickLog)
+	ctx := c.Request.Context()
+	if cookie, err := c.Cookie(unlockCookie); err == nil {
+		ctx = domain.WithUnlockToken(ctx, cookie)
+	}
+
+	if strings.HasSuffix(c.Param("code"), previewSuffix) || c.Query("preview") == "1" {
+		h.preview(ctx, c, code, clickLog)
+		return
+	}
+
+	req := domain.RedirectRequest{
+		RawQuery: c.Request.URL.RawQuery,
+		Path:     forwardedPath(c),
+		OS:       detectOS(c.Request.UserAgent()),
+	}
+	result, err := h.useCase.Redirect(ctx, code, req, clickLog)
 	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrShortLinkNotFound):
-			c.JSON(http.StatusNotFound, gin.H{
-				"code":    404001,
-				"message": "短链接不存在",
-				"details": "请检查短链接是否正确",
-			})
-		case errors.Is(err, domain.ErrShortLinkExpired):
-			c.JSON(http.StatusGone, gin.H{
-				"code":    410001,
-				"message": "短链接已过期",
-				"details": "该链接已超过设定的有效期，无法访问",
-			})
-		case errors.Is(err, domain.ErrMaxVisitsReached):
-			c.JSON(http.StatusForbidden, gin.H{
-				"code":    403001,
-				"message": "访问次数已达上限",
-				"details": "该短链接的访问次数已达到限制,无法继续访问",
-			})
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500001,
-				"message": "服务器内部错误",
-				"details": "请稍后重试，如果问题持续存在请联系管理员",
-			})
+		if errors.Is(err, domain.ErrPasswordRequired) {
+			h.renderPassword(c, http.StatusOK, "")
+			return
 		}
+		h.redirectError(c, code, err)
+		return
+	}
+
+	// 配置了App深度链接时由中间页尝试打开App
+	if result.App != nil {
+		renderAppPage(c, result.App, h.logger)
 		return
 	}
 
 	// 根据规则设置不同的状态码
 	var statusCode int
-	switch redirectType {
+	switch result.Type {
 	case domain.RedirectPermanent:
 		statusCode = http.StatusMovedPermanently // 301
 	case domain.RedirectTemporary:
@@ -259,21 +387,137 @@ func (h *ShortLinkHandler) Redirect(c *gin.Context) {
 		statusCode = http.StatusMovedPermanently // 默认301
 	}
 
-	c.Redirect(statusCode, url)
+	c.Redirect(statusCode, result.URL)
 }
 
-// CreateRule 创建跳转规则
-func (h *ShortLinkHandler) CreateRule(c *gin.Context) {
-	code := c.Param("code")
+// previewData 预览页的数据
+type previewData struct {
+	*domain.LinkPreview
+	Continue string // 继续访问的地址
+}
+
+// preview 显示短链接的预览页
+func (h *ShortLinkHandler) preview(ctx context.Context, c *gin.Context, code string, clickLog *domain.ClickLog) {
+	preview, err := h.useCase.Preview(ctx, code, clickLog)
+	if err != nil {
+		if errors.Is(err, domain.ErrPasswordRequired) {
+			h.renderPassword(c, http.StatusOK, "")
+			return
+		}
+		h.redirectError(c, code, err)
+		return
+	}
+
+	// 继续访问时保留短码之后的路径和除 preview 以外的查询参数
+	query := c.Request.URL.Query()
+	query.Del("preview")
+	target := "/" + code + forwardedPath(c)
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	renderPage(c, http.StatusOK, previewPage, previewData{LinkPreview: preview, Continue: target}, h.logger)
+}
+
+// redirectError 处理访问短链接时的错误
+func (h *ShortLinkHandler) redirectError(c *gin.Context, code string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrShortLinkNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404001,
+			"message": "短链接不存在",
+			"details": "请检查短链接是否正确",
+		})
+	case errors.Is(err, domain.ErrShortLinkExpired):
+		c.JSON(http.StatusGone, gin.H{
+			"code":    410001,
+			"message": "短链接已过期",
+			"details": "该链接已超过设定的有效期，无法访问",
+		})
+	case errors.Is(err, domain.ErrMaxVisitsReached):
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":    403001,
+			"message": "访问次数已达上限",
+			"details": "该短链接的访问次数已达到限制,无法继续访问",
+		})
+	case errors.Is(err, domain.ErrInvalidForwardPath):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400016,
+			"message": "无效的访问路径",
+			"details": "路径不能包含 . 或 .. 段、编码的斜杠、反斜杠或空段",
+		})
+	default:
+		if handleContextError(c, err) {
+			return
+		}
+		logger.FromContext(c.Request.Context(), h.logger).Error("redirect failed",
+			zap.String("code", code), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500001,
+			"message": "服务器内部错误",
+			"details": "请稍后重试，如果问题持续存在请联系管理员",
+		})
+	}
+}
+
+// passwordForm 密码输入页的数据
+type passwordForm struct {
+	Action string // 表单提交地址
+	Error  string // 错误提示
+}
+
+// renderPassword 渲染密码输入页，表单提交到当前地址
+func (h *ShortLinkHandler) renderPassword(c *gin.Context, status int, message string) {
+	renderPage(c, status, passwordPage, passwordForm{
+		Action: c.Request.URL.RequestURI(),
+		Error:  message,
+	}, h.logger)
+}
+
+// Unlock 校验访问密码，通过后写入解锁Cookie并重新访问短链接或预览页
+func (h *ShortLinkHandler) Unlock(c *gin.Context) {
+	code := visitCode(c)
 	if err := h.validateCode(code); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的短码", "details": err.Error()})
 		return
 	}
 
-	// 先获取短链接信息
-	shortLink, err := h.useCase.Get(code)
+	token, expiresAt, err := h.useCase.Unlock(c.Request.Context(), code, c.PostForm("password"), c.ClientIP())
 	if err != nil {
-		h.handleError(c, err)
+		switch {
+		case errors.Is(err, domain.ErrWrongPassword):
+			h.renderPassword(c, http.StatusUnauthorized, "密码错误，请重新输入")
+		case errors.Is(err, domain.ErrPasswordLocked):
+			h.renderPassword(c, http.StatusTooManyRequests, "密码错误次数过多，请稍后再试")
+		default:
+			h.redirectError(c, code, err)
+		}
+		return
+	}
+
+	// 经由HTTPS访问时(包括TLS终止在代理上)只通过HTTPS发送Cookie
+	// Cookie路径 /<code> 不匹配 /<code>+，预览页需要单独写入一份
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	for _, path := range []string{"/" + code, "/" + code + previewSuffix} {
+		http.SetCookie(c.Writer, &http.Cookie{
+			Name:     unlockCookie,
+			Value:    token,
+			Path:     path,
+			Expires:  expiresAt,
+			MaxAge:   int(time.Until(expiresAt).Seconds()),
+			Secure:   secure,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusSeeOther, c.Request.URL.RequestURI())
+}
+
+// CreateRule 创建跳转规则
+func (h *ShortLinkHandler) CreateRule(c *gin.Context) {
+	code := c.Param("code")
+	if err := h.validateCode(code); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的短码", "details": err.Error()})
 		return
 	}
 
@@ -283,16 +527,13 @@ func (h *ShortLinkHandler) CreateRule(c *gin.Context) {
 		return
 	}
 
-	// 设置ShortLinkID
-	input.ShortLinkID = shortLink.ID
-
 	// 验证时间范围
 	if input.StartTime != nil && input.EndTime != nil && input.EndTime.Before(*input.StartTime) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "结束时间不能早于开始时间"})
 		return
 	}
 
-	rule, err := h.useCase.CreateRule(&input)
+	rule, err := h.useCase.CreateRule(c.Request.Context(), code, &input)
 	if err != nil {
 		h.handleError(c, err)
 		return
@@ -309,13 +550,7 @@ func (h *ShortLinkHandler) GetRules(c *gin.Context) {
 		return
 	}
 
-	shortLink, err := h.useCase.Get(code)
-	if err != nil {
-		h.handleError(c, err)
-		return
-	}
-
-	rules, err := h.useCase.GetRules(shortLink.ID)
+	rules, err := h.useCase.GetRules(c.Request.Context(), code)
 	if err != nil {
 		h.handleError(c, err)
 		return
@@ -351,7 +586,7 @@ func (h *ShortLinkHandler) UpdateRule(c *gin.Context) {
 		return
 	}
 
-	rule, err := h.useCase.UpdateRule(uint(ruleID), &input)
+	rule, err := h.useCase.UpdateRule(c.Request.Context(), code, uint(ruleID), &input)
 	if err != nil {
 		h.handleError(c, err)
 		return
@@ -375,7 +610,7 @@ func (h *ShortLinkHandler) DeleteRule(c *gin.Context) {
 		return
 	}
 
-	if err := h.useCase.DeleteRule(uint(ruleID)); err != nil {
+	if err := h.useCase.DeleteRule(c.Request.Context(), code, uint(ruleID)); err != nil {
 		h.handleError(c, err)
 		return
 	}
@@ -412,7 +647,7 @@ func (h *ShortLinkHandler) List(c *gin.Context) {
 		PageSize: pageSize,
 	}
 
-	// 解析过滤条件
+	// 解析过滤条件，用户过滤仅对管理员有效
 	if userIDStr := c.Query("user_id"); userIDStr != "" {
 		if userID, err := strconv.ParseUint(userIDStr, 10, 32); err == nil {
 			uid := uint(userID)
@@ -448,6 +683,14 @@ func (h *ShortLinkHandler) List(c *gin.Context) {
 		}
 	}
 
+	// campaign 为空字符串时查询未设置活动的短链接
+	if campaign, ok := c.GetQuery("campaign"); ok {
+		if query.Filter == nil {
+			query.Filter = &domain.ShortLinkFilter{}
+		}
+		query.Filter.Campaign = &campaign
+	}
+
 	// 解析时间范围
 	if startTimeStr := c.Query("start_time"); startTimeStr != "" {
 		if startTime, err := time.Parse(time.RFC3339, startTimeStr); err == nil {
@@ -480,7 +723,7 @@ func (h *ShortLinkHandler) List(c *gin.Context) {
 	}
 
 	// 调用usecase层获取数据
-	result, err := h.useCase.List(query)
+	result, err := h.useCase.List(c.Request.Context(), query)
 	if err != nil {
 		h.handleError(c, err)
 		return
@@ -509,7 +752,7 @@ func (h *ShortLinkHandler) Update(c *gin.Context) {
 		return
 	}
 
-	shortLink, err := h.useCase.Update(code, &input)
+	shortLink, err := h.useCase.Update(c.Request.Context(), code, &input)
 	if err != nil {
 		h.handleError(c, err)
 		return
@@ -526,13 +769,6 @@ func (h *ShortLinkHandler) UpdateRules(c *gin.Context) {
 		return
 	}
 
-	// 先获取短链接信息
-	shortLink, err := h.useCase.Get(code)
-	if err != nil {
-		h.handleError(c, err)
-		return
-	}
-
 	var inputs []domain.CreateRuleInput
 	if err := c.ShouldBindJSON(&inputs); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求参数", "details": err.Error()})
@@ -547,7 +783,7 @@ func (h *ShortLinkHandler) UpdateRules(c *gin.Context) {
 		}
 	}
 
-	rules, err := h.useCase.UpdateRules(shortLink.ID, inputs)
+	rules, err := h.useCase.UpdateRules(c.Request.Context(), code, inputs)
 	if err != nil {
 		h.handleError(c, err)
 		return
@@ -639,6 +875,26 @@ func (h *ShortLinkHandler) ListClickLogs(c *gin.Context) {
 		}
 	}
 
+	if campaign, ok := c.GetQuery("campaign"); ok {
+		filter.Campaign = &campaign
+		hasFilter = true
+	}
+
+	// 默认只返回跳转记录，kind=preview 返回预览记录，kind=all 返回全部
+	switch kind := domain.ClickKind(c.DefaultQuery("kind", string(domain.ClickKindClick))); kind {
+	case domain.ClickKindClick, domain.ClickKindPreview:
+		filter.Kind = &kind
+		hasFilter = true
+	case "all":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400012,
+			"message": "无效的访问记录类型",
+			"details": "kind 必须是 click、preview 或 all",
+		})
+		return
+	}
+
 	if hasFilter {
 		query.Filter = filter
 	}
@@ -656,7 +912,7 @@ func (h *ShortLinkHandler) ListClickLogs(c *gin.Context) {
 	}
 
 	// 获取访问记录
-	logs, err := h.useCase.ListClickLogs(code, query)
+	logs, err := h.useCase.ListClickLogs(c.Request.Context(), code, query)
 	if err != nil {
 		h.handleError(c, err)
 		return
@@ -664,3 +920,29 @@ func (h *ShortLinkHandler) ListClickLogs(c *gin.Context) {
 
 	c.JSON(http.StatusOK, logs)
 }
+
+// CampaignStats 按UTM活动统计当前空间短链接的点击数，不含预览
+// start_time、end_time 为RFC3339格式的时间，统计范围为 [start_time, end_time)
+func (h *ShortLinkHandler) CampaignStats(c *gin.Context) {
+	query := &domain.CampaignStatsQuery{}
+	if startTimeStr := c.Query("start_time"); startTimeStr != "" {
+		if startTime, err := time.Parse(time.RFC3339, startTimeStr); err == nil {
+			query.Start = startTime
+		}
+	}
+	if endTimeStr := c.Query("end_time"); endTimeStr != "" {
+		if endTime, err := time.Parse(time.RFC3339, endTimeStr); err == nil {
+			query.End = endTime
+		}
+	}
+
+	stats, err := h.useCase.CampaignStats(c.Request.Context(), query)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": stats,
+	})
+}
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"linkit/internal/delivery/http/middleware"
+	"linkit/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClickCounterStatsProvider 提供点击计数器运行状态
+type ClickCounterStatsProvider interface {
+	Stats(ctx context.Context) domain.ClickCounterStats
+}
+
+// ClickLogWriterStatsProvider 提供点击日志写入器运行状态
+type ClickLogWriterStatsProvider interface {
+	Stats() domain.ClickLogWriterStats
+}
+
+// ClickStreamStatsProvider 提供点击事件流运行状态
+type ClickStreamStatsProvider interface {
+	Stats(ctx context.Context) domain.ClickStreamStats
+}
+
+// StatsHandler 处理运行状态相关的HTTP请求
+type StatsHandler struct {
+	counter ClickCounterStatsProvider
+	writer  ClickLogWriterStatsProvider
+	stream  ClickStreamStatsProvider
+}
+
+// NewStatsHandler 创建运行状态处理器，未启用点击事件流时stream为nil
+func NewStatsHandler(counter ClickCounterStatsProvider, writer ClickLogWriterStatsProvider, stream ClickStreamStatsProvider) *StatsHandler {
+	return &StatsHandler{
+		counter: counter,
+		writer:  writer,
+		stream:  stream,
+	}
+}
+
+// Register 注册API路由
+func (h *StatsHandler) Register(r *gin.RouterGroup) {
+	r.GET("/stats/clicks", middleware.RequireScope(domain.ScopeAdmin), h.ClickStats) // 点击数据写入状态
+}
+
+// RegisterRoot 注册根路由
+func (h *StatsHandler) RegisterRoot(r *gin.Engine) {}
+
+// ClickStats 获取点击数据写入状态，包括计数同步积压、点击日志队列深度、丢弃数和事件流积压
+func (h *StatsHandler) ClickStats(c *gin.Context) {
+	resp := gin.H{
+		"counter":    h.counter.Stats(c.Request.Context()),
+		"log_writer": h.writer.Stats(),
+	}
+	if h.stream != nil {
+		resp["stream"] = h.stream.Stats(c.Request.Context())
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,185 @@
+package http
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"linkit/internal/delivery/http/middleware"
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxExportDays 单次导出的最大天数
+const maxExportDays = 366
+
+// UsageHandler 处理用量查询和计量记录导出相关的HTTP请求
+type UsageHandler struct {
+	useCase  domain.UsageUseCase
+	metering domain.MeteringUseCase
+	logger   *zap.Logger
+}
+
+// NewUsageHandler 创建用量查询处理器
+func NewUsageHandler(useCase domain.UsageUseCase, metering domain.MeteringUseCase, logger *zap.Logger) *UsageHandler {
+	return &UsageHandler{
+		useCase:  useCase,
+		metering: metering,
+		logger:   logger.Named("handler"),
+	}
+}
+
+// Register 注册API路由
+func (h *UsageHandler) Register(r *gin.RouterGroup) {
+	r.GET("/usage", middleware.RequireScope(domain.ScopeRead), h.Get)
+	r.GET("/usage/records", middleware.RequireScope(domain.ScopeRead), h.Export)
+}
+
+// RegisterRoot 注册根路由
+func (h *UsageHandler) RegisterRoot(r *gin.Engine) {}
+
+// handleError 统一错误处理
+func (h *UsageHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrUnauthorized):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401001,
+			"message": "未认证",
+			"details": "请在 Authorization 头中携带有效的API Key: Bearer <key>",
+		})
+	case errors.Is(err, domain.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":    403003,
+			"message": "权限不足",
+			"details": "查看用量需要工作空间成员身份，导出计量记录需要管理员权限或工作空间 owner 角色",
+		})
+	default:
+		if handleContextError(c, err) {
+			return
+		}
+		logger.FromContext(c.Request.Context(), h.logger).Error("request failed",
+			zap.String("path", c.FullPath()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500001,
+			"message": "服务器内部错误",
+			"details": "请稍后重试，如果问题持续存在请联系管理员",
+		})
+	}
+}
+
+// Get 查询当前空间的用量和配额，通过 X-Workspace-ID 查询工作空间的用量
+func (h *UsageHandler) Get(c *gin.Context) {
+	usage, err := h.useCase.Get(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
+
+// invalidExport 返回导出参数错误
+func invalidExport(c *gin.Context, details string) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"code":    400010,
+		"message": "无效的导出参数",
+		"details": details,
+	})
+}
+
+// Export 导出工作空间按天或按月的用量计量记录，支持JSON和CSV格式
+// from、to 为UTC日期(YYYY-MM-DD，均包含)，默认导出本月至今
+func (h *UsageHandler) Export(c *gin.Context) {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := today
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		value := c.Query(p.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			invalidExport(c, p.name+" 必须是 YYYY-MM-DD 格式的日期")
+			return
+		}
+		*p.dst = t
+	}
+	end := to.AddDate(0, 0, 1)
+	if !from.Before(end) {
+		invalidExport(c, "from 不能晚于 to")
+		return
+	}
+	if end.Sub(from) > maxExportDays*24*time.Hour {
+		invalidExport(c, fmt.Sprintf("单次最多导出 %d 天", maxExportDays))
+		return
+	}
+
+	granularity := domain.UsageGranularity(c.DefaultQuery("granularity", string(domain.GranularityDay)))
+	if granularity != domain.GranularityDay && granularity != domain.GranularityMonth {
+		invalidExport(c, "granularity 必须是 day 或 month")
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		invalidExport(c, "format 必须是 json 或 csv")
+		return
+	}
+
+	query := &domain.UsageExportQuery{
+		UsageRecordQuery: domain.UsageRecordQuery{Start: from, End: end},
+		Granularity:      granularity,
+	}
+	if wsStr := c.Query("workspace_id"); wsStr != "" {
+		workspaceID, err := strconv.ParseUint(wsStr, 10, 32)
+		if err != nil {
+			invalidExport(c, "workspace_id 必须是整数")
+			return
+		}
+		id := uint(workspaceID)
+		query.WorkspaceID = &id
+	}
+
+	export, err := h.metering.Export(c.Request.Context(), query)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	// CSV 中的周期结束日期不包含在周期内
+	filename := fmt.Sprintf("usage_%s_%s.csv", from.Format(time.DateOnly), to.Format(time.DateOnly))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"workspace_id", "period_start", "period_end", "links_created", "redirects", "click_logs"})
+	for _, r := range export.Records {
+		_ = w.Write([]string{
+			strconv.FormatUint(uint64(r.WorkspaceID), 10),
+			r.PeriodStart.Format(time.DateOnly),
+			r.PeriodEnd.Format(time.DateOnly),
+			strconv.FormatInt(r.LinksCreated, 10),
+			strconv.FormatInt(r.Redirects, 10),
+			strconv.FormatInt(r.ClickLogs, 10),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		logger.FromContext(c.Request.Context(), h.logger).Warn("failed to write usage csv", zap.Error(err))
+	}
+}
@@ -0,0 +1,191 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"linkit/internal/delivery/http/middleware"
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// WorkspaceHandler 处理工作空间相关的HTTP请求
+type WorkspaceHandler struct {
+	useCase domain.WorkspaceUseCase
+	logger  *zap.Logger
+}
+
+// NewWorkspaceHandler 创建工作空间处理器
+func NewWorkspaceHandler(useCase domain.WorkspaceUseCase, logger *zap.Logger) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		useCase: useCase,
+		logger:  logger.Named("handler"),
+	}
+}
+
+// Register 注册API路由
+func (h *WorkspaceHandler) Register(r *gin.RouterGroup) {
+	read := middleware.RequireScope(domain.ScopeRead)
+	write := middleware.RequireScope(domain.ScopeWrite)
+
+	workspaces := r.Group("/workspaces")
+	workspaces.GET("", read, h.List)
+	workspaces.POST("", write, h.Create)
+	workspaces.GET("/:id/members", read, h.ListMembers)
+	workspaces.PUT("/:id/members/:userId", write, h.SetMember)
+	workspaces.DELETE("/:id/members/:userId", write, h.RemoveMember)
+}
+
+// RegisterRoot 注册根路由
+func (h *WorkspaceHandler) RegisterRoot(r *gin.Engine) {}
+
+// handleError 统一错误处理
+func (h *WorkspaceHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    400009,
+			"message": "无效的角色",
+			"details": "角色只能是 owner、editor、analyst 或 viewer",
+		})
+	case errors.Is(err, domain.ErrWorkspaceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404004,
+			"message": "工作空间不存在",
+			"details": "工作空间不存在或您不是该工作空间的成员",
+		})
+	case errors.Is(err, domain.ErrMemberNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"code":    404005,
+			"message": "成员不存在",
+			"details": "该用户不是工作空间的成员",
+		})
+	case errors.Is(err, domain.ErrLastOwner):
+		c.JSON(http.StatusConflict, gin.H{
+			"code":    409002,
+			"message": "不能移除最后一个所有者",
+			"details": "请先将其他成员设置为所有者",
+		})
+	case errors.Is(err, domain.ErrUnauthorized):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401001,
+			"message": "未认证",
+			"details": "请在 Authorization 头中携带有效的API Key: Bearer <key>",
+		})
+	case errors.Is(err, domain.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{
+			"code":    403003,
+			"message": "权限不足",
+			"details": "只有工作空间所有者可以管理成员",
+		})
+	default:
+		if handleContextError(c, err) {
+			return
+		}
+		logger.FromContext(c.Request.Context(), h.logger).Error("request failed",
+			zap.String("path", c.FullPath()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code":    500001,
+			"message": "服务器内部错误",
+			"details": "请稍后重试，如果问题持续存在请联系管理员",
+		})
+	}
+}
+
+// parseID 解析路径中的ID参数
+func parseID(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的ID", "details": err.Error()})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// List 获取调用者所在的工作空间
+func (h *WorkspaceHandler) List(c *gin.Context) {
+	workspaces, err := h.useCase.List(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, workspaces)
+}
+
+// Create 创建工作空间
+func (h *WorkspaceHandler) Create(c *gin.Context) {
+	var input domain.CreateWorkspaceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求参数", "details": err.Error()})
+		return
+	}
+
+	ws, err := h.useCase.Create(c.Request.Context(), &input)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, ws)
+}
+
+// ListMembers 获取工作空间成员
+func (h *WorkspaceHandler) ListMembers(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	members, err := h.useCase.ListMembers(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+// SetMember 添加成员或修改成员角色
+func (h *WorkspaceHandler) SetMember(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	userID, ok := parseID(c, "userId")
+	if !ok {
+		return
+	}
+
+	var input domain.SetMemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求参数", "details": err.Error()})
+		return
+	}
+
+	member, err := h.useCase.SetMember(c.Request.Context(), id, userID, &input)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveMember 移除工作空间成员
+func (h *WorkspaceHandler) RemoveMember(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	userID, ok := parseID(c, "userId")
+	if !ok {
+		return
+	}
+
+	if err := h.useCase.RemoveMember(c.Request.Context(), id, userID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Scope 表示API Key的权限范围
+type Scope string
+
+const (
+	// ScopeRead 查询短链接、规则和访问记录
+	ScopeRead Scope = "read"
+	// ScopeWrite 创建、更新和删除短链接及规则，包含 read 权限
+	ScopeWrite Scope = "write"
+	// ScopeAdmin 管理API Key，包含所有权限
+	ScopeAdmin Scope = "admin"
+)
+
+// ValidScope 判断权限范围是否合法
+func ValidScope(s Scope) bool {
+	switch s {
+	case ScopeRead, ScopeWrite, ScopeAdmin:
+		return true
+	}
+	return false
+}
+
+// APIKey 表示一个API Key，密钥本身只在创建时返回一次，数据库中仅保存其哈希值
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"column:id;primaryKey"`
+	Name       string     `json:"name" gorm:"column:name"`                              // 名称
+	Prefix     string     `json:"prefix" gorm:"column:prefix"`                          // 密钥前缀，用于识别密钥
+	KeyHash    string     `json:"-" gorm:"column:key_hash;size:64;uniqueIndex"`         // 密钥的SHA-256哈希
+	UserID     uint       `json:"user_id" gorm:"column:user_id;index"`                  // 所属用户
+	Scopes     []Scope    `json:"scopes" gorm:"column:scopes;type:text[];default:'{}'"` // 权限范围
+	ExpiresAt  *time.Time `json:"expires_at" gorm:"column:expires_at"`                  // 过期时间，为空表示永不过期
+	LastUsedAt *time.Time `json:"last_used_at" gorm:"column:last_used_at"`              // 最近使用时间
+	RevokedAt  *time.Time `json:"revoked_at" gorm:"column:revoked_at"`                  // 吊销时间
+	CreatedAt  time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+}
+
+// TableName 指定表名
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// HasScope 判断API Key是否拥有指定权限，admin 包含所有权限，write 包含 read
+func (k *APIKey) HasScope(scope Scope) bool {
+	return hasScope(k.Scopes, scope)
+}
+
+// hasScope 判断权限列表是否包含指定权限
+func hasScope(scopes []Scope, scope Scope) bool {
+	for _, s := range scopes {
+		switch {
+		case s == scope, s == ScopeAdmin:
+			return true
+		case s == ScopeWrite && scope == ScopeRead:
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAPIKeyInput 表示创建API Key的输入参数
+type CreateAPIKeyInput struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []Scope    `json:"scopes" binding:"required,min=1"`
+	UserID    uint       `json:"user_id,omitempty"` // 所属用户，为空时使用调用者的用户
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreatedAPIKey 表示新创建的API Key，Key 为完整密钥，仅在创建时返回
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// Principal 表示经过认证的调用者
+type Principal struct {
+	UserID      uint    // 用户ID
+	APIKeyID    uint    // 使用的API Key
+	Scopes      []Scope // 权限范围
+	WorkspaceID uint    // 当前工作空间，为0表示调用者的个人空间
+	Role        Role    // 在当前工作空间中的角色
+	IP          string  // 请求来源IP，用于审计
+}
+
+// HasScope 判断调用者是否拥有指定权限
+func (p *Principal) HasScope(scope Scope) bool {
+	return hasScope(p.Scopes, scope)
+}
+
+// IsAdmin 判断调用者是否为管理员
+func (p *Principal) IsAdmin() bool {
+	return p.HasScope(ScopeAdmin)
+}
+
+// Can 判断调用者在当前空间中是否拥有指定权限，个人空间中拥有全部权限
+func (p *Principal) Can(perm Permission) bool {
+	if p.WorkspaceID == 0 {
+		return true
+	}
+	return p.Role.Can(perm)
+}
+
+// InWorkspace 返回切换到指定工作空间后的调用者
+func (p *Principal) InWorkspace(workspaceID uint, role Role) *Principal {
+	wp := *p
+	wp.WorkspaceID = workspaceID
+	wp.Role = role
+	return &wp
+}
+
+// principalKey 调用者在context中的键
+type principalKey struct{}
+
+// WithPrincipal 将调用者写入context
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext 从context中获取调用者，未认证时返回false
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// APIKeyRepository 定义API Key仓储接口
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *APIKey) error
+	GetByID(ctx context.Context, id uint) (*APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*APIKey, error)
+	List(ctx context.Context, userID uint) ([]APIKey, error) // userID为0时返回所有API Key
+	Revoke(ctx context.Context, id uint, at time.Time) error
+	TouchLastUsed(ctx context.Context, id uint, at time.Time) error
+}
+
+// APIKeyUseCase 定义API Key用例接口
+type APIKeyUseCase interface {
+	Create(ctx context.Context, input *CreateAPIKeyInput) (*CreatedAPIKey, error)
+	List(ctx context.Context) ([]APIKey, error)
+	Revoke(ctx context.Context, id uint) error
+	// Authenticate 校验密钥并返回对应的调用者
+	Authenticate(ctx context.Context, key string) (*Principal, error)
+}
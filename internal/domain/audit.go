@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// AuditAction 表示审计日志记录的操作
+type AuditAction string
+
+const (
+	AuditLinkCreate   AuditAction = "link.create"   // 创建短链接
+	AuditLinkUpdate   AuditAction = "link.update"   // 更新短链接
+	AuditLinkDelete   AuditAction = "link.delete"   // 删除短链接
+	AuditRuleCreate   AuditAction = "rule.create"   // 创建跳转规则
+	AuditRuleUpdate   AuditAction = "rule.update"   // 更新跳转规则
+	AuditRuleDelete   AuditAction = "rule.delete"   // 删除跳转规则
+	AuditRulesReplace AuditAction = "rules.replace" // 批量替换跳转规则
+)
+
+// AuditData 审计日志中保存的JSON数据，以 jsonb 存储
+type AuditData []byte
+
+// Value 实现 driver.Valuer，空值保存为 NULL
+func (d AuditData) Value() (driver.Value, error) {
+	if len(d) == 0 {
+		return nil, nil
+	}
+	return string(d), nil
+}
+
+// Scan 实现 sql.Scanner
+func (d *AuditData) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*d = nil
+	case []byte:
+		*d = append((*d)[:0], v...)
+	case string:
+		*d = AuditData(v)
+	default:
+		return fmt.Errorf("unsupported audit data type %T", value)
+	}
+	return nil
+}
+
+// MarshalJSON 原样输出JSON数据
+func (d AuditData) MarshalJSON() ([]byte, error) {
+	if len(d) == 0 {
+		return []byte("null"), nil
+	}
+	return d, nil
+}
+
+// AuditLog 表示一条审计日志，只能追加，不能修改或删除
+type AuditLog struct {
+	ID          uint        `json:"id" gorm:"column:id;primaryKey"`
+	Action      AuditAction `json:"action" gorm:"column:action;size:32;index"`
+	ShortLinkID uint        `json:"short_link_id" gorm:"column:short_link_id;index"`
+	ShortCode   string      `json:"short_code" gorm:"column:short_code;size:32;index"`
+	RuleID      *uint       `json:"rule_id,omitempty" gorm:"column:rule_id"`
+	WorkspaceID uint        `json:"workspace_id" gorm:"column:workspace_id;index"`
+	ActorID     uint        `json:"actor_id" gorm:"column:actor_id;index"`                    // 操作者用户ID
+	APIKeyID    uint        `json:"api_key_id,omitempty" gorm:"column:api_key_id"`            // 操作者使用的API Key，JWT认证时为0
+	IP          string      `json:"ip" gorm:"column:ip;size:45"`                              // 请求来源IP
+	Before      AuditData   `json:"before" gorm:"column:before;type:jsonb"`                   // 修改前发生变化的字段
+	After       AuditData   `json:"after" gorm:"column:after;type:jsonb"`                     // 修改后发生变化的字段
+	CreatedAt   time.Time   `json:"created_at" gorm:"column:created_at;autoCreateTime;index"` // 操作时间
+}
+
+// TableName 指定表名
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
+
+// AuditFilter 表示审计日志的过滤条件
+type AuditFilter struct {
+	ShortCode   *string      `json:"short_code,omitempty"`   // 短码
+	ActorID     *uint        `json:"actor_id,omitempty"`     // 操作者用户ID
+	Action      *AuditAction `json:"action,omitempty"`       // 操作
+	StartTime   *time.Time   `json:"start_time,omitempty"`   // 开始时间
+	EndTime     *time.Time   `json:"end_time,omitempty"`     // 结束时间
+	WorkspaceID *uint        `json:"workspace_id,omitempty"` // 工作空间ID，由用例层根据调用者设置
+}
+
+// AuditQuery 表示审计日志的分页查询参数
+type AuditQuery struct {
+	Page     int          `json:"page"`      // 页码，从1开始
+	PageSize int          `json:"page_size"` // 每页数量
+	Filter   *AuditFilter `json:"filter,omitempty"`
+}
+
+// PaginatedAuditLogs 表示分页的审计日志列表
+type PaginatedAuditLogs struct {
+	Total       int64      `json:"total"`        // 总记录数
+	TotalPages  int        `json:"total_pages"`  // 总页数
+	CurrentPage int        `json:"current_page"` // 当前页码
+	PageSize    int        `json:"page_size"`    // 每页数量
+	Data        []AuditLog `json:"data"`         // 当前页数据
+}
+
+// AuditRepository 定义审计日志仓储接口
+type AuditRepository interface {
+	Create(ctx context.Context, entry *AuditLog) error
+	List(ctx context.Context, query *AuditQuery) (*PaginatedAuditLogs, error)
+}
+
+// AuditUseCase 定义审计日志用例接口
+type AuditUseCase interface {
+	List(ctx context.Context, query *AuditQuery) (*PaginatedAuditLogs, error)
+}
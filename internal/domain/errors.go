@@ -27,6 +27,66 @@ var (
 	// ErrRateLimitExceeded 表示超出速率限制
 	ErrRateLimitExceeded = errors.New("rate limit exceeded")
 
+	// ErrRuleNotFound 表示跳转规则不存在
+	ErrRuleNotFound = errors.New("redirect rule not found")
+
 	// ErrMaxVisitsReached 表示访问次数达到上限
 	ErrMaxVisitsReached = errors.New("maximum visits limit reached")
+
+	// ErrUnauthorized 表示未提供有效的认证信息
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrForbidden 表示调用者没有执行该操作的权限
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrAPIKeyNotFound 表示API Key不存在
+	ErrAPIKeyNotFound = errors.New("api key not found")
+
+	// ErrWorkspaceNotFound 表示工作空间不存在或调用者不是其成员
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+
+	// ErrMemberNotFound 表示工作空间成员不存在
+	ErrMemberNotFound = errors.New("workspace member not found")
+
+	// ErrInvalidRole 表示无效的成员角色
+	ErrInvalidRole = errors.New("invalid role")
+
+	// ErrLastOwner 表示不能移除或降级工作空间的最后一个所有者
+	ErrLastOwner = errors.New("workspace must keep at least one owner")
+
+	// ErrInvalidExpiration 表示过期时间早于当前时间
+	ErrInvalidExpiration = errors.New("expiration must be in the future")
+
+	// ErrInvalidScope 表示无效的权限范围
+	ErrInvalidScope = errors.New("invalid scope")
+
+	// ErrQuotaExceeded 表示用户或工作空间的用量配额已用尽
+	ErrQuotaExceeded = errors.New("usage quota exceeded")
+
+	// ErrPasswordRequired 表示短链接受密码保护，需要先输入密码
+	ErrPasswordRequired = errors.New("password required")
+
+	// ErrWrongPassword 表示短链接的访问密码错误
+	ErrWrongPassword = errors.New("wrong password")
+
+	// ErrPasswordLocked 表示密码错误次数过多，暂时禁止继续尝试
+	ErrPasswordLocked = errors.New("too many password attempts")
+
+	// ErrInvalidPassword 表示设置的访问密码不符合要求
+	ErrInvalidPassword = errors.New("invalid password")
+
+	// ErrInvalidQueryPassthrough 表示无效的查询参数转发方式
+	ErrInvalidQueryPassthrough = errors.New("invalid query passthrough")
+
+	// ErrInvalidUTM 表示UTM参数不符合要求
+	ErrInvalidUTM = errors.New("invalid utm")
+
+	// ErrInvalidAppLink 表示App深度链接或应用商店地址不符合要求
+	ErrInvalidAppLink = errors.New("invalid app link")
+
+	// ErrInvalidInput 表示请求参数不合法，如不支持的排序字段
+	ErrInvalidInput = errors.New("invalid input")
+
+	// ErrInvalidForwardPath 表示访问者请求的转发路径不安全，如包含 .. 或编码的斜杠
+	ErrInvalidForwardPath = errors.New("invalid forward path")
 )
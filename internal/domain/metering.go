@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// UsageGranularity 表示用量导出的统计周期
+type UsageGranularity string
+
+const (
+	// GranularityDay 按天统计
+	GranularityDay UsageGranularity = "day"
+	// GranularityMonth 按自然月统计
+	GranularityMonth UsageGranularity = "month"
+)
+
+// UsageRecord 表示一个工作空间一天的用量计量记录，日期按UTC计算
+// 工作空间ID为0的记录汇总所有个人空间的用量
+type UsageRecord struct {
+	WorkspaceID  uint      `json:"workspace_id" gorm:"column:workspace_id;primaryKey;autoIncrement:false"`
+	Day          time.Time `json:"day" gorm:"column:day;type:date;primaryKey;index"`
+	LinksCreated int64     `json:"links_created" gorm:"column:links_created;not null;default:0"` // 创建的短链接数
+	Redirects    int64     `json:"redirects" gorm:"column:redirects;not null;default:0"`         // 完成的跳转次数
+	ClickLogs    int64     `json:"click_logs" gorm:"column:click_logs;not null;default:0"`       // 写入的访问日志数
+	UpdatedAt    time.Time `json:"updated_at" gorm:"column:updated_at"`                          // 最近一次汇总时间
+}
+
+// TableName 指定表名
+func (UsageRecord) TableName() string {
+	return "usage_records"
+}
+
+// UsageRecordQuery 表示用量计量记录的查询条件，时间范围为 [Start, End)
+type UsageRecordQuery struct {
+	WorkspaceID *uint     // 工作空间，为空表示所有工作空间
+	Start       time.Time // 开始日期(含)
+	End         time.Time // 结束日期(不含)
+}
+
+// UsageExportQuery 表示用量导出的查询条件
+type UsageExportQuery struct {
+	UsageRecordQuery
+	Granularity UsageGranularity // 统计周期
+}
+
+// UsagePeriod 表示一个工作空间在一个统计周期内的用量，周期为 [PeriodStart, PeriodEnd)
+type UsagePeriod struct {
+	WorkspaceID  uint      `json:"workspace_id"`
+	PeriodStart  time.Time `json:"period_start"`
+	PeriodEnd    time.Time `json:"period_end"`
+	LinksCreated int64     `json:"links_created"`
+	Redirects    int64     `json:"redirects"`
+	ClickLogs    int64     `json:"click_logs"`
+}
+
+// UsageExport 表示用量导出结果
+type UsageExport struct {
+	Start       time.Time        `json:"start"`       // 导出范围开始时间(含)
+	End         time.Time        `json:"end"`         // 导出范围结束时间(不含)
+	Granularity UsageGranularity `json:"granularity"` // 统计周期
+	GeneratedAt time.Time        `json:"generated_at"`
+	Records     []UsagePeriod    `json:"records"`
+}
+
+// MeteringRepository 定义用量计量仓储接口
+type MeteringRepository interface {
+	// IncrRedirects 记录一次跳转
+	IncrRedirects(ctx context.Context, workspaceID uint, at time.Time) error
+	// Aggregate 汇总指定日期的用量并写入计量记录
+	Aggregate(ctx context.Context, day time.Time) error
+	// List 按工作空间和日期查询计量记录
+	List(ctx context.Context, query *UsageRecordQuery) ([]UsageRecord, error)
+}
+
+// MeteringUseCase 定义用量计量用例接口
+type MeteringUseCase interface {
+	// Export 导出用量计量记录，未结束的日期先按实时数据重新汇总
+	Export(ctx context.Context, query *UsageExportQuery) (*UsageExport, error)
+}
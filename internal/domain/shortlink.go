@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"context"
+	"encoding/json"
 	"time"
 )
 
@@ -18,6 +20,103 @@ const (
 	RedirectPermanentKeepMethod
 )
 
+// QueryPassthrough 表示访问短链接时查询参数的转发方式
+type QueryPassthrough string
+
+const (
+	// PassthroughOff 不转发查询参数，跳转到原样保存的目标URL
+	PassthroughOff QueryPassthrough = "off"
+	// PassthroughAppend 将访问者的查询参数追加到目标URL，同名参数同时保留目标URL和访问者的值，目标URL中的值在前
+	PassthroughAppend QueryPassthrough = "append"
+	// PassthroughOverride 将访问者的查询参数追加到目标URL，同名参数使用访问者的值
+	PassthroughOverride QueryPassthrough = "override"
+)
+
+// ValidQueryPassthrough 判断查询参数转发方式是否合法
+func ValidQueryPassthrough(p QueryPassthrough) bool {
+	switch p {
+	case PassthroughOff, PassthroughAppend, PassthroughOverride:
+		return true
+	}
+	return false
+}
+
+// maxUTMLength UTM参数值的最大长度
+const maxUTMLength = 255
+
+// UTM 表示跳转时写入目标URL的 utm_* 营销参数，为空的字段不写入
+type UTM struct {
+	Source   string `json:"source,omitempty" gorm:"column:source;size:255;not null;default:''"`     // 流量来源，对应 utm_source
+	Medium   string `json:"medium,omitempty" gorm:"column:medium;size:255;not null;default:''"`     // 媒介，对应 utm_medium
+	Campaign string `json:"campaign,omitempty" gorm:"column:campaign;size:255;not null;default:''"` // 活动名称，对应 utm_campaign
+	Term     string `json:"term,omitempty" gorm:"column:term;size:255;not null;default:''"`         // 关键词，对应 utm_term
+	Content  string `json:"content,omitempty" gorm:"column:content;size:255;not null;default:''"`   // 内容，对应 utm_content
+}
+
+// IsZero 判断是否未设置任何UTM参数
+func (u UTM) IsZero() bool {
+	return u == UTM{}
+}
+
+// Valid 判断UTM参数值的长度是否合法
+func (u UTM) Valid() bool {
+	for _, v := range []string{u.Source, u.Medium, u.Campaign, u.Term, u.Content} {
+		if len(v) > maxUTMLength {
+			return false
+		}
+	}
+	return true
+}
+
+// Merge 使用 override 中不为空的字段覆盖当前参数，用于规则参数覆盖短链接参数
+func (u UTM) Merge(override UTM) UTM {
+	pick := func(base, v string) string {
+		if v != "" {
+			return v
+		}
+		return base
+	}
+	return UTM{
+		Source:   pick(u.Source, override.Source),
+		Medium:   pick(u.Medium, override.Medium),
+		Campaign: pick(u.Campaign, override.Campaign),
+		Term:     pick(u.Term, override.Term),
+		Content:  pick(u.Content, override.Content),
+	}
+}
+
+// MobileOS 表示访问者的移动操作系统，由User-Agent识别
+type MobileOS string
+
+const (
+	// OSOther 非移动操作系统或无法识别
+	OSOther MobileOS = ""
+	// OSIOS iOS 和 iPadOS
+	OSIOS MobileOS = "ios"
+	// OSAndroid Android
+	OSAndroid MobileOS = "android"
+)
+
+// AppLinks 表示短链接在移动端的App深度链接，可以是自定义scheme或 Universal Links/App Links
+// 访问者的系统配置了深度链接时先尝试打开App，未安装App时跳转到应用商店地址，未设置应用商店地址时跳转到网页目标
+type AppLinks struct {
+	IOSURL             string `json:"ios_url,omitempty" gorm:"column:ios_url;size:2048;not null;default:''"`                           // iOS 深度链接
+	IOSFallbackURL     string `json:"ios_fallback_url,omitempty" gorm:"column:ios_fallback_url;size:2048;not null;default:''"`         // App Store 地址
+	AndroidURL         string `json:"android_url,omitempty" gorm:"column:android_url;size:2048;not null;default:''"`                   // Android 深度链接
+	AndroidFallbackURL string `json:"android_fallback_url,omitempty" gorm:"column:android_fallback_url;size:2048;not null;default:''"` // Google Play 地址
+}
+
+// For 返回指定系统的深度链接和应用商店地址，未配置时深度链接为空
+func (a AppLinks) For(os MobileOS) (string, string) {
+	switch os {
+	case OSIOS:
+		return a.IOSURL, a.IOSFallbackURL
+	case OSAndroid:
+		return a.AndroidURL, a.AndroidFallbackURL
+	}
+	return "", ""
+}
+
 // DeviceType 表示设备类型
 type DeviceType int
 
@@ -49,6 +148,7 @@ type RedirectRule struct {
 	Cities      []string     `json:"cities" gorm:"column:cities;type:text[];default:'{}'"`       // 城市列表
 	Percentage  *int         `json:"percentage" gorm:"column:percentage"`                        // A/B测试流量百分比（1-100）
 	MaxVisits   *int         `json:"max_visits" gorm:"column:max_visits"`                        // 最大访问次数
+	UTM         UTM          `json:"utm" gorm:"embedded;embeddedPrefix:utm_"`                    // UTM参数，不为空的字段覆盖短链接的UTM参数
 	CreatedAt   time.Time    `json:"created_at" gorm:"column:created_at;autoCreateTime"`
 	UpdatedAt   time.Time    `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
 }
@@ -60,18 +160,24 @@ func (RedirectRule) TableName() string {
 
 // ShortLink 表示一个短链接实体
 type ShortLink struct {
-	ID              uint           `json:"id" gorm:"column:id;primaryKey"`
-	ShortCode       string         `json:"short_code" gorm:"column:short_code;uniqueIndex"`
-	LongURL         string         `json:"long_url" gorm:"column:long_url"`
-	UserID          uint           `json:"user_id,omitempty" gorm:"column:user_id"`
-	Clicks          uint64         `json:"clicks" gorm:"column:clicks;default:0"`
-	MaxVisits       *uint64        `json:"max_visits" gorm:"column:max_visits"` // 最大访问次数限制
-	ExpiresAt       time.Time      `json:"expires_at" gorm:"column:expires_at"`
-	NeverExpire     bool           `json:"never_expire" gorm:"column:never_expire;default:false"`     // 是否永不过期
-	DefaultRedirect RedirectType   `json:"default_redirect" gorm:"column:default_redirect;default:1"` // 默认跳转类型
-	Rules           []RedirectRule `json:"rules,omitempty" gorm:"-"`                                  // 跳转规则列表
-	CreatedAt       time.Time      `json:"created_at" gorm:"column:created_at;autoCreateTime"`
-	UpdatedAt       time.Time      `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+	ID               uint             `json:"id" gorm:"column:id;primaryKey"`
+	ShortCode        string           `json:"short_code" gorm:"column:short_code;uniqueIndex"`
+	LongURL          string           `json:"long_url" gorm:"column:long_url"`
+	UserID           uint             `json:"user_id,omitempty" gorm:"column:user_id;index"`
+	WorkspaceID      uint             `json:"workspace_id,omitempty" gorm:"column:workspace_id;default:0;index"` // 所属工作空间，为0表示创建者的个人空间
+	Clicks           uint64           `json:"clicks" gorm:"column:clicks;default:0"`
+	MaxVisits        *uint64          `json:"max_visits" gorm:"column:max_visits"` // 最大访问次数限制
+	ExpiresAt        time.Time        `json:"expires_at" gorm:"column:expires_at"`
+	NeverExpire      bool             `json:"never_expire" gorm:"column:never_expire;default:false"`                            // 是否永不过期
+	DefaultRedirect  RedirectType     `json:"default_redirect" gorm:"column:default_redirect;default:1"`                        // 默认跳转类型
+	PasswordHash     string           `json:"-" gorm:"column:password_hash;size:100;not null;default:''"`                       // 访问密码的bcrypt哈希，为空表示不需要密码
+	QueryPassthrough QueryPassthrough `json:"query_passthrough" gorm:"column:query_passthrough;size:16;not null;default:'off'"` // 查询参数转发方式，同时作用于默认目标和规则目标
+	UTM              UTM              `json:"utm" gorm:"embedded;embeddedPrefix:utm_"`                                          // UTM参数，跳转时写入目标URL
+	AppLinks         AppLinks         `json:"app_links" gorm:"embedded;embeddedPrefix:app_"`                                    // 移动端App深度链接
+	PathForwarding   bool             `json:"path_forwarding" gorm:"column:path_forwarding;not null;default:false"`             // 是否将短码之后的路径转发到目标URL，同时作用于默认目标和规则目标；开启后查询参数至少按 append 转发
+	Rules            []RedirectRule   `json:"rules,omitempty" gorm:"-"`                                                         // 跳转规则列表
+	CreatedAt        time.Time        `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt        time.Time        `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
 }
 
 // TableName 指定表名
@@ -79,19 +185,52 @@ func (ShortLink) TableName() string {
 	return "short_links"
 }
 
+// PasswordProtected 判断短链接是否需要密码才能访问
+func (l ShortLink) PasswordProtected() bool {
+	return l.PasswordHash != ""
+}
+
+// MarshalJSON 序列化短链接，不输出密码哈希，只输出是否设置了密码
+func (l ShortLink) MarshalJSON() ([]byte, error) {
+	type alias ShortLink
+	return json.Marshal(struct {
+		alias
+		PasswordProtected bool `json:"password_protected"`
+	}{alias(l), l.PasswordProtected()})
+}
+
+// unlockTokenKey 解锁令牌在context中的键
+type unlockTokenKey struct{}
+
+// WithUnlockToken 将访问者提交的密码保护短链接解锁令牌写入context
+func WithUnlockToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, unlockTokenKey{}, token)
+}
+
+// UnlockTokenFromContext 从context中获取解锁令牌，未提供时返回false
+func UnlockTokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(unlockTokenKey{}).(string)
+	return token, ok && token != ""
+}
+
 // CreateShortLinkInput 表示创建短链接的输入参数
 type CreateShortLinkInput struct {
-	LongURL         string       `json:"long_url" binding:"required,url"`
-	CustomCode      string       `json:"custom_code,omitempty"`
-	ExpiresAt       time.Time    `json:"expires_at,omitempty"`
-	UserID          uint         `json:"user_id,omitempty"`
-	DefaultRedirect RedirectType `json:"default_redirect,omitempty"` // 默认跳转类型
-	NeverExpire     bool         `json:"never_expire,omitempty"`     // 是否永不过期
+	LongURL          string           `json:"long_url" binding:"required,url"`
+	CustomCode       string           `json:"custom_code,omitempty"`
+	ExpiresAt        time.Time        `json:"expires_at,omitempty"`
+	UserID           uint             `json:"-"`                           // 所属用户，由调用者身份决定
+	DefaultRedirect  RedirectType     `json:"default_redirect,omitempty"`  // 默认跳转类型
+	NeverExpire      bool             `json:"never_expire,omitempty"`      // 是否永不过期
+	Password         string           `json:"password,omitempty"`          // 访问密码，为空表示不需要密码
+	QueryPassthrough QueryPassthrough `json:"query_passthrough,omitempty"` // 查询参数转发方式，默认不转发
+	UTM              UTM              `json:"utm,omitempty"`               // UTM参数
+	AppLinks         AppLinks         `json:"app_links,omitempty"`         // 移动端App深度链接
+	PathForwarding   bool             `json:"path_forwarding,omitempty"`   // 是否转发短码之后的路径
 }
 
 // CreateRuleInput 表示创建跳转规则的输入参数
 type CreateRuleInput struct {
-	ShortLinkID uint         `json:"short_link_id"`
+	ShortLinkID uint         `json:"-"` // 所属短链接，由请求路径中的短码决定
 	Name        string       `json:"name" binding:"required"`
 	Description string       `json:"description"`
 	Priority    int          `json:"priority"`
@@ -103,8 +242,19 @@ type CreateRuleInput struct {
 	Countries   []string     `json:"countries"`
 	Percentage  *int         `json:"percentage"`
 	MaxVisits   *int         `json:"max_visits"`
+	UTM         UTM          `json:"utm"` // UTM参数，不为空的字段覆盖短链接的UTM参数
 }
 
+// ClickKind 表示访问记录的类型
+type ClickKind string
+
+const (
+	// ClickKindClick 访问短链接并完成跳转
+	ClickKindClick ClickKind = "click"
+	// ClickKindPreview 查看短链接的预览页，不计入点击数
+	ClickKindPreview ClickKind = "preview"
+)
+
 // ClickLog 表示一个点击日志实体
 type ClickLog struct {
 	ID          uint       `json:"id" gorm:"column:id;primaryKey"`
@@ -113,8 +263,11 @@ type ClickLog struct {
 	IP          string     `json:"ip" gorm:"column:ip"`
 	UserAgent   string     `json:"user_agent" gorm:"column:user_agent"`
 	Referer     string     `json:"referer" gorm:"column:referer"`
-	Country     string     `json:"country" gorm:"column:country"`         // 访问者国家/地区
-	Device      DeviceType `json:"device" gorm:"column:device;default:0"` // 访问者设备类型
+	Country     string     `json:"country" gorm:"column:country"`                                 // 访问者国家/地区
+	Device      DeviceType `json:"device" gorm:"column:device;default:0"`                         // 访问者设备类型
+	Kind        ClickKind  `json:"kind" gorm:"column:kind;size:16;not null;default:'click'"`      // 访问记录类型
+	Campaign    string     `json:"campaign" gorm:"column:campaign;size:255;not null;default:''"`  // 跳转时生效的UTM活动名称
+	EventID     *string    `json:"event_id,omitempty" gorm:"column:event_id;size:64;uniqueIndex"` // 点击事件ID，用于事件流消费去重
 	CreatedAt   time.Time  `json:"created_at" gorm:"column:created_at;autoCreateTime"`
 }
 
@@ -135,12 +288,14 @@ const (
 
 // ShortLinkFilter 表示短链接查询过滤条件
 type ShortLinkFilter struct {
-	UserID    *uint      `json:"user_id,omitempty"`    // 用户ID过滤
-	IsExpired *bool      `json:"is_expired,omitempty"` // 是否已过期
-	StartTime *time.Time `json:"start_time,omitempty"` // 创建时间范围开始
-	EndTime   *time.Time `json:"end_time,omitempty"`   // 创建时间范围结束
-	MinClicks *uint64    `json:"min_clicks,omitempty"` // 最小点击数
-	MaxClicks *uint64    `json:"max_clicks,omitempty"` // 最大点击数
+	UserID      *uint      `json:"user_id,omitempty"`      // 用户ID过滤
+	WorkspaceID *uint      `json:"workspace_id,omitempty"` // 工作空间过滤，由调用者的当前空间决定
+	IsExpired   *bool      `json:"is_expired,omitempty"`   // 是否已过期
+	StartTime   *time.Time `json:"start_time,omitempty"`   // 创建时间范围开始
+	EndTime     *time.Time `json:"end_time,omitempty"`     // 创建时间范围结束
+	MinClicks   *uint64    `json:"min_clicks,omitempty"`   // 最小点击数
+	MaxClicks   *uint64    `json:"max_clicks,omitempty"`   // 最大点击数
+	Campaign    *string    `json:"campaign,omitempty"`     // UTM活动名称
 }
 
 // ShortLinkSort 表示短链接排序条件
@@ -168,11 +323,16 @@ type PaginatedShortLinks struct {
 
 // UpdateShortLinkInput 表示更新短链接的输入参数
 type UpdateShortLinkInput struct {
-	LongURL         *string       `json:"long_url,omitempty"`
-	MaxVisits       *uint64       `json:"max_visits,omitempty"`
-	ExpiresAt       *time.Time    `json:"expires_at,omitempty"`
-	NeverExpire     *bool         `json:"never_expire,omitempty"`
-	DefaultRedirect *RedirectType `json:"default_redirect,omitempty"`
+	LongURL          *string           `json:"long_url,omitempty"`
+	MaxVisits        *uint64           `json:"max_visits,omitempty"`
+	ExpiresAt        *time.Time        `json:"expires_at,omitempty"`
+	NeverExpire      *bool             `json:"never_expire,omitempty"`
+	DefaultRedirect  *RedirectType     `json:"default_redirect,omitempty"`
+	Password         *string           `json:"password,omitempty"`          // 访问密码，空字符串表示取消密码
+	QueryPassthrough *QueryPassthrough `json:"query_passthrough,omitempty"` // 查询参数转发方式
+	UTM              *UTM              `json:"utm,omitempty"`               // UTM参数，整体替换原有参数
+	AppLinks         *AppLinks         `json:"app_links,omitempty"`         // 移动端App深度链接，整体替换原有配置
+	PathForwarding   *bool             `json:"path_forwarding,omitempty"`   // 是否转发短码之后的路径
 }
 
 // ClickLogFilter 表示访问记录查询过滤条件
@@ -183,6 +343,8 @@ type ClickLogFilter struct {
 	Country   *string     `json:"country,omitempty"`    // 国家/地区
 	Device    *DeviceType `json:"device,omitempty"`     // 设备类型
 	RuleID    *uint       `json:"rule_id,omitempty"`    // 规则ID
+	Kind      *ClickKind  `json:"kind,omitempty"`       // 访问记录类型
+	Campaign  *string     `json:"campaign,omitempty"`   // UTM活动名称
 }
 
 // ClickLogSort 表示访问记录排序条件
@@ -208,39 +370,132 @@ type PaginatedClickLogs struct {
 	Data        []ClickLog `json:"data"`         // 当前页数据
 }
 
+// ClickCounterStats 表示点击计数器的运行状态
+type ClickCounterStats struct {
+	Backlog          int64         `json:"backlog"`            // 待同步的短码数量
+	LastFlushAt      time.Time     `json:"last_flush_at"`      // 最近一次同步时间
+	LastFlushLatency time.Duration `json:"last_flush_latency"` // 最近一次同步耗时(纳秒)
+	LastFlushCodes   int64         `json:"last_flush_codes"`   // 最近一次同步的短码数量
+	FlushedClicks    uint64        `json:"flushed_clicks"`     // 累计同步的点击数
+	FlushErrors      uint64        `json:"flush_errors"`       // 累计同步失败次数
+	LastFlushError   string        `json:"last_flush_error"`   // 最近一次同步错误
+}
+
+// ClickLogWriterStats 表示点击日志写入器的运行状态
+type ClickLogWriterStats struct {
+	QueueDepth       int           `json:"queue_depth"`        // 当前队列长度
+	QueueCapacity    int           `json:"queue_capacity"`     // 队列容量
+	Enqueued         uint64        `json:"enqueued"`           // 累计入队数
+	Written          uint64        `json:"written"`            // 累计写入数
+	Dropped          uint64        `json:"dropped"`            // 累计因队列已满丢弃数
+	Spilled          uint64        `json:"spilled"`            // 累计溢出到磁盘数
+	Failed           uint64        `json:"failed"`             // 累计写入失败数
+	LastBatchLatency time.Duration `json:"last_batch_latency"` // 最近一批写入耗时(纳秒)
+}
+
+// ClickStreamStats 表示点击事件流消费者的运行状态
+type ClickStreamStats struct {
+	Length       int64  `json:"length"`        // 事件流当前长度
+	Pending      int64  `json:"pending"`       // 已投递但尚未确认的事件数
+	DeadLetters  int64  `json:"dead_letters"`  // 死信流长度
+	Processed    uint64 `json:"processed"`     // 累计写入并确认数
+	Retried      uint64 `json:"retried"`       // 累计重新认领数
+	DeadLettered uint64 `json:"dead_lettered"` // 累计转入死信流数
+	Failed       uint64 `json:"failed"`        // 累计写入失败数
+}
+
+// AttemptLimiter 限制同一对象在时间窗口内的尝试次数
+type AttemptLimiter interface {
+	// Attempt 记录一次尝试，超出次数时返回false和需要等待的时间
+	Attempt(ctx context.Context, key string) (bool, time.Duration, error)
+}
+
+// RedirectRequest 表示访问者请求中影响跳转目标的部分
+type RedirectRequest struct {
+	RawQuery string   // 访问短链接时的原始查询字符串，不含 ?
+	Path     string   // 短码之后的路径，保持原始编码，如 /docs/install；未开启路径转发的短链接只接受空路径
+	OS       MobileOS // 访问者的移动操作系统，用于选择App深度链接
+}
+
+// AppLaunch 表示需要先尝试打开App的跳转，由中间页尝试打开深度链接，超时后跳转到 Fallback
+type AppLaunch struct {
+	URL      string // App深度链接
+	Fallback string // 未安装App时的跳转地址，为应用商店地址或网页目标
+}
+
+// RedirectResult 表示访问短链接的跳转结果
+type RedirectResult struct {
+	URL  string       // 网页跳转目标
+	Type RedirectType // 跳转类型
+	App  *AppLaunch   // 访问者的系统配置了App深度链接时不为空
+}
+
+// LinkPreview 表示短链接预览页展示的信息，供访问者在跳转前确认目标地址
+type LinkPreview struct {
+	ShortCode     string    // 短码
+	LongURL       string    // 目标URL
+	Domain        string    // 目标域名
+	CreatedAt     time.Time // 创建时间
+	WorkspaceID   uint      // 所属工作空间，为0表示个人空间
+	WorkspaceName string    // 所属工作空间名称，查询失败时为空
+	HasRules      bool      // 是否配置了跳转规则，配置时实际跳转目标可能不同
+}
+
+// CampaignStatsQuery 表示按活动统计点击的查询条件，时间范围为 [Start, End)
+type CampaignStatsQuery struct {
+	UserID      *uint     // 短链接所属用户，为空表示不限制
+	WorkspaceID *uint     // 短链接所属工作空间，为空表示不限制
+	Start       time.Time // 开始时间(含)，为零值表示不限制
+	End         time.Time // 结束时间(不含)，为零值表示不限制
+}
+
+// CampaignStats 表示一个UTM活动的点击统计，活动名称为空表示未设置活动
+type CampaignStats struct {
+	Campaign string `json:"campaign"` // 活动名称
+	Clicks   int64  `json:"clicks"`   // 点击数
+	Links    int64  `json:"links"`    // 产生点击的短链接数
+}
+
 // ShortLinkRepository 定义短链接仓储接口
 type ShortLinkRepository interface {
-	Create(link *ShortLink) error
-	GetByCode(code string) (*ShortLink, error)
-	Update(link *ShortLink) error
-	Delete(code string) error
-	IncrementClicks(code string) error
-	LogClick(log *ClickLog) error
-	List(query *PaginationQuery) (*PaginatedShortLinks, error)
-	ListClickLogs(shortLinkID uint, query *ClickLogQuery) (*PaginatedClickLogs, error) // 新增：获取访问记录列表
+	// Create 创建短链接，maxActive 大于0时与写入在同一事务中检查归属空间的有效短链接数，达到上限返回 ErrQuotaExceeded
+	Create(ctx context.Context, link *ShortLink, maxActive int64) error
+	GetByCode(ctx context.Context, code string) (*ShortLink, error)
+	// Update 更新短链接，maxActive 含义与 Create 相同，用于已过期的短链接重新生效时检查配额
+	Update(ctx context.Context, link *ShortLink, maxActive int64) error
+	Delete(ctx context.Context, code string) error
+	IncrementClicks(ctx context.Context, code string) error
+	LogClick(ctx context.Context, log *ClickLog) error
+	List(ctx context.Context, query *PaginationQuery) (*PaginatedShortLinks, error)
+	ListClickLogs(ctx context.Context, shortLinkID uint, query *ClickLogQuery) (*PaginatedClickLogs, error) // 新增：获取访问记录列表
+	CampaignStats(ctx context.Context, query *CampaignStatsQuery) ([]CampaignStats, error)                  // 按UTM活动统计点击数，不含预览，按点击数降序
 
 	// 规则相关
-	CreateRule(rule *RedirectRule) error
-	UpdateRule(rule *RedirectRule) error
-	DeleteRule(ruleID uint) error
-	GetRules(shortLinkID uint) ([]RedirectRule, error)
-	UpdateRules(shortLinkID uint, rules []RedirectRule) error
+	CreateRule(ctx context.Context, rule *RedirectRule) error
+	UpdateRule(ctx context.Context, rule *RedirectRule) error            // 规则不属于 rule.ShortLinkID 时返回 ErrRuleNotFound
+	DeleteRule(ctx context.Context, shortLinkID uint, ruleID uint) error // 规则不属于该短链接时返回 ErrRuleNotFound
+	GetRules(ctx context.Context, shortLinkID uint) ([]RedirectRule, error)
+	UpdateRules(ctx context.Context, shortLinkID uint, rules []RedirectRule) error
 }
 
 // ShortLinkUseCase 定义短链接用例接口
+// 除 Redirect 外所有操作仅限调用者自己的短链接，管理员可以访问所有用户的短链接
 type ShortLinkUseCase interface {
-	Create(input *CreateShortLinkInput) (*ShortLink, error)
-	Get(code string) (*ShortLink, error)
-	Redirect(code string, clickLog *ClickLog) (string, RedirectType, error)
-	Delete(code string) error
-	List(query *PaginationQuery) (*PaginatedShortLinks, error)
-	Update(code string, input *UpdateShortLinkInput) (*ShortLink, error)
-	ListClickLogs(code string, query *ClickLogQuery) (*PaginatedClickLogs, error)
+	Create(ctx context.Context, input *CreateShortLinkInput) (*ShortLink, error)
+	Get(ctx context.Context, code string) (*ShortLink, error)
+	Redirect(ctx context.Context, code string, req RedirectRequest, clickLog *ClickLog) (*RedirectResult, error) // 密码保护的短链接需要在context中携带有效的解锁令牌
+	Unlock(ctx context.Context, code, password, ip string) (string, time.Time, error)                            // 校验访问密码，返回解锁令牌及其过期时间
+	Preview(ctx context.Context, code string, clickLog *ClickLog) (*LinkPreview, error)                          // 获取预览信息并记录预览，不计入点击数
+	Delete(ctx context.Context, code string) error
+	List(ctx context.Context, query *PaginationQuery) (*PaginatedShortLinks, error)
+	Update(ctx context.Context, code string, input *UpdateShortLinkInput) (*ShortLink, error)
+	ListClickLogs(ctx context.Context, code string, query *ClickLogQuery) (*PaginatedClickLogs, error)
+	CampaignStats(ctx context.Context, query *CampaignStatsQuery) ([]CampaignStats, error) // 统计调用者当前空间的短链接按UTM活动的点击数
 
 	// 规则相关
-	CreateRule(input *CreateRuleInput) (*RedirectRule, error)
-	UpdateRule(ruleID uint, input *CreateRuleInput) (*RedirectRule, error)
-	DeleteRule(ruleID uint) error
-	GetRules(shortLinkID uint) ([]RedirectRule, error)
-	UpdateRules(shortLinkID uint, rules []CreateRuleInput) ([]RedirectRule, error)
+	CreateRule(ctx context.Context, code string, input *CreateRuleInput) (*RedirectRule, error)
+	UpdateRule(ctx context.Context, code string, ruleID uint, input *CreateRuleInput) (*RedirectRule, error)
+	DeleteRule(ctx context.Context, code string, ruleID uint) error
+	GetRules(ctx context.Context, code string) ([]RedirectRule, error)
+	UpdateRules(ctx context.Context, code string, rules []CreateRuleInput) ([]RedirectRule, error)
 }
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// UsageOwner 表示用量的归属，工作空间的用量按空间计算，个人空间按用户计算
+type UsageOwner struct {
+	UserID      uint // 用户ID，WorkspaceID 不为0时忽略
+	WorkspaceID uint // 工作空间ID，为0表示用户的个人空间
+}
+
+// UsageItem 表示单项配额的用量
+type UsageItem struct {
+	Used  int64 `json:"used"`  // 已使用
+	Limit int64 `json:"limit"` // 配额，0表示不限制
+}
+
+// Exhausted 判断配额是否已用尽
+func (i UsageItem) Exhausted() bool {
+	return i.Limit > 0 && i.Used >= i.Limit
+}
+
+// Usage 表示用户或工作空间当前的用量
+type Usage struct {
+	UserID        uint      `json:"user_id,omitempty"`      // 个人空间所属用户
+	WorkspaceID   uint      `json:"workspace_id,omitempty"` // 工作空间
+	Period        string    `json:"period"`                 // 统计月份(UTC)，格式为 YYYY-MM
+	ResetsAt      time.Time `json:"resets_at"`              // 月度用量重置时间
+	ActiveLinks   UsageItem `json:"active_links"`           // 未过期的短链接数
+	MonthlyClicks UsageItem `json:"monthly_clicks"`         // 本月记录访问日志的点击数
+}
+
+// UsageRepository 定义用量统计仓储接口
+type UsageRepository interface {
+	// CountActiveLinks 统计未过期的短链接数
+	CountActiveLinks(ctx context.Context, owner UsageOwner) (int64, error)
+	// IncrClicks 增加指定月份的点击数并返回新值，n 可以为负数
+	IncrClicks(ctx context.Context, owner UsageOwner, month time.Time, n int64) (int64, error)
+	// GetClicks 获取指定月份的点击数
+	GetClicks(ctx context.Context, owner UsageOwner, month time.Time) (int64, error)
+}
+
+// UsageUseCase 定义用量查询用例接口
+type UsageUseCase interface {
+	// Get 获取调用者当前空间的用量
+	Get(ctx context.Context) (*Usage, error)
+}
@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Role 表示工作空间成员的角色
+type Role string
+
+const (
+	// RoleOwner 所有者，拥有全部权限，可以管理成员、查看审计日志和导出用量记录
+	RoleOwner Role = "owner"
+	// RoleEditor 编辑者，可以管理短链接和跳转规则，查看访问记录
+	RoleEditor Role = "editor"
+	// RoleAnalyst 分析者，可以查看短链接和访问记录
+	RoleAnalyst Role = "analyst"
+	// RoleViewer 查看者，只能查看短链接和跳转规则
+	RoleViewer Role = "viewer"
+)
+
+// Permission 表示工作空间内的操作权限
+type Permission string
+
+const (
+	// PermLinkRead 查看短链接和跳转规则
+	PermLinkRead Permission = "link:read"
+	// PermLinkWrite 创建、更新和删除短链接
+	PermLinkWrite Permission = "link:write"
+	// PermRuleWrite 创建、更新和删除跳转规则
+	PermRuleWrite Permission = "rule:write"
+	// PermClickLogRead 查看访问记录
+	PermClickLogRead Permission = "clicklog:read"
+	// PermMemberManage 管理工作空间成员
+	PermMemberManage Permission = "member:manage"
+	// PermAuditRead 查看审计日志
+	PermAuditRead Permission = "audit:read"
+	// PermUsageExport 导出用量计量记录
+	PermUsageExport Permission = "usage:export"
+)
+
+// rolePermissions 各角色拥有的权限
+var rolePermissions = map[Role][]Permission{
+	RoleOwner:   {PermLinkRead, PermLinkWrite, PermRuleWrite, PermClickLogRead, PermMemberManage, PermAuditRead, PermUsageExport},
+	RoleEditor:  {PermLinkRead, PermLinkWrite, PermRuleWrite, PermClickLogRead},
+	RoleAnalyst: {PermLinkRead, PermClickLogRead},
+	RoleViewer:  {PermLinkRead},
+}
+
+// ValidRole 判断角色是否合法
+func ValidRole(r Role) bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Can 判断角色是否拥有指定权限
+func (r Role) Can(perm Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Workspace 表示一个工作空间，工作空间内的短链接由所有成员按角色共享
+type Workspace struct {
+	ID        uint      `json:"id" gorm:"column:id;primaryKey"`
+	Name      string    `json:"name" gorm:"column:name;size:100"`
+	CreatedBy uint      `json:"created_by" gorm:"column:created_by"`
+	Role      Role      `json:"role,omitempty" gorm:"-"` // 调用者在工作空间中的角色
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (Workspace) TableName() string {
+	return "workspaces"
+}
+
+// WorkspaceMember 表示工作空间成员
+type WorkspaceMember struct {
+	WorkspaceID uint      `json:"workspace_id" gorm:"column:workspace_id;primaryKey;autoIncrement:false"`
+	UserID      uint      `json:"user_id" gorm:"column:user_id;primaryKey;autoIncrement:false;index"`
+	Role        Role      `json:"role" gorm:"column:role;size:16"`
+	CreatedAt   time.Time `json:"created_at" gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
+}
+
+// TableName 指定表名
+func (WorkspaceMember) TableName() string {
+	return "workspace_members"
+}
+
+// CreateWorkspaceInput 表示创建工作空间的输入参数
+type CreateWorkspaceInput struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// SetMemberInput 表示添加或修改工作空间成员的输入参数
+type SetMemberInput struct {
+	Role Role `json:"role" binding:"required"`
+}
+
+// WorkspaceRepository 定义工作空间仓储接口
+type WorkspaceRepository interface {
+	// Create 创建工作空间，并将创建者添加为所有者
+	Create(ctx context.Context, ws *Workspace) error
+	GetByID(ctx context.Context, id uint) (*Workspace, error)
+	// List 获取用户所在的工作空间，userID为0时返回所有工作空间
+	List(ctx context.Context, userID uint) ([]Workspace, error)
+	GetMember(ctx context.Context, workspaceID, userID uint) (*WorkspaceMember, error)
+	ListMembers(ctx context.Context, workspaceID uint) ([]WorkspaceMember, error)
+	// SetMember 添加成员或修改成员角色，降级最后一个所有者时返回 ErrLastOwner
+	SetMember(ctx context.Context, member *WorkspaceMember) error
+	// RemoveMember 移除工作空间成员，移除最后一个所有者时返回 ErrLastOwner
+	RemoveMember(ctx context.Context, workspaceID, userID uint) error
+}
+
+// WorkspaceUseCase 定义工作空间用例接口
+type WorkspaceUseCase interface {
+	Create(ctx context.Context, input *CreateWorkspaceInput) (*Workspace, error)
+	List(ctx context.Context) ([]Workspace, error)
+	ListMembers(ctx context.Context, workspaceID uint) ([]WorkspaceMember, error)
+	SetMember(ctx context.Context, workspaceID, userID uint, input *SetMemberInput) (*WorkspaceMember, error)
+	RemoveMember(ctx context.Context, workspaceID, userID uint) error
+	// Resolve 返回调用者在工作空间中的角色，不是成员时返回 ErrWorkspaceNotFound
+	Resolve(ctx context.Context, workspaceID uint) (Role, error)
+}
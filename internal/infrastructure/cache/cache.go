@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"linkit/internal/config"
+)
+
+// ErrCacheMiss 表示缓存未命中
+var ErrCacheMiss = errors.New("cache miss")
+
+// 缓存驱动类型
+const (
+	// DriverRedis 使用Redis作为缓存后端
+	DriverRedis = "redis"
+	// DriverMemory 使用进程内LRU缓存作为后端，适用于单节点部署
+	DriverMemory = "memory"
+)
+
+// Cache 定义缓存与计数器接口
+// 短链接缓存、规则缓存、点击计数器、同步锁以及限流计数均通过该接口访问
+type Cache interface {
+	// Get 获取字符串值，不存在时返回 ErrCacheMiss
+	Get(ctx context.Context, key string) (string, error)
+	// Set 设置字符串值，ttl为0表示永不过期，小于0表示保留原有过期时间
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	// SetNX 仅当键不存在时设置值，返回是否设置成功
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// Del 删除键
+	Del(ctx context.Context, keys ...string) error
+	// DelIfEqual 仅当键的值等于 value 时删除，返回是否删除，用于释放自己持有的锁
+	DelIfEqual(ctx context.Context, key, value string) (bool, error)
+	// TTL 获取键的剩余过期时间，永不过期时返回-1，不存在时返回 ErrCacheMiss
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// IncrBy 原子递增计数器并返回新值
+	IncrBy(ctx context.Context, key string, n int64) (int64, error)
+	// DecrBy 原子递减计数器并返回新值
+	DecrBy(ctx context.Context, key string, n int64) (int64, error)
+	// DecrByAll 在一个原子操作中递减多个计数器并删除指定的键
+	DecrByAll(ctx context.Context, deltas map[string]int64, del ...string) error
+	// SAdd 向集合添加成员
+	SAdd(ctx context.Context, key string, members ...string) error
+	// SRem 从集合移除成员
+	SRem(ctx context.Context, key string, members ...string) error
+	// SMembers 获取集合的所有成员
+	SMembers(ctx context.Context, key string) ([]string, error)
+	// SCard 获取集合的成员数量
+	SCard(ctx context.Context, key string) (int64, error)
+	// SlidingWindow 滑动窗口限流计数，未超过 limit 时计入本次请求
+	SlidingWindow(ctx context.Context, key string, limit int64, window time.Duration) (RateLimitResult, error)
+	// DelPrefix 删除所有指定前缀的键
+	DelPrefix(ctx context.Context, prefix string) error
+	// Ping 检查缓存后端是否可用
+	Ping(ctx context.Context) error
+	// Close 释放缓存资源
+	Close() error
+}
+
+// NewCache 根据配置创建缓存实例，Redis驱动使用 redisCfg 连接
+func NewCache(cfg config.CacheConfig, redisCfg config.RedisConfig) (Cache, error) {
+	switch driver := cfg.Driver; driver {
+	case "", DriverRedis:
+		client, err := NewRedisClient(redisCfg)
+		if err != nil {
+			return nil, err
+		}
+		return NewRedisCache(client), nil
+	case DriverMemory:
+		return NewMemoryCache(cfg.Memory.MaxEntries), nil
+	default:
+		return nil, fmt.Errorf("unknown cache driver: %s", driver)
+	}
+}
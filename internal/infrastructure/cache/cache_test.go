@@ -0,0 +1,358 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// testRedisAddrEnv 设置后同时对该地址的Redis运行一致性测试，如 localhost:6379
+const testRedisAddrEnv = "LINKIT_TEST_REDIS_ADDR"
+
+// forEachCache 对内存缓存以及配置了地址的Redis运行同一组用例
+// key 为键加上本次测试独有的前缀，避免与Redis中的其他数据冲突
+func forEachCache(t *testing.T, fn func(t *testing.T, c Cache, key func(string) string)) {
+	t.Helper()
+	backends := map[string]func(t *testing.T) Cache{
+		"memory": func(*testing.T) Cache { return NewMemoryCache(0) },
+		"redis": func(t *testing.T) Cache {
+			addr := os.Getenv(testRedisAddrEnv)
+			if addr == "" {
+				t.Skipf("%s not set", testRedisAddrEnv)
+			}
+			client := redis.NewClient(&redis.Options{Addr: addr})
+			if err := client.Ping(context.Background()).Err(); err != nil {
+				t.Skipf("redis unavailable: %v", err)
+			}
+			return NewRedisCache(client)
+		},
+	}
+	for _, name := range []string{"memory", "redis"} {
+		t.Run(name, func(t *testing.T) {
+			c := backends[name](t)
+			prefix := fmt.Sprintf("linkit-test:%s:%d:", t.Name(), time.Now().UnixNano())
+			t.Cleanup(func() {
+				c.DelPrefix(context.Background(), prefix)
+				c.Close()
+			})
+			fn(t, c, func(k string) string { return prefix + k })
+		})
+	}
+}
+
+// wantTTL 检查剩余过期时间在 (want-1s, want] 之间，Redis的TTL精度为秒
+func wantTTL(t *testing.T, c Cache, key string, want time.Duration) {
+	t.Helper()
+	ttl, err := c.TTL(context.Background(), key)
+	if err != nil {
+		t.Fatalf("TTL(%s) error = %v", key, err)
+	}
+	if want < 0 {
+		if ttl != -1 {
+			t.Errorf("TTL(%s) = %v, want -1", key, ttl)
+		}
+		return
+	}
+	if ttl <= want-time.Second || ttl > want {
+		t.Errorf("TTL(%s) = %v, want about %v", key, ttl, want)
+	}
+}
+
+// wantGet 检查键的值
+func wantGet(t *testing.T, c Cache, key, want string) {
+	t.Helper()
+	got, err := c.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%s) error = %v", key, err)
+	}
+	if got != want {
+		t.Errorf("Get(%s) = %q, want %q", key, got, want)
+	}
+}
+
+// wantMiss 检查键不存在
+func wantMiss(t *testing.T, c Cache, key string) {
+	t.Helper()
+	if _, err := c.Get(context.Background(), key); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Get(%s) error = %v, want ErrCacheMiss", key, err)
+	}
+}
+
+func TestCacheStringTTL(t *testing.T) {
+	forEachCache(t, func(t *testing.T, c Cache, key func(string) string) {
+		ctx := context.Background()
+
+		wantMiss(t, c, key("missing"))
+		if _, err := c.TTL(ctx, key("missing")); !errors.Is(err, ErrCacheMiss) {
+			t.Errorf("TTL(missing) error = %v, want ErrCacheMiss", err)
+		}
+
+		if err := c.Set(ctx, key("forever"), "v", 0); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		wantGet(t, c, key("forever"), "v")
+		wantTTL(t, c, key("forever"), -1)
+
+		// ttl<0 保留原有过期时间，只替换值
+		if err := c.Set(ctx, key("link"), "v1", time.Hour); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		if err := c.Set(ctx, key("link"), "v2", -1); err != nil {
+			t.Fatalf("Set(ttl<0) error = %v", err)
+		}
+		wantGet(t, c, key("link"), "v2")
+		wantTTL(t, c, key("link"), time.Hour)
+
+		// 不存在的键使用ttl<0时永不过期
+		if err := c.Set(ctx, key("new"), "v", -1); err != nil {
+			t.Fatalf("Set(ttl<0) error = %v", err)
+		}
+		wantTTL(t, c, key("new"), -1)
+
+		// 重新设置正数ttl时覆盖过期时间
+		if err := c.Set(ctx, key("forever"), "v", time.Minute); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		wantTTL(t, c, key("forever"), time.Minute)
+
+		if err := c.Set(ctx, key("short"), "v", 100*time.Millisecond); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		time.Sleep(200 * time.Millisecond)
+		wantMiss(t, c, key("short"))
+		if ok, err := c.SetNX(ctx, key("short"), "again", time.Minute); err != nil || !ok {
+			t.Errorf("SetNX() after expiry = %v, %v; want true", ok, err)
+		}
+	})
+}
+
+func TestCacheSetNXAndDelIfEqual(t *testing.T) {
+	forEachCache(t, func(t *testing.T, c Cache, key func(string) string) {
+		ctx := context.Background()
+		lock := key("lock")
+
+		if ok, err := c.SetNX(ctx, lock, "a", time.Minute); err != nil || !ok {
+			t.Fatalf("SetNX() = %v, %v; want true", ok, err)
+		}
+		if ok, err := c.SetNX(ctx, lock, "b", time.Minute); err != nil || ok {
+			t.Fatalf("SetNX() on held key = %v, %v; want false", ok, err)
+		}
+		wantGet(t, c, lock, "a")
+		wantTTL(t, c, lock, time.Minute)
+
+		if ok, err := c.DelIfEqual(ctx, lock, "b"); err != nil || ok {
+			t.Errorf("DelIfEqual(other token) = %v, %v; want false", ok, err)
+		}
+		wantGet(t, c, lock, "a")
+		if ok, err := c.DelIfEqual(ctx, lock, "a"); err != nil || !ok {
+			t.Errorf("DelIfEqual(own token) = %v, %v; want true", ok, err)
+		}
+		wantMiss(t, c, lock)
+		if ok, err := c.DelIfEqual(ctx, lock, "a"); err != nil || ok {
+			t.Errorf("DelIfEqual(missing) = %v, %v; want false", ok, err)
+		}
+
+		// 计数器不能作为锁重复获取
+		if _, err := c.IncrBy(ctx, key("counter"), 1); err != nil {
+			t.Fatalf("IncrBy() error = %v", err)
+		}
+		if ok, err := c.SetNX(ctx, key("counter"), "x", time.Minute); err != nil || ok {
+			t.Errorf("SetNX() on counter = %v, %v; want false", ok, err)
+		}
+	})
+}
+
+func TestCacheCounters(t *testing.T) {
+	forEachCache(t, func(t *testing.T, c Cache, key func(string) string) {
+		ctx := context.Background()
+		clicks := key("clicks")
+
+		if n, err := c.IncrBy(ctx, clicks, 3); err != nil || n != 3 {
+			t.Fatalf("IncrBy(missing) = %d, %v; want 3", n, err)
+		}
+		if n, err := c.DecrBy(ctx, clicks, 5); err != nil || n != -2 {
+			t.Fatalf("DecrBy() = %d, %v; want -2", n, err)
+		}
+		wantGet(t, c, clicks, "-2")
+		wantTTL(t, c, clicks, -1)
+
+		// 整数字符串可以继续递增，并保留原有过期时间
+		usage := key("usage")
+		if err := c.Set(ctx, usage, "10", time.Hour); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		if n, err := c.IncrBy(ctx, usage, 5); err != nil || n != 15 {
+			t.Fatalf("IncrBy(integer string) = %d, %v; want 15", n, err)
+		}
+		wantGet(t, c, usage, "15")
+		wantTTL(t, c, usage, time.Hour)
+
+		// 覆盖计数器后按字符串处理
+		if err := c.Set(ctx, usage, "7", -1); err != nil {
+			t.Fatalf("Set(ttl<0) error = %v", err)
+		}
+		wantTTL(t, c, usage, time.Hour)
+		if n, err := c.IncrBy(ctx, usage, 1); err != nil || n != 8 {
+			t.Fatalf("IncrBy() after Set = %d, %v; want 8", n, err)
+		}
+
+		// 非整数字符串不能递增，值保持不变
+		name := key("name")
+		if err := c.Set(ctx, name, "abc", 0); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		if _, err := c.IncrBy(ctx, name, 1); err == nil {
+			t.Error("IncrBy(non-integer) error = nil, want error")
+		}
+		wantGet(t, c, name, "abc")
+
+		// 带过期时间的计数器到期后从零开始
+		short := key("short")
+		if err := c.Set(ctx, short, "5", 100*time.Millisecond); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		if _, err := c.IncrBy(ctx, short, 1); err != nil {
+			t.Fatalf("IncrBy() error = %v", err)
+		}
+		time.Sleep(200 * time.Millisecond)
+		wantMiss(t, c, short)
+		if n, err := c.IncrBy(ctx, short, 1); err != nil || n != 1 {
+			t.Errorf("IncrBy() after expiry = %d, %v; want 1", n, err)
+		}
+	})
+}
+
+func TestCacheDecrByAll(t *testing.T) {
+	forEachCache(t, func(t *testing.T, c Cache, key func(string) string) {
+		ctx := context.Background()
+		a, b, missing := key("a"), key("b"), key("missing")
+		pending, lock := key("pending"), key("lock")
+
+		if _, err := c.IncrBy(ctx, a, 10); err != nil {
+			t.Fatalf("IncrBy() error = %v", err)
+		}
+		if err := c.Set(ctx, b, "4", 0); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		if err := c.SAdd(ctx, pending, "a", "b"); err != nil {
+			t.Fatalf("SAdd() error = %v", err)
+		}
+		if err := c.Set(ctx, lock, "token", time.Minute); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+
+		if err := c.DecrByAll(ctx, map[string]int64{a: 3, b: 4, missing: 2}, pending, lock); err != nil {
+			t.Fatalf("DecrByAll() error = %v", err)
+		}
+		wantGet(t, c, a, "7")
+		wantGet(t, c, b, "0")
+		wantGet(t, c, missing, "-2")
+		wantMiss(t, c, lock)
+		if n, err := c.SCard(ctx, pending); err != nil || n != 0 {
+			t.Errorf("SCard(deleted set) = %d, %v; want 0", n, err)
+		}
+	})
+}
+
+func TestCacheSets(t *testing.T) {
+	forEachCache(t, func(t *testing.T, c Cache, key func(string) string) {
+		ctx := context.Background()
+		set := key("set")
+
+		if err := c.SAdd(ctx, set, "x", "y", "x"); err != nil {
+			t.Fatalf("SAdd() error = %v", err)
+		}
+		if err := c.SAdd(ctx, set, "z"); err != nil {
+			t.Fatalf("SAdd() error = %v", err)
+		}
+		members, err := c.SMembers(ctx, set)
+		if err != nil {
+			t.Fatalf("SMembers() error = %v", err)
+		}
+		sort.Strings(members)
+		if fmt.Sprint(members) != "[x y z]" {
+			t.Errorf("SMembers() = %v, want [x y z]", members)
+		}
+
+		if err := c.SRem(ctx, set, "x", "missing"); err != nil {
+			t.Fatalf("SRem() error = %v", err)
+		}
+		if n, err := c.SCard(ctx, set); err != nil || n != 2 {
+			t.Errorf("SCard() = %d, %v; want 2", n, err)
+		}
+		if err := c.SRem(ctx, set, "y", "z"); err != nil {
+			t.Fatalf("SRem() error = %v", err)
+		}
+		if members, err := c.SMembers(ctx, set); err != nil || len(members) != 0 {
+			t.Errorf("SMembers(empty) = %v, %v; want []", members, err)
+		}
+		if err := c.SRem(ctx, key("missing"), "x"); err != nil {
+			t.Errorf("SRem(missing) error = %v", err)
+		}
+	})
+}
+
+func TestCacheDelPrefix(t *testing.T) {
+	forEachCache(t, func(t *testing.T, c Cache, key func(string) string) {
+		ctx := context.Background()
+
+		if err := c.Set(ctx, key("link:a"), "v", time.Hour); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+		if _, err := c.IncrBy(ctx, key("link:b"), 1); err != nil {
+			t.Fatalf("IncrBy() error = %v", err)
+		}
+		if err := c.SAdd(ctx, key("link:c"), "m"); err != nil {
+			t.Fatalf("SAdd() error = %v", err)
+		}
+		if err := c.Set(ctx, key("rules:a"), "v", time.Hour); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+
+		if err := c.DelPrefix(ctx, key("link:")); err != nil {
+			t.Fatalf("DelPrefix() error = %v", err)
+		}
+		wantMiss(t, c, key("link:a"))
+		wantMiss(t, c, key("link:b"))
+		if n, err := c.SCard(ctx, key("link:c")); err != nil || n != 0 {
+			t.Errorf("SCard(link:c) = %d, %v; want 0", n, err)
+		}
+		wantGet(t, c, key("rules:a"), "v")
+	})
+}
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(2)
+
+	c.Set(ctx, "a", "1", 0)
+	c.Set(ctx, "b", "2", 0)
+	wantGet(t, c, "a", "1") // a 成为最近使用的条目
+	c.Set(ctx, "c", "3", 0)
+
+	wantMiss(t, c, "b")
+	wantGet(t, c, "a", "1")
+	wantGet(t, c, "c", "3")
+
+	// 计数器和集合不参与淘汰
+	if _, err := c.IncrBy(ctx, "clicks", 1); err != nil {
+		t.Fatalf("IncrBy() error = %v", err)
+	}
+	c.SAdd(ctx, "pending", "x")
+	for i := 0; i < 10; i++ {
+		c.Set(ctx, fmt.Sprintf("k%d", i), "v", 0)
+	}
+	wantGet(t, c, "clicks", "1")
+	if n, _ := c.SCard(ctx, "pending"); n != 1 {
+		t.Errorf("SCard(pending) = %d, want 1", n)
+	}
+	if c.ll.Len() != 2 {
+		t.Errorf("entries = %d, want 2", c.ll.Len())
+	}
+}
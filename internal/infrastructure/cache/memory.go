@@ -0,0 +1,379 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultMemoryMaxEntries 内存缓存默认最大条目数
+const defaultMemoryMaxEntries = 100000
+
+// memoryEntry 内存缓存条目
+type memoryEntry struct {
+	key       string
+	value     string
+	expiresAt time.Time // 零值表示永不过期
+}
+
+// expired 判断条目是否已过期
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// memoryCounter 内存计数器
+type memoryCounter struct {
+	n         int64
+	expiresAt time.Time // 由带过期时间的字符串值转换而来时保留其过期时间，零值表示永不过期
+}
+
+// MemoryCache 基于进程内存实现的LRU + TTL缓存
+// 计数器和集合单独存放，不参与LRU淘汰，避免丢失尚未同步的点击数
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+	counters   map[string]*memoryCounter
+	sets       map[string]map[string]struct{}
+	windows    map[string]*memoryWindow
+
+	windowsSweptAt time.Time // 上一次清理滑动窗口的时间
+}
+
+// NewMemoryCache 创建内存缓存实例
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultMemoryMaxEntries
+	}
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		counters:   make(map[string]*memoryCounter),
+		sets:       make(map[string]map[string]struct{}),
+		windows:    make(map[string]*memoryWindow),
+	}
+}
+
+// counter 查找未过期的计数器
+func (c *MemoryCache) counter(key string, now time.Time) *memoryCounter {
+	counter, ok := c.counters[key]
+	if !ok {
+		return nil
+	}
+	if !counter.expiresAt.IsZero() && !now.Before(counter.expiresAt) {
+		delete(c.counters, key)
+		return nil
+	}
+	return counter
+}
+
+// lookup 查找未过期的条目，并将其移动到LRU链表头部
+func (c *MemoryCache) lookup(key string, now time.Time) *memoryEntry {
+	el, ok := c.items[key]
+	if !ok {
+		return nil
+	}
+	entry := el.Value.(*memoryEntry)
+	if entry.expired(now) {
+		c.removeElement(el)
+		return nil
+	}
+	c.ll.MoveToFront(el)
+	return entry
+}
+
+// removeElement 从缓存中移除条目
+func (c *MemoryCache) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*memoryEntry).key)
+}
+
+// store 写入条目，超出容量时淘汰最久未使用的条目
+func (c *MemoryCache) store(key, value string, expiresAt time.Time) {
+	delete(c.counters, key)
+
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.ll.Len() > c.maxEntries {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// expiresAt 根据ttl计算过期时间
+func expiresAt(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return now.Add(ttl)
+}
+
+// Get 获取字符串值
+func (c *MemoryCache) Get(_ context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if counter := c.counter(key, now); counter != nil {
+		return strconv.FormatInt(counter.n, 10), nil
+	}
+	if entry := c.lookup(key, now); entry != nil {
+		return entry.value, nil
+	}
+	return "", ErrCacheMiss
+}
+
+// Set 设置字符串值
+func (c *MemoryCache) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	exp := expiresAt(now, ttl)
+	if ttl < 0 {
+		// 保留原有过期时间
+		if counter := c.counter(key, now); counter != nil {
+			exp = counter.expiresAt
+		} else if entry := c.lookup(key, now); entry != nil {
+			exp = entry.expiresAt
+		}
+	}
+	c.store(key, value, exp)
+	return nil
+}
+
+// SetNX 仅当键不存在时设置值
+func (c *MemoryCache) SetNX(_ context.Context, key, value string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.counter(key, now) != nil {
+		return false, nil
+	}
+	if entry := c.lookup(key, now); entry != nil {
+		return false, nil
+	}
+	c.store(key, value, expiresAt(now, ttl))
+	return true, nil
+}
+
+// Del 删除键
+func (c *MemoryCache) Del(_ context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		delete(c.counters, key)
+		delete(c.sets, key)
+		if el, ok := c.items[key]; ok {
+			c.removeElement(el)
+		}
+	}
+	return nil
+}
+
+// DelIfEqual 仅当键的值等于 value 时删除
+func (c *MemoryCache) DelIfEqual(_ context.Context, key, value string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.lookup(key, time.Now())
+	if entry == nil || entry.value != value {
+		return false, nil
+	}
+	c.removeElement(c.items[key])
+	return true, nil
+}
+
+// TTL 获取键的剩余过期时间
+func (c *MemoryCache) TTL(_ context.Context, key string) (time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	exp := time.Time{}
+	if counter := c.counter(key, now); counter != nil {
+		exp = counter.expiresAt
+	} else if entry := c.lookup(key, now); entry != nil {
+		exp = entry.expiresAt
+	} else {
+		return 0, ErrCacheMiss
+	}
+	if exp.IsZero() {
+		return -1, nil
+	}
+	return exp.Sub(now), nil
+}
+
+// IncrBy 原子递增计数器
+func (c *MemoryCache) IncrBy(_ context.Context, key string, n int64) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.incrBy(key, n)
+}
+
+// incrBy 递增计数器，调用方需持有锁
+func (c *MemoryCache) incrBy(key string, n int64) (int64, error) {
+	now := time.Now()
+	counter := c.counter(key, now)
+	if counter == nil {
+		counter = &memoryCounter{}
+		// 与Redis一致：已存在的字符串值必须是整数，递增后保留其过期时间
+		if entry := c.lookup(key, now); entry != nil {
+			v, err := strconv.ParseInt(entry.value, 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("value of %s is not an integer", key)
+			}
+			counter.n = v
+			counter.expiresAt = entry.expiresAt
+			c.removeElement(c.items[key])
+		}
+		c.counters[key] = counter
+	}
+	counter.n += n
+	return counter.n, nil
+}
+
+// DecrBy 原子递减计数器
+func (c *MemoryCache) DecrBy(ctx context.Context, key string, n int64) (int64, error) {
+	return c.IncrBy(ctx, key, -n)
+}
+
+// DecrByAll 在持有锁的情况下递减多个计数器并删除指定的键
+func (c *MemoryCache) DecrByAll(_ context.Context, deltas map[string]int64, del ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for key := range deltas {
+		if c.counter(key, now) != nil {
+			continue
+		}
+		if entry := c.lookup(key, now); entry != nil {
+			if _, err := strconv.ParseInt(entry.value, 10, 64); err != nil {
+				return fmt.Errorf("value of %s is not an integer", key)
+			}
+		}
+	}
+	for key, n := range deltas {
+		if _, err := c.incrBy(key, -n); err != nil {
+			return err
+		}
+	}
+	for _, key := range del {
+		delete(c.counters, key)
+		delete(c.sets, key)
+		if el, ok := c.items[key]; ok {
+			c.removeElement(el)
+		}
+	}
+	return nil
+}
+
+// SAdd 向集合添加成员
+func (c *MemoryCache) SAdd(_ context.Context, key string, members ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	set, ok := c.sets[key]
+	if !ok {
+		set = make(map[string]struct{}, len(members))
+		c.sets[key] = set
+	}
+	for _, m := range members {
+		set[m] = struct{}{}
+	}
+	return nil
+}
+
+// SRem 从集合移除成员
+func (c *MemoryCache) SRem(_ context.Context, key string, members ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	set, ok := c.sets[key]
+	if !ok {
+		return nil
+	}
+	for _, m := range members {
+		delete(set, m)
+	}
+	if len(set) == 0 {
+		delete(c.sets, key)
+	}
+	return nil
+}
+
+// SMembers 获取集合的所有成员
+func (c *MemoryCache) SMembers(_ context.Context, key string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	members := make([]string, 0, len(c.sets[key]))
+	for m := range c.sets[key] {
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+// SCard 获取集合的成员数量
+func (c *MemoryCache) SCard(_ context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return int64(len(c.sets[key])), nil
+}
+
+// DelPrefix 删除所有指定前缀的键
+func (c *MemoryCache) DelPrefix(_ context.Context, prefix string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.counters {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.counters, key)
+		}
+	}
+	for key := range c.sets {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.sets, key)
+		}
+	}
+	for key, el := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.removeElement(el)
+		}
+	}
+	return nil
+}
+
+// Ping 内存缓存始终可用
+func (c *MemoryCache) Ping(_ context.Context) error {
+	return nil
+}
+
+// Close 释放缓存资源
+func (c *MemoryCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.counters = make(map[string]*memoryCounter)
+	c.sets = make(map[string]map[string]struct{})
+	return nil
+}
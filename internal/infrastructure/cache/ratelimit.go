@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RateLimitResult 滑动窗口限流的计数结果
+type RateLimitResult struct {
+	Allowed    bool          // 是否允许本次请求
+	Limit      int64         // 窗口内允许的最大请求数
+	Remaining  int64         // 窗口内剩余的请求数
+	Reset      time.Duration // 距离当前固定窗口结束的时间
+	RetryAfter time.Duration // 被拒绝时需要等待的时间
+}
+
+// windowWeight 返回上一个固定窗口计数的权重，保留6位小数，保证与Lua脚本中的计算一致
+func windowWeight(window, elapsed time.Duration) (float64, string) {
+	s := strconv.FormatFloat(float64(window-elapsed)/float64(window), 'f', 6, 64)
+	weight, _ := strconv.ParseFloat(s, 64)
+	return weight, s
+}
+
+// slidingWindow 使用滑动窗口计数算法计算限流结果
+// 窗口内的请求数按上一个固定窗口的计数乘以剩余比例，加上当前固定窗口的计数估算
+// prev、cur 为本次请求计入前的计数
+func slidingWindow(prev, cur, limit int64, window, elapsed time.Duration) RateLimitResult {
+	weight, _ := windowWeight(window, elapsed)
+	count := int64(math.Floor(float64(prev)*weight)) + cur
+
+	result := RateLimitResult{
+		Limit: limit,
+		Reset: window - elapsed,
+	}
+	if count < limit {
+		result.Allowed = true
+		result.Remaining = limit - count - 1
+		return result
+	}
+
+	// 当前窗口已用尽时需等到下一个窗口；否则等待上一个窗口的计数衰减到允许一次请求
+	if cur >= limit || prev == 0 {
+		result.RetryAfter = window - elapsed
+	} else {
+		wait := window - elapsed - time.Duration(float64(limit-cur)*float64(window)/float64(prev))
+		result.RetryAfter = max(wait, time.Millisecond)
+	}
+	return result
+}
+
+// windowOf 返回当前固定窗口的序号和已经过的时间
+func windowOf(now time.Time, window time.Duration) (int64, time.Duration) {
+	ns := now.UnixNano()
+	return ns / int64(window), time.Duration(ns % int64(window))
+}
+
+// slidingWindowScript 原子地读取两个固定窗口的计数，未超限时递增当前窗口
+// KEYS[1] 当前窗口，KEYS[2] 上一个窗口；ARGV[1] 限额，ARGV[2] 上一个窗口的权重，ARGV[3] 过期时间(毫秒)
+// 返回 {是否允许, 上一个窗口计数, 计入前的当前窗口计数}
+var slidingWindowScript = redis.NewScript(`
+local cur = tonumber(redis.call('GET', KEYS[1]) or '0')
+local prev = tonumber(redis.call('GET', KEYS[2]) or '0')
+if math.floor(prev * tonumber(ARGV[2])) + cur >= tonumber(ARGV[1]) then
+	return {0, prev, cur}
+end
+if redis.call('INCR', KEYS[1]) == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[3])
+end
+return {1, prev, cur}
+`)
+
+// rateLimitKey 返回固定窗口计数器的键，使用哈希标签保证两个窗口位于同一个槽
+func rateLimitKey(key string, idx int64) string {
+	return fmt.Sprintf("ratelimit:{%s}:%d", key, idx)
+}
+
+// SlidingWindow 使用Lua脚本在Redis中进行滑动窗口计数
+func (c *RedisCache) SlidingWindow(ctx context.Context, key string, limit int64, window time.Duration) (RateLimitResult, error) {
+	idx, elapsed := windowOf(time.Now(), window)
+	_, weight := windowWeight(window, elapsed)
+
+	res, err := slidingWindowScript.Run(ctx, c.client,
+		[]string{rateLimitKey(key, idx), rateLimitKey(key, idx-1)},
+		limit, weight, (2 * window).Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	if len(res) != 3 {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit script result: %v", res)
+	}
+	return slidingWindow(res[1], res[2], limit, window, elapsed), nil
+}
+
+// windowSweepInterval 清理过期滑动窗口的最短间隔
+const windowSweepInterval = time.Minute
+
+// memoryWindow 内存中单个键的固定窗口计数
+type memoryWindow struct {
+	idx    int64         // 当前固定窗口序号
+	cur    int64         // 当前窗口计数
+	prev   int64         // 上一个窗口计数
+	window time.Duration // 窗口长度
+	last   time.Time     // 最近一次请求时间
+}
+
+// sweepWindows 删除最近一次请求已超过窗口长度的键，调用方需持有锁
+func (c *MemoryCache) sweepWindows(now time.Time) {
+	for k, w := range c.windows {
+		if now.Sub(w.last) >= w.window {
+			delete(c.windows, k)
+		}
+	}
+	c.windowsSweptAt = now
+}
+
+// SlidingWindow 在内存中进行滑动窗口计数，仅对当前进程有效
+func (c *MemoryCache) SlidingWindow(_ context.Context, key string, limit int64, window time.Duration) (RateLimitResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	// 定期清理不再活跃的键
+	if now.Sub(c.windowsSweptAt) >= windowSweepInterval {
+		c.sweepWindows(now)
+	}
+
+	idx, elapsed := windowOf(now, window)
+	w, ok := c.windows[key]
+	if !ok {
+		// 条目过多时提前清理
+		if len(c.windows) >= c.maxEntries {
+			c.sweepWindows(now)
+		}
+		w = &memoryWindow{idx: idx}
+		c.windows[key] = w
+	}
+	w.window, w.last = window, now
+	switch {
+	case w.idx == idx-1:
+		w.prev, w.cur = w.cur, 0
+	case w.idx < idx-1:
+		w.prev, w.cur = 0, 0
+	}
+	w.idx = idx
+
+	result := slidingWindow(w.prev, w.cur, limit, window, elapsed)
+	if result.Allowed {
+		w.cur++
+	}
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMemorySlidingWindowEvictsIdleKeys(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(0)
+
+	for i := 0; i < 100; i++ {
+		if _, err := c.SlidingWindow(ctx, fmt.Sprintf("idle:%d", i), 10, 20*time.Millisecond); err != nil {
+			t.Fatalf("SlidingWindow() error = %v", err)
+		}
+	}
+	if _, err := c.SlidingWindow(ctx, "active", 10, time.Hour); err != nil {
+		t.Fatalf("SlidingWindow() error = %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	// 模拟距离上一次清理已超过清理间隔
+	c.windowsSweptAt = time.Now().Add(-windowSweepInterval)
+	res, err := c.SlidingWindow(ctx, "active", 10, time.Hour)
+	if err != nil {
+		t.Fatalf("SlidingWindow() error = %v", err)
+	}
+	if res.Remaining != 8 {
+		t.Errorf("active key remaining = %d, want 8", res.Remaining)
+	}
+	if len(c.windows) != 1 {
+		t.Errorf("windows = %d, want 1", len(c.windows))
+	}
+}
+
+func TestMemorySlidingWindowEvictsWhenFull(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(10)
+
+	for i := 0; i < 10; i++ {
+		if _, err := c.SlidingWindow(ctx, fmt.Sprintf("idle:%d", i), 10, 10*time.Millisecond); err != nil {
+			t.Fatalf("SlidingWindow() error = %v", err)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := c.SlidingWindow(ctx, "new", 10, time.Hour); err != nil {
+		t.Fatalf("SlidingWindow() error = %v", err)
+	}
+	if len(c.windows) != 1 {
+		t.Errorf("windows = %d, want 1", len(c.windows))
+	}
+}
@@ -3,19 +3,24 @@ package cache
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-redis/redis/v8"
-	"github.com/spf13/viper"
+
+	"linkit/internal/config"
 )
 
 // NewRedisClient 创建Redis客户端连接
-func NewRedisClient() (*redis.Client, error) {
+func NewRedisClient(cfg config.RedisConfig) (*redis.Client, error) {
 	client := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", viper.GetString("redis.host"), viper.GetInt("redis.port")),
-		Password: viper.GetString("redis.password"),
-		DB:       viper.GetInt("redis.db"),
+		Addr:     cfg.Addr(),
+		Password: cfg.Password,
+		DB:       cfg.DB,
+		PoolSize: cfg.PoolSize,
 	})
 
+	client.AddHook(metricsHook{})
+
 	// 测试连接
 	ctx := context.Background()
 	if err := client.Ping(ctx).Err(); err != nil {
@@ -24,3 +29,157 @@ func NewRedisClient() (*redis.Client, error) {
 
 	return client, nil
 }
+
+// RedisCache 基于Redis实现的缓存
+type RedisCache struct {
+	client *redis.Client
+}
+
+// NewRedisCache 创建Redis缓存实例
+func NewRedisCache(client *redis.Client) *RedisCache {
+	return &RedisCache{
+		client: client,
+	}
+}
+
+// Get 获取字符串值
+func (c *RedisCache) Get(ctx context.Context, key string) (string, error) {
+	val, err := c.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", ErrCacheMiss
+	}
+	return val, err
+}
+
+// Set 设置字符串值
+func (c *RedisCache) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	if ttl < 0 {
+		ttl = redis.KeepTTL
+	}
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+// SetNX 仅当键不存在时设置值
+func (c *RedisCache) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, key, value, ttl).Result()
+}
+
+// Del 删除键
+func (c *RedisCache) Del(ctx context.Context, keys ...string) error {
+	return c.client.Del(ctx, keys...).Err()
+}
+
+// delIfEqualScript 比较键的值，相等时删除
+// KEYS[1] 键；ARGV[1] 期望的值；返回删除的键数量
+var delIfEqualScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// DelIfEqual 使用Lua脚本原子地比较并删除键
+func (c *RedisCache) DelIfEqual(ctx context.Context, key, value string) (bool, error) {
+	n, err := delIfEqualScript.Run(ctx, c.client, []string{key}, value).Int64()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// TTL 获取键的剩余过期时间
+func (c *RedisCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := c.client.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// Redis对不存在的键返回-2
+	if ttl == -2 {
+		return 0, ErrCacheMiss
+	}
+	return ttl, nil
+}
+
+// IncrBy 原子递增计数器
+func (c *RedisCache) IncrBy(ctx context.Context, key string, n int64) (int64, error) {
+	return c.client.IncrBy(ctx, key, n).Result()
+}
+
+// DecrBy 原子递减计数器
+func (c *RedisCache) DecrBy(ctx context.Context, key string, n int64) (int64, error) {
+	return c.client.DecrBy(ctx, key, n).Result()
+}
+
+// DecrByAll 使用MULTI/EXEC事务递减多个计数器并删除指定的键
+func (c *RedisCache) DecrByAll(ctx context.Context, deltas map[string]int64, del ...string) error {
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, n := range deltas {
+			pipe.DecrBy(ctx, key, n)
+		}
+		if len(del) > 0 {
+			pipe.Del(ctx, del...)
+		}
+		return nil
+	})
+	return err
+}
+
+// SAdd 向集合添加成员
+func (c *RedisCache) SAdd(ctx context.Context, key string, members ...string) error {
+	args := make([]interface{}, len(members))
+	for i, m := range members {
+		args[i] = m
+	}
+	return c.client.SAdd(ctx, key, args...).Err()
+}
+
+// SRem 从集合移除成员
+func (c *RedisCache) SRem(ctx context.Context, key string, members ...string) error {
+	args := make([]interface{}, len(members))
+	for i, m := range members {
+		args[i] = m
+	}
+	return c.client.SRem(ctx, key, args...).Err()
+}
+
+// SMembers 获取集合的所有成员
+func (c *RedisCache) SMembers(ctx context.Context, key string) ([]string, error) {
+	return c.client.SMembers(ctx, key).Result()
+}
+
+// SCard 获取集合的成员数量
+func (c *RedisCache) SCard(ctx context.Context, key string) (int64, error) {
+	return c.client.SCard(ctx, key).Result()
+}
+
+// DelPrefix 使用SCAN遍历并删除所有指定前缀的键
+func (c *RedisCache) DelPrefix(ctx context.Context, prefix string) error {
+	iter := c.client.Scan(ctx, 0, prefix+"*", 500).Iterator()
+	batch := make([]string, 0, 500)
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == cap(batch) {
+			if err := c.client.Del(ctx, batch...).Err(); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		return c.client.Del(ctx, batch...).Err()
+	}
+	return nil
+}
+
+// Ping 检查Redis连接
+func (c *RedisCache) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
+// Close 关闭Redis连接
+func (c *RedisCache) Close() error {
+	return c.client.Close()
+}
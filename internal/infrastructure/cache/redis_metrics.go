@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"errors"
+
+	"linkit/internal/infrastructure/metrics"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// metricsHook 统计Redis命令错误数的钩子
+type metricsHook struct{}
+
+// BeforeProcess 命令执行前调用
+func (metricsHook) BeforeProcess(ctx context.Context, _ redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+// AfterProcess 命令执行后统计错误
+func (metricsHook) AfterProcess(_ context.Context, cmd redis.Cmder) error {
+	observeRedisError(cmd)
+	return nil
+}
+
+// BeforeProcessPipeline 管道执行前调用
+func (metricsHook) BeforeProcessPipeline(ctx context.Context, _ []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+// AfterProcessPipeline 管道执行后统计错误
+func (metricsHook) AfterProcessPipeline(_ context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		observeRedisError(cmd)
+	}
+	return nil
+}
+
+// observeRedisError 记录命令错误，键不存在不计为错误
+func observeRedisError(cmd redis.Cmder) {
+	if err := cmd.Err(); err != nil && !errors.Is(err, redis.Nil) {
+		metrics.RedisErrors.WithLabelValues(cmd.Name()).Inc()
+	}
+}
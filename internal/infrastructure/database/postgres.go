@@ -6,22 +6,16 @@ import (
 	"os"
 	"time"
 
-	"github.com/spf13/viper"
+	"linkit/internal/config"
+
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
 // NewPostgresDB 创建PostgreSQL数据库连接
-func NewPostgresDB() (*gorm.DB, error) {
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		viper.GetString("database.host"),
-		viper.GetInt("database.port"),
-		viper.GetString("database.user"),
-		viper.GetString("database.password"),
-		viper.GetString("database.dbname"),
-		viper.GetString("database.sslmode"),
-	)
+func NewPostgresDB(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	dsn := cfg.DSN()
 
 	// 配置GORM日志
 	newLogger := logger.New(
@@ -49,8 +43,9 @@ func NewPostgresDB() (*gorm.DB, error) {
 	}
 
 	// 设置最大连接数
-	sqlDB.SetMaxOpenConns(25)
-	sqlDB.SetMaxIdleConns(10)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
 	return db, nil
 }
@@ -0,0 +1,66 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// hook 表示一个关闭钩子
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Manager 管理组件的生命周期
+// 组件按启动顺序注册关闭钩子，关闭时按相反顺序执行，
+// 保证上游组件(如HTTP服务)先停止，下游依赖(如数据库)最后关闭
+type Manager struct {
+	mu     sync.Mutex
+	hooks  []hook
+	logger *zap.SugaredLogger
+	closed bool
+}
+
+// NewManager 创建生命周期管理器
+func NewManager(logger *zap.SugaredLogger) *Manager {
+	return &Manager{
+		logger: logger,
+	}
+}
+
+// OnShutdown 注册关闭钩子
+func (m *Manager) OnShutdown(name string, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hooks = append(m.hooks, hook{name: name, fn: fn})
+}
+
+// Shutdown 按注册的相反顺序执行所有关闭钩子
+// 某个钩子失败不会阻止后续钩子执行，所有错误会被合并返回
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	hooks := m.hooks
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		m.logger.Infof("Shutting down %s", h.name)
+		if err := h.fn(ctx); err != nil {
+			m.logger.Errorf("Failed to shut down %s: %v", h.name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
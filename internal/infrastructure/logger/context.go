@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// requestIDKey 请求ID在context中的键
+type requestIDKey struct{}
+
+// WithRequestID 将请求ID写入context
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext 从context中获取请求ID，不存在时返回空字符串
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// FromContext 返回带有请求ID字段的日志实例，用于跨层关联同一请求的日志
+func FromContext(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		return logger.With(zap.String("request_id", requestID))
+	}
+	return logger
+}
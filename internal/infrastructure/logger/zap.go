@@ -1,23 +1,35 @@
 package logger
 
 import (
-	"github.com/spf13/viper"
+	"fmt"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+
+	"linkit/internal/config"
 )
 
-// NewLogger 创建Zap日志实例
-func NewLogger() (*zap.Logger, error) {
+// NewLogger 创建Zap日志实例，mode 为gin运行模式
+func NewLogger(mode string, cfg config.LogConfig) (*zap.Logger, error) {
 	var config zap.Config
 
 	// 根据配置选择开发或生产环境的日志配置
-	if viper.GetString("server.mode") == "debug" {
+	if mode == "debug" {
 		config = zap.NewDevelopmentConfig()
 		config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	} else {
 		config = zap.NewProductionConfig()
 	}
 
+	// 日志级别，未配置时使用默认级别(debug模式为debug，否则为info)
+	if level := cfg.Level; level != "" {
+		lvl, err := zap.ParseAtomicLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+		}
+		config.Level = lvl
+	}
+
 	// 创建日志实例
 	logger, err := config.Build()
 	if err != nil {
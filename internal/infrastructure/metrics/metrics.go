@@ -0,0 +1,177 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"linkit/internal/domain"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// namespace 指标名称前缀
+const namespace = "linkit"
+
+// 跳转结果
+const (
+	OutcomeRedirected       = "redirected"
+	OutcomeNotFound         = "not_found"
+	OutcomeExpired          = "expired"
+	OutcomeMaxVisits        = "max_visits"
+	OutcomePasswordRequired = "password_required"
+	OutcomeInvalidPath      = "invalid_path"
+	OutcomeError            = "error"
+)
+
+// 缓存查询类型
+const (
+	CacheGetByCode = "get_by_code"
+	CacheGetRules  = "get_rules"
+)
+
+var (
+	// HTTPRequests HTTP请求数
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Total number of HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPDuration HTTP请求耗时
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// RedirectDuration 跳转耗时，按跳转结果区分
+	RedirectDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redirect_duration_seconds",
+		Help:      "Redirect latency by outcome.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"outcome"})
+
+	// CacheRequests 缓存查询次数，按查询类型和命中结果区分
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups by operation and result (hit or miss).",
+	}, []string{"op", "result"})
+
+	// RuleMatches 跳转规则匹配次数，matched表示命中规则，default表示使用默认跳转
+	RuleMatches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rule_matches_total",
+		Help:      "Redirects by rule match result (matched or default).",
+	}, []string{"result"})
+
+	// RateLimited 被限流拒绝的请求数，按限额区分
+	RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected by rate limiting by budget.",
+	}, []string{"budget"})
+
+	// QuotaExceeded 因配额用尽被拒绝的操作数，按配额区分
+	// monthly_clicks 表示点击仍然跳转但不再记录访问日志
+	QuotaExceeded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quota_exceeded_total",
+		Help:      "Operations rejected or left untracked because a usage quota is exhausted, by quota.",
+	}, []string{"quota"})
+
+	// RedisErrors Redis命令错误数，不包括键不存在
+	RedisErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redis_errors_total",
+		Help:      "Total number of failed Redis commands by command name.",
+	}, []string{"command"})
+)
+
+// ObserveCache 记录一次缓存查询结果
+func ObserveCache(op string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	CacheRequests.WithLabelValues(op, result).Inc()
+}
+
+// RegisterDB 注册数据库连接池指标
+func RegisterDB(db *sql.DB, name string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterClickLogWriter 注册点击日志队列指标
+func RegisterClickLogWriter(stats func() domain.ClickLogWriterStats) error {
+	return registerAll(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "click_log_queue_depth",
+			Help:      "Number of click logs waiting in the write queue.",
+		}, func() float64 { return float64(stats().QueueDepth) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "click_log_queue_capacity",
+			Help:      "Capacity of the click log write queue.",
+		}, func() float64 { return float64(stats().QueueCapacity) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "click_logs_written_total",
+			Help:      "Total number of click logs written to the database.",
+		}, func() float64 { return float64(stats().Written) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "click_logs_dropped_total",
+			Help:      "Total number of click logs dropped because the queue was full.",
+		}, func() float64 { return float64(stats().Dropped) }),
+	)
+}
+
+// RegisterClickCounter 注册点击数同步指标
+func RegisterClickCounter(stats func(ctx context.Context) domain.ClickCounterStats) error {
+	// 采集时查询一次缓存，限制耗时避免拖慢抓取
+	snapshot := func() domain.ClickCounterStats {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		return stats(ctx)
+	}
+	return registerAll(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "click_counter_backlog",
+			Help:      "Number of short links with clicks not yet synced to the database.",
+		}, func() float64 { return float64(snapshot().Backlog) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "click_counter_sync_lag_seconds",
+			Help:      "Seconds since the last click counter sync attempt.",
+		}, func() float64 {
+			last := snapshot().LastFlushAt
+			if last.IsZero() {
+				return 0
+			}
+			return time.Since(last).Seconds()
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "click_counter_sync_errors_total",
+			Help:      "Total number of failed click counter syncs.",
+		}, func() float64 { return float64(snapshot().FlushErrors) }),
+	)
+}
+
+// registerAll 注册多个指标，遇到错误时停止
+func registerAll(cs ...prometheus.Collector) error {
+	for _, c := range cs {
+		if err := prometheus.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
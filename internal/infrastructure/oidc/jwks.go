@@ -0,0 +1,225 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// minRefreshInterval 遇到未知kid时重新拉取JWKS的最小间隔，防止伪造kid触发大量请求
+	minRefreshInterval = 30 * time.Second
+	// maxJWKSSize JWKS文档的最大字节数
+	maxJWKSSize = 1 << 20
+)
+
+// jwk JSON Web Key，仅支持RSA和P-256/P-384 EC公钥
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey 将JWK转换为公钥
+func (k *jwk) publicKey() (crypto.PublicKey, error) {
+	decode := func(s string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid exponent")
+		}
+		if n.BitLen() < 2048 {
+			return nil, fmt.Errorf("rsa key must be at least 2048 bits, got %d", n.BitLen())
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve %s", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// ParseJWKS 解析JWKS文档，跳过不支持或用途不是签名的密钥
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	for i := range doc.Keys {
+		k := &doc.Keys[i]
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("jwks contains no signing keys")
+	}
+	return keys, nil
+}
+
+// KeySet 缓存从文件或URL加载的JWKS，过期后在下次使用时重新加载
+type KeySet struct {
+	source   string
+	load     func(ctx context.Context) ([]byte, error)
+	interval time.Duration
+	logger   *zap.Logger
+
+	mu       sync.Mutex
+	keys     map[string]crypto.PublicKey
+	loadedAt time.Time
+}
+
+// NewFileKeySet 创建从本地文件加载的JWKS
+func NewFileKeySet(path string, interval time.Duration, logger *zap.Logger) *KeySet {
+	return &KeySet{
+		source:   path,
+		interval: interval,
+		logger:   logger,
+		load: func(ctx context.Context) ([]byte, error) {
+			return os.ReadFile(path)
+		},
+	}
+}
+
+// NewURLKeySet 创建从URL拉取的JWKS
+func NewURLKeySet(url string, interval time.Duration, logger *zap.Logger) *KeySet {
+	client := &http.Client{Timeout: 10 * time.Second}
+	return &KeySet{
+		source:   url,
+		interval: interval,
+		logger:   logger,
+		load: func(ctx context.Context) ([]byte, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("Accept", "application/json")
+			resp, err := client.Do(req)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+			}
+			return io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+		},
+	}
+}
+
+// refresh 重新加载JWKS，调用方需持有锁
+func (s *KeySet) refresh(ctx context.Context) error {
+	data, err := s.load(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load jwks from %s: %w", s.source, err)
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("failed to load jwks from %s: %w", s.source, err)
+	}
+	s.keys = keys
+	s.loadedAt = time.Now()
+	s.logger.Debug("jwks loaded", zap.String("source", s.source), zap.Int("keys", len(keys)))
+	return nil
+}
+
+// Load 立即加载JWKS，用于启动时检查配置
+func (s *KeySet) Load(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refresh(ctx)
+}
+
+// Key 根据kid获取公钥
+// 缓存过期或kid未知时重新加载JWKS，加载失败时继续使用旧的密钥
+// 令牌未指定kid且JWKS中只有一个密钥时使用该密钥
+func (s *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	age := time.Since(s.loadedAt)
+	_, known := s.keys[kid]
+	if s.keys == nil || age >= s.interval || (!known && age >= minRefreshInterval) {
+		if err := s.refresh(ctx); err != nil {
+			if s.keys == nil {
+				return nil, err
+			}
+			s.logger.Warn("failed to refresh jwks, using cached keys", zap.Error(err))
+			// 间隔 minRefreshInterval 后再重试，避免每个请求都重新加载
+			s.loadedAt = time.Now().Add(minRefreshInterval - s.interval)
+		}
+	}
+
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, nil
+		}
+	}
+	return nil, errUnknownKey
+}
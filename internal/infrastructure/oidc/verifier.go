@@ -0,0 +1,181 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"linkit/internal/config"
+	"linkit/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// errUnknownKey JWKS中没有令牌指定的kid
+var errUnknownKey = errors.New("unknown signing key")
+
+// signingMethods 允许的签名算法
+var signingMethods = []string{"RS256", "ES256"}
+
+// Verifier 校验SSO签发的JWT，并将声明映射为Linkit用户和工作空间
+type Verifier struct {
+	cfg     config.JWTConfig
+	keys    *KeySet
+	parser  *jwt.Parser
+	userMap map[string]uint
+	logger  *zap.Logger
+}
+
+// NewVerifier 创建JWT校验器，JWKS从 jwks_file 或 jwks_url 加载
+func NewVerifier(cfg config.JWTConfig, logger *zap.Logger) *Verifier {
+	logger = logger.Named("jwt")
+
+	var keys *KeySet
+	if cfg.JWKSFile != "" {
+		keys = NewFileKeySet(cfg.JWKSFile, cfg.RefreshInterval, logger)
+	} else {
+		keys = NewURLKeySet(cfg.JWKSURL, cfg.RefreshInterval, logger)
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	userMap := make(map[string]uint, len(cfg.UserMap))
+	for _, m := range cfg.UserMap {
+		userMap[m.Subject] = m.UserID
+	}
+
+	return &Verifier{
+		cfg:     cfg,
+		keys:    keys,
+		parser:  jwt.NewParser(opts...),
+		userMap: userMap,
+		logger:  logger,
+	}
+}
+
+// Load 加载JWKS，启动时调用以尽早发现配置错误
+func (v *Verifier) Load(ctx context.Context) error {
+	return v.keys.Load(ctx)
+}
+
+// Authenticate 校验JWT并返回调用者
+// 签名、过期时间、签发者或受众不正确时返回 ErrUnauthorized，JWKS无法加载时返回其他错误
+func (v *Verifier) Authenticate(ctx context.Context, token string) (*domain.Principal, error) {
+	var loadErr error
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := v.keys.Key(ctx, kid)
+		if err != nil && !errors.Is(err, errUnknownKey) {
+			loadErr = err
+		}
+		return key, err
+	})
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	if err != nil {
+		v.logger.Debug("invalid jwt", zap.Error(err))
+		return nil, domain.ErrUnauthorized
+	}
+
+	return v.principal(claims)
+}
+
+// principal 将JWT声明映射为调用者
+func (v *Verifier) principal(claims jwt.MapClaims) (*domain.Principal, error) {
+	userID, ok := v.userID(claims[v.cfg.UserClaim])
+	if !ok || userID == 0 {
+		v.logger.Debug("jwt user claim is not numeric and has no user_map entry", zap.String("claim", v.cfg.UserClaim))
+		return nil, domain.ErrUnauthorized
+	}
+
+	p := &domain.Principal{UserID: userID}
+
+	// 令牌指定的工作空间由工作空间中间件校验成员身份
+	if v.cfg.WorkspaceClaim != "" {
+		if raw, exists := claims[v.cfg.WorkspaceClaim]; exists {
+			workspaceID, ok := uintClaim(raw)
+			if !ok {
+				v.logger.Debug("jwt has invalid workspace claim", zap.String("claim", v.cfg.WorkspaceClaim))
+				return nil, domain.ErrUnauthorized
+			}
+			p.WorkspaceID = workspaceID
+		}
+	}
+
+	raw, exists := claims[v.cfg.ScopeClaim]
+	if !exists {
+		for _, s := range v.cfg.DefaultScopes {
+			p.Scopes = append(p.Scopes, domain.Scope(s))
+		}
+		return p, nil
+	}
+	// 忽略不属于Linkit的权限范围
+	for _, s := range stringsClaim(raw) {
+		if scope := domain.Scope(s); domain.ValidScope(scope) {
+			p.Scopes = append(p.Scopes, scope)
+		}
+	}
+	return p, nil
+}
+
+// userID 解析用户声明，优先使用 user_map 中配置的映射
+func (v *Verifier) userID(claim interface{}) (uint, bool) {
+	if s, ok := claim.(string); ok {
+		if id, ok := v.userMap[s]; ok {
+			return id, true
+		}
+	}
+	return uintClaim(claim)
+}
+
+// uintClaim 解析数字或数字字符串形式的ID声明
+func uintClaim(v interface{}) (uint, bool) {
+	switch n := v.(type) {
+	case float64:
+		if n < 0 || n != float64(uint32(n)) {
+			return 0, false
+		}
+		return uint(n), true
+	case string:
+		id, err := strconv.ParseUint(n, 10, 32)
+		if err != nil {
+			return 0, false
+		}
+		return uint(id), true
+	default:
+		return 0, false
+	}
+}
+
+// stringsClaim 解析以空格分隔的字符串或字符串数组形式的声明
+func stringsClaim(v interface{}) []string {
+	switch s := v.(type) {
+	case string:
+		return strings.Fields(s)
+	case []interface{}:
+		values := make([]string, 0, len(s))
+		for _, item := range s {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,238 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"linkit/internal/config"
+	"linkit/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	testIssuer   = "https://sso.example.com"
+	testAudience = "linkit"
+)
+
+// rsaJWK 将RSA公钥编码为JWK
+func rsaJWK(kid string, pub *rsa.PublicKey) jwk {
+	return jwk{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		Alg: "RS256",
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+// ecJWK 将P-256公钥编码为JWK
+func ecJWK(kid string, pub *ecdsa.PublicKey) jwk {
+	coord := func(n *big.Int) string {
+		b := make([]byte, 32)
+		return base64.RawURLEncoding.EncodeToString(n.FillBytes(b))
+	}
+	return jwk{Kty: "EC", Kid: kid, Use: "sig", Alg: "ES256", Crv: "P-256", X: coord(pub.X), Y: coord(pub.Y)}
+}
+
+// writeJWKS 将密钥写入临时JWKS文件并返回路径
+func writeJWKS(t *testing.T, keys ...jwk) string {
+	t.Helper()
+	data, err := json.Marshal(map[string][]jwk{"keys": keys})
+	if err != nil {
+		t.Fatalf("failed to marshal jwks: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("failed to write jwks: %v", err)
+	}
+	return path
+}
+
+// testConfig 返回使用指定JWKS文件的配置
+func testConfig(jwksFile string) config.JWTConfig {
+	return config.JWTConfig{
+		Enabled:         true,
+		JWKSFile:        jwksFile,
+		RefreshInterval: time.Hour,
+		Issuer:          testIssuer,
+		Audience:        testAudience,
+		UserClaim:       "sub",
+		WorkspaceClaim:  "workspace_id",
+		ScopeClaim:      "scope",
+		DefaultScopes:   []string{"read"},
+		UserMap:         []config.JWTUserMap{{Subject: "auth0|alice", UserID: 7}},
+	}
+}
+
+// validClaims 返回可以通过校验的声明
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"sub":   "42",
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"scope": "read write",
+	}
+}
+
+// sign 使用指定算法和kid签名令牌
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func TestVerifierAuthenticate(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate rsa key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ec key: %v", err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate ec key: %v", err)
+	}
+
+	v := NewVerifier(testConfig(writeJWKS(t, rsaJWK("rsa", &rsaKey.PublicKey), ecJWK("ec", &ecKey.PublicKey))), zap.NewNop())
+	if err := v.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	with := func(changes jwt.MapClaims) jwt.MapClaims {
+		claims := validClaims()
+		for k, val := range changes {
+			if val == nil {
+				delete(claims, k)
+			} else {
+				claims[k] = val
+			}
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name      string
+		token     string
+		wantUser  uint
+		wantScope []domain.Scope
+	}{
+		{
+			name:      "RS256",
+			token:     sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, validClaims()),
+			wantUser:  42,
+			wantScope: []domain.Scope{domain.ScopeRead, domain.ScopeWrite},
+		},
+		{
+			name:      "ES256",
+			token:     sign(t, jwt.SigningMethodES256, "ec", ecKey, validClaims()),
+			wantUser:  42,
+			wantScope: []domain.Scope{domain.ScopeRead, domain.ScopeWrite},
+		},
+		{
+			name:      "mapped subject and default scopes",
+			token:     sign(t, jwt.SigningMethodES256, "ec", ecKey, with(jwt.MapClaims{"sub": "auth0|alice", "scope": nil})),
+			wantUser:  7,
+			wantScope: []domain.Scope{domain.ScopeRead},
+		},
+		{
+			name:  "expired",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, with(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})),
+		},
+		{
+			name:  "missing exp",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, with(jwt.MapClaims{"exp": nil})),
+		},
+		{
+			name:  "wrong issuer",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, with(jwt.MapClaims{"iss": "https://evil.example.com"})),
+		},
+		{
+			name:  "wrong audience",
+			token: sign(t, jwt.SigningMethodES256, "ec", ecKey, with(jwt.MapClaims{"aud": "other"})),
+		},
+		{
+			name:  "unknown kid",
+			token: sign(t, jwt.SigningMethodES256, "missing", ecKey, validClaims()),
+		},
+		{
+			name:  "signed by another key",
+			token: sign(t, jwt.SigningMethodES256, "ec", otherKey, validClaims()),
+		},
+		{
+			name:  "unsupported algorithm",
+			token: sign(t, jwt.SigningMethodHS256, "rsa", []byte("secret"), validClaims()),
+		},
+		{
+			name:  "unmapped non-numeric subject",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, with(jwt.MapClaims{"sub": "auth0|bob"})),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := v.Authenticate(context.Background(), tt.token)
+			if tt.wantUser == 0 {
+				if !errors.Is(err, domain.ErrUnauthorized) {
+					t.Fatalf("Authenticate() error = %v, want ErrUnauthorized", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if p.UserID != tt.wantUser {
+				t.Errorf("UserID = %d, want %d", p.UserID, tt.wantUser)
+			}
+			if len(p.Scopes) != len(tt.wantScope) {
+				t.Fatalf("Scopes = %v, want %v", p.Scopes, tt.wantScope)
+			}
+			for i := range p.Scopes {
+				if p.Scopes[i] != tt.wantScope[i] {
+					t.Errorf("Scopes = %v, want %v", p.Scopes, tt.wantScope)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifierRejectsWeakRSAKey(t *testing.T) {
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("failed to generate rsa key: %v", err)
+	}
+	v := NewVerifier(testConfig(writeJWKS(t, rsaJWK("weak", &weak.PublicKey))), zap.NewNop())
+
+	if err := v.Load(context.Background()); err == nil {
+		t.Fatal("Load() error = nil, want error for 1024-bit key")
+	}
+
+	// JWKS无法加载时返回加载错误而不是 ErrUnauthorized
+	_, err = v.Authenticate(context.Background(), sign(t, jwt.SigningMethodRS256, "weak", weak, validClaims()))
+	if err == nil || errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("Authenticate() error = %v, want jwks load error", err)
+	}
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"linkit/internal/domain"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// apiKeyRecord API Key的数据库记录，使用 pq.StringArray 读写权限数组
+type apiKeyRecord struct {
+	ID         uint           `gorm:"column:id;primaryKey"`
+	Name       string         `gorm:"column:name"`
+	Prefix     string         `gorm:"column:prefix"`
+	KeyHash    string         `gorm:"column:key_hash"`
+	UserID     uint           `gorm:"column:user_id"`
+	Scopes     pq.StringArray `gorm:"column:scopes;type:text[]"`
+	ExpiresAt  *time.Time     `gorm:"column:expires_at"`
+	LastUsedAt *time.Time     `gorm:"column:last_used_at"`
+	RevokedAt  *time.Time     `gorm:"column:revoked_at"`
+	CreatedAt  time.Time      `gorm:"column:created_at;autoCreateTime"`
+}
+
+// TableName 指定表名
+func (apiKeyRecord) TableName() string {
+	return "api_keys"
+}
+
+// toDomain 转换为domain.APIKey
+func (r *apiKeyRecord) toDomain() *domain.APIKey {
+	scopes := make([]domain.Scope, len(r.Scopes))
+	for i, s := range r.Scopes {
+		scopes[i] = domain.Scope(s)
+	}
+	return &domain.APIKey{
+		ID:         r.ID,
+		Name:       r.Name,
+		Prefix:     r.Prefix,
+		KeyHash:    r.KeyHash,
+		UserID:     r.UserID,
+		Scopes:     scopes,
+		ExpiresAt:  r.ExpiresAt,
+		LastUsedAt: r.LastUsedAt,
+		RevokedAt:  r.RevokedAt,
+		CreatedAt:  r.CreatedAt,
+	}
+}
+
+// APIKeyRepository 实现API Key仓储接口
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository 创建API Key仓储实例
+func NewAPIKeyRepository(db *gorm.DB) domain.APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// Create 创建API Key
+func (r *APIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	scopes := make(pq.StringArray, len(key.Scopes))
+	for i, s := range key.Scopes {
+		scopes[i] = string(s)
+	}
+	record := &apiKeyRecord{
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		KeyHash:   key.KeyHash,
+		UserID:    key.UserID,
+		Scopes:    scopes,
+		ExpiresAt: key.ExpiresAt,
+	}
+	if err := r.db.WithContext(ctx).Create(record).Error; err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	key.ID = record.ID
+	key.CreatedAt = record.CreatedAt
+	return nil
+}
+
+// get 按条件查询单个API Key
+func (r *APIKeyRepository) get(ctx context.Context, query string, args ...interface{}) (*domain.APIKey, error) {
+	var record apiKeyRecord
+	if err := r.db.WithContext(ctx).Where(query, args...).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return record.toDomain(), nil
+}
+
+// GetByID 根据ID获取API Key
+func (r *APIKeyRepository) GetByID(ctx context.Context, id uint) (*domain.APIKey, error) {
+	return r.get(ctx, "id = ?", id)
+}
+
+// GetByHash 根据密钥哈希获取API Key
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	return r.get(ctx, "key_hash = ?", hash)
+}
+
+// List 获取API Key列表，按创建时间倒序
+func (r *APIKeyRepository) List(ctx context.Context, userID uint) ([]domain.APIKey, error) {
+	db := r.db.WithContext(ctx).Order("created_at DESC")
+	if userID != 0 {
+		db = db.Where("user_id = ?", userID)
+	}
+
+	var records []apiKeyRecord
+	if err := db.Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	keys := make([]domain.APIKey, len(records))
+	for i := range records {
+		keys[i] = *records[i].toDomain()
+	}
+	return keys, nil
+}
+
+// Revoke 吊销API Key，已吊销的API Key保留原吊销时间
+func (r *APIKeyRepository) Revoke(ctx context.Context, id uint, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&apiKeyRecord{}).
+		Where("id = ?", id).
+		Update("revoked_at", gorm.Expr("COALESCE(revoked_at, ?)", at))
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke api key: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed 更新最近使用时间
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id uint, at time.Time) error {
+	if err := r.db.WithContext(ctx).Model(&apiKeyRecord{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error; err != nil {
+		return fmt.Errorf("failed to update api key last used time: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/cache"
+)
+
+// AttemptLimiter 基于缓存滑动窗口的尝试次数限制，使用Redis时多个实例共享计数
+type AttemptLimiter struct {
+	cache  cache.Cache
+	prefix string
+	limit  int64
+	window time.Duration
+}
+
+// NewAttemptLimiter 创建尝试次数限制器，window 内同一键最多尝试 limit 次，limit 为0表示不限制
+func NewAttemptLimiter(cache cache.Cache, prefix string, limit int, window time.Duration) domain.AttemptLimiter {
+	return &AttemptLimiter{
+		cache:  cache,
+		prefix: prefix,
+		limit:  int64(limit),
+		window: window,
+	}
+}
+
+// Attempt 记录一次尝试，超出次数时返回false和需要等待的时间
+func (l *AttemptLimiter) Attempt(ctx context.Context, key string) (bool, time.Duration, error) {
+	if l.limit <= 0 {
+		return true, 0, nil
+	}
+	result, err := l.cache.SlidingWindow(ctx, l.prefix+":"+key, l.limit, l.window)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to count attempts: %w", err)
+	}
+	return result.Allowed, result.RetryAfter, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"linkit/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// AuditRepository 实现审计日志仓储接口
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository 创建审计日志仓储实例
+func NewAuditRepository(db *gorm.DB) domain.AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Create 追加审计日志
+func (r *AuditRepository) Create(ctx context.Context, entry *domain.AuditLog) error {
+	if err := r.db.WithContext(ctx).Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	return nil
+}
+
+// List 分页查询审计日志，按时间倒序排列
+func (r *AuditRepository) List(ctx context.Context, query *domain.AuditQuery) (*domain.PaginatedAuditLogs, error) {
+	var total int64
+	var entries []domain.AuditLog
+
+	db := r.db.WithContext(ctx).Model(&domain.AuditLog{})
+	if f := query.Filter; f != nil {
+		if f.ShortCode != nil {
+			db = db.Where("short_code = ?", *f.ShortCode)
+		}
+		if f.ActorID != nil {
+			db = db.Where("actor_id = ?", *f.ActorID)
+		}
+		if f.Action != nil {
+			db = db.Where("action = ?", *f.Action)
+		}
+		if f.StartTime != nil {
+			db = db.Where("created_at >= ?", *f.StartTime)
+		}
+		if f.EndTime != nil {
+			db = db.Where("created_at <= ?", *f.EndTime)
+		}
+		if f.WorkspaceID != nil {
+			db = db.Where("workspace_id = ?", *f.WorkspaceID)
+		}
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+
+	offset := (query.Page - 1) * query.PageSize
+	if err := db.Order("created_at DESC, id DESC").Offset(offset).Limit(query.PageSize).Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+
+	return &domain.PaginatedAuditLogs{
+		Total:       total,
+		TotalPages:  (int(total) + query.PageSize - 1) / query.PageSize,
+		CurrentPage: query.Page,
+		PageSize:    query.PageSize,
+		Data:        entries,
+	}, nil
+}
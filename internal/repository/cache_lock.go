@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"linkit/internal/infrastructure/cache"
+)
+
+// cacheLock 基于缓存 SetNX 的分布式锁
+// 锁的值为随机令牌，释放时只删除值仍为该令牌的键，避免锁过期后被其他进程获取时误删
+type cacheLock struct {
+	cache cache.Cache
+	key   string
+	token string
+}
+
+// acquireCacheLock 尝试获取锁，锁已被占用时返回 nil
+func acquireCacheLock(ctx context.Context, c cache.Cache, key string, ttl time.Duration) (*cacheLock, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	lock := &cacheLock{cache: c, key: key, token: hex.EncodeToString(b)}
+
+	ok, err := c.SetNX(ctx, key, lock.token, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+	return lock, nil
+}
+
+// release 释放锁，锁已过期并被其他进程持有时不做任何操作
+func (l *cacheLock) release(ctx context.Context) error {
+	_, err := l.cache.DelIfEqual(ctx, l.key, l.token)
+	return err
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"linkit/internal/infrastructure/cache"
+)
+
+func TestCacheLockReleaseKeepsOtherOwner(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemoryCache(0)
+
+	lock, err := acquireCacheLock(ctx, c, meteringLockKey, time.Minute)
+	if err != nil || lock == nil {
+		t.Fatalf("acquireCacheLock() = %v, %v", lock, err)
+	}
+	if other, err := acquireCacheLock(ctx, c, meteringLockKey, time.Minute); err != nil || other != nil {
+		t.Fatalf("acquireCacheLock() while held = %v, %v; want nil, nil", other, err)
+	}
+
+	// 锁过期后被其他进程获取
+	if err := c.Del(ctx, meteringLockKey); err != nil {
+		t.Fatalf("Del() error = %v", err)
+	}
+	other, err := acquireCacheLock(ctx, c, meteringLockKey, time.Minute)
+	if err != nil || other == nil {
+		t.Fatalf("acquireCacheLock() after expiry = %v, %v", other, err)
+	}
+
+	if err := lock.release(ctx); err != nil {
+		t.Fatalf("release() error = %v", err)
+	}
+	if got, err := c.Get(ctx, meteringLockKey); err != nil || got != other.token {
+		t.Errorf("lock value after stale release = %q, %v; want %q", got, err, other.token)
+	}
+
+	if err := other.release(ctx); err != nil {
+		t.Fatalf("release() error = %v", err)
+	}
+	if _, err := c.Get(ctx, meteringLockKey); err != cache.ErrCacheMiss {
+		t.Errorf("Get() after release error = %v, want ErrCacheMiss", err)
+	}
+}
@@ -0,0 +1,395 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/cache"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// clickDirtyKey 存放有未同步点击数的短码集合
+	clickDirtyKey = "clicks_dirty"
+	// clickFlushPendingKey 存放正在同步的批次，用于崩溃后恢复
+	clickFlushPendingKey = "clicks_flush:pending"
+	// clickFlushLockKey 同步锁，保证同一时刻只有一个进程在同步
+	clickFlushLockKey = "clicks_flush:lock"
+
+	defaultClickFlushInterval  = 10 * time.Second
+	defaultClickFlushBatchSize = 500
+	// clickFlushRetention 已完成批次记录的保留时间
+	clickFlushRetention = 24 * time.Hour
+)
+
+// ClickCountFlush 记录已应用到数据库的点击数同步批次
+// 与 short_links 的更新在同一事务中写入，用于判断崩溃前的批次是否已生效，保证计数精确
+type ClickCountFlush struct {
+	BatchID   string    `gorm:"column:batch_id;primaryKey;size:32"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime;index"`
+}
+
+// TableName 指定表名
+func (ClickCountFlush) TableName() string {
+	return "click_count_flushes"
+}
+
+// clickFlushBatch 正在同步的批次
+type clickFlushBatch struct {
+	ID     string           `json:"id"`
+	Counts map[string]int64 `json:"counts"`
+}
+
+// ClickCounter 点击计数器
+// 点击数先累加到缓存计数器并将短码记入待同步集合，
+// 由每个进程唯一的后台协程按固定间隔批量执行 UPDATE short_links SET clicks = clicks + n
+type ClickCounter struct {
+	db        *gorm.DB
+	cache     cache.Cache
+	interval  time.Duration
+	batchSize int
+
+	stop     chan struct{}
+	done     chan struct{}
+	start    sync.Once
+	shutdown sync.Once
+	flushMu  sync.Mutex
+
+	lastFlushAt      atomic.Int64
+	lastFlushLatency atomic.Int64
+	lastFlushCodes   atomic.Int64
+	flushedClicks    atomic.Uint64
+	flushErrors      atomic.Uint64
+	lastFlushError   atomic.Value
+
+	logger *zap.Logger
+}
+
+// NewClickCounter 创建点击计数器
+func NewClickCounter(db *gorm.DB, cache cache.Cache, interval time.Duration, batchSize int, logger *zap.Logger) *ClickCounter {
+	if interval <= 0 {
+		interval = defaultClickFlushInterval
+	}
+	if batchSize <= 0 {
+		batchSize = defaultClickFlushBatchSize
+	}
+	return &ClickCounter{
+		db:        db,
+		cache:     cache,
+		interval:  interval,
+		batchSize: batchSize,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+		logger:    logger.Named("click_counter"),
+	}
+}
+
+// counterKey 获取短码的计数器键
+func counterKey(code string) string {
+	return fmt.Sprintf("clicks:%s", code)
+}
+
+// Start 启动后台同步协程
+func (c *ClickCounter) Start() {
+	c.start.Do(func() {
+		go c.run()
+	})
+}
+
+// run 按固定间隔同步点击数
+func (c *ClickCounter) run() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.Flush(context.Background()); err != nil {
+				c.logger.Error("failed to flush click counters", zap.Error(err))
+			}
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// Close 停止后台协程并执行最后一次同步
+func (c *ClickCounter) Close(ctx context.Context) error {
+	c.shutdown.Do(func() {
+		close(c.stop)
+	})
+
+	// 等待进行中的同步完成(未启动时直接跳过)
+	started := true
+	c.start.Do(func() {
+		started = false
+	})
+	if started {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return fmt.Errorf("failed to stop click counter: %w", ctx.Err())
+		}
+	}
+
+	return c.Flush(ctx)
+}
+
+// Incr 增加短码的点击数并标记为待同步
+func (c *ClickCounter) Incr(ctx context.Context, code string) (int64, error) {
+	n, err := c.cache.IncrBy(ctx, counterKey(code), 1)
+	if err != nil {
+		return 0, err
+	}
+	if err := c.cache.SAdd(ctx, clickDirtyKey, code); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Pending 获取短码尚未同步到数据库的点击数
+func (c *ClickCounter) Pending(ctx context.Context, code string) (int64, error) {
+	data, err := c.cache.Get(ctx, counterKey(code))
+	if err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return strconv.ParseInt(data, 10, 64)
+}
+
+// Stats 获取计数器运行状态
+func (c *ClickCounter) Stats(ctx context.Context) domain.ClickCounterStats {
+	stats := domain.ClickCounterStats{
+		LastFlushLatency: time.Duration(c.lastFlushLatency.Load()),
+		LastFlushCodes:   c.lastFlushCodes.Load(),
+		FlushedClicks:    c.flushedClicks.Load(),
+		FlushErrors:      c.flushErrors.Load(),
+	}
+	if ts := c.lastFlushAt.Load(); ts > 0 {
+		stats.LastFlushAt = time.Unix(0, ts)
+	}
+	if msg, ok := c.lastFlushError.Load().(string); ok {
+		stats.LastFlushError = msg
+	}
+	if backlog, err := c.cache.SCard(ctx, clickDirtyKey); err == nil {
+		stats.Backlog = backlog
+	}
+	return stats
+}
+
+// Flush 将所有待同步的点击数写入数据库
+func (c *ClickCounter) Flush(ctx context.Context) (err error) {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	started := time.Now()
+	var codes int64
+	defer func() {
+		c.lastFlushAt.Store(started.UnixNano())
+		c.lastFlushLatency.Store(int64(time.Since(started)))
+		c.lastFlushCodes.Store(codes)
+		if err != nil {
+			c.flushErrors.Add(1)
+			c.lastFlushError.Store(err.Error())
+		}
+	}()
+
+	// 获取同步锁，防止多个进程同时同步
+	lock, err := acquireCacheLock(ctx, c.cache, clickFlushLockKey, 4*c.interval+30*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to acquire flush lock: %w", err)
+	}
+	if lock == nil {
+		return nil
+	}
+	defer func() {
+		if err := lock.release(context.Background()); err != nil {
+			c.logger.Warn("failed to release flush lock", zap.Error(err))
+		}
+	}()
+
+	// 先恢复上一次未完成的批次
+	if err := c.recover(ctx); err != nil {
+		return err
+	}
+
+	dirty, err := c.cache.SMembers(ctx, clickDirtyKey)
+	if err != nil {
+		return fmt.Errorf("failed to get dirty codes: %w", err)
+	}
+
+	for start := 0; start < len(dirty); start += c.batchSize {
+		end := start + c.batchSize
+		if end > len(dirty) {
+			end = len(dirty)
+		}
+		n, err := c.flushBatch(ctx, dirty[start:end])
+		codes += n
+		if err != nil {
+			return err
+		}
+	}
+
+	// 清理过期的批次记录
+	if err := c.db.WithContext(ctx).
+		Where("created_at < ?", time.Now().Add(-clickFlushRetention)).
+		Delete(&ClickCountFlush{}).Error; err != nil {
+		return fmt.Errorf("failed to prune click count flushes: %w", err)
+	}
+
+	return nil
+}
+
+// flushBatch 同步一批短码的点击数，返回实际同步的短码数量
+func (c *ClickCounter) flushBatch(ctx context.Context, codes []string) (int64, error) {
+	batch := clickFlushBatch{Counts: make(map[string]int64, len(codes))}
+	var idle []string
+	for _, code := range codes {
+		n, err := c.Pending(ctx, code)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get click count for %s: %w", code, err)
+		}
+		if n > 0 {
+			batch.Counts[code] = n
+		} else {
+			idle = append(idle, code)
+		}
+	}
+
+	// 计数器已清零的短码直接移出待同步集合
+	if err := c.untrack(ctx, idle); err != nil {
+		return 0, err
+	}
+	if len(batch.Counts) == 0 {
+		return 0, nil
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return 0, fmt.Errorf("failed to generate batch id: %w", err)
+	}
+	batch.ID = hex.EncodeToString(id)
+
+	// 记录进行中的批次，崩溃后可据此判断数据库是否已生效
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal flush batch: %w", err)
+	}
+	if err := c.cache.Set(ctx, clickFlushPendingKey, string(data), 0); err != nil {
+		return 0, fmt.Errorf("failed to record flush batch: %w", err)
+	}
+
+	// 在同一事务中批量更新点击数并记录批次
+	values := make([]string, 0, len(batch.Counts))
+	args := make([]interface{}, 0, 2*len(batch.Counts))
+	for code, n := range batch.Counts {
+		values = append(values, "(?, ?::bigint)")
+		args = append(args, code, n)
+	}
+	sql := `
+		UPDATE short_links AS s SET clicks = s.clicks + v.n
+		FROM (VALUES ` + strings.Join(values, ", ") + `) AS v(code, n)
+		WHERE s.short_code = v.code`
+
+	err = c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(sql, args...).Error; err != nil {
+			return err
+		}
+		return tx.Create(&ClickCountFlush{BatchID: batch.ID}).Error
+	})
+	if err != nil {
+		// 保留进行中的批次，由下一次同步根据数据库中的批次记录判断是否已生效
+		return 0, fmt.Errorf("failed to sync clicks: %w", err)
+	}
+
+	if err := c.complete(ctx, &batch); err != nil {
+		return 0, err
+	}
+	return int64(len(batch.Counts)), nil
+}
+
+// complete 扣减已同步的计数器并清除进行中的批次
+func (c *ClickCounter) complete(ctx context.Context, batch *clickFlushBatch) error {
+	deltas := make(map[string]int64, len(batch.Counts))
+	var total uint64
+	for code, n := range batch.Counts {
+		deltas[counterKey(code)] = n
+		total += uint64(n)
+	}
+	if err := c.cache.DecrByAll(ctx, deltas, clickFlushPendingKey); err != nil {
+		return fmt.Errorf("failed to reset click counters: %w", err)
+	}
+	c.flushedClicks.Add(total)
+
+	// 计数器归零后移出待同步集合
+	var idle []string
+	for code := range batch.Counts {
+		if n, err := c.Pending(ctx, code); err == nil && n <= 0 {
+			idle = append(idle, code)
+		}
+	}
+	return c.untrack(ctx, idle)
+}
+
+// untrack 将计数器已清零的短码移出待同步集合
+// 移除后再次检查计数器，避免与并发的 Incr 竞争导致漏同步
+func (c *ClickCounter) untrack(ctx context.Context, codes []string) error {
+	if len(codes) == 0 {
+		return nil
+	}
+	if err := c.cache.SRem(ctx, clickDirtyKey, codes...); err != nil {
+		return fmt.Errorf("failed to untrack codes: %w", err)
+	}
+	for _, code := range codes {
+		if n, err := c.Pending(ctx, code); err != nil || n > 0 {
+			if err := c.cache.SAdd(ctx, clickDirtyKey, code); err != nil {
+				return fmt.Errorf("failed to track code %s: %w", code, err)
+			}
+		}
+	}
+	return nil
+}
+
+// recover 处理上一次同步中断时遗留的批次
+// 批次已写入数据库则补做计数器扣减，否则丢弃批次等待重新同步
+func (c *ClickCounter) recover(ctx context.Context) error {
+	data, err := c.cache.Get(ctx, clickFlushPendingKey)
+	if err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return nil
+		}
+		return fmt.Errorf("failed to get pending flush batch: %w", err)
+	}
+
+	var batch clickFlushBatch
+	if err := json.Unmarshal([]byte(data), &batch); err != nil {
+		c.logger.Warn("discarding malformed flush batch", zap.Error(err))
+		return c.cache.Del(ctx, clickFlushPendingKey)
+	}
+
+	var applied int64
+	if err := c.db.WithContext(ctx).Model(&ClickCountFlush{}).
+		Where("batch_id = ?", batch.ID).Count(&applied).Error; err != nil {
+		return fmt.Errorf("failed to check flush batch %s: %w", batch.ID, err)
+	}
+	if applied == 0 {
+		return c.cache.Del(ctx, clickFlushPendingKey)
+	}
+	return c.complete(ctx, &batch)
+}
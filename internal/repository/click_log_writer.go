@@ -0,0 +1,435 @@
+package repository
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"linkit/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OverflowPolicy 表示点击日志队列已满时的处理策略
+type OverflowPolicy string
+
+const (
+	// OverflowBlock 阻塞等待队列空位，超过等待时间后丢弃
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDrop 直接丢弃
+	OverflowDrop OverflowPolicy = "drop"
+	// OverflowSpill 写入磁盘溢出文件，空闲时回放到数据库
+	OverflowSpill OverflowPolicy = "spill"
+)
+
+const (
+	defaultClickLogQueueSize     = 10000
+	defaultClickLogBatchSize     = 500
+	defaultClickLogFlushInterval = time.Second
+	defaultClickLogBlockTimeout  = 100 * time.Millisecond
+	defaultClickLogSpillPath     = "data/click_logs.spill"
+)
+
+// ErrClickLogDropped 表示点击日志因队列已满被丢弃
+var ErrClickLogDropped = errors.New("click log dropped")
+
+// ClickLogWriterConfig 点击日志写入器配置
+type ClickLogWriterConfig struct {
+	QueueSize     int            // 队列容量
+	BatchSize     int            // 每批写入的最大条数
+	FlushInterval time.Duration  // 未达到批量大小时的最长等待时间
+	Overflow      OverflowPolicy // 队列已满时的处理策略
+	BlockTimeout  time.Duration  // block策略下的最长等待时间
+	SpillPath     string         // spill策略下的溢出文件路径
+}
+
+// ClickLogWriter 点击日志写入器
+// 点击日志进入有界队列，由单个后台协程按批量大小或时间间隔使用多行INSERT写入 click_logs
+// 被数据库拒绝的日志(如短链接已被删除)单独丢弃并计入失败数，不影响同批次的其他日志
+type ClickLogWriter struct {
+	db    *gorm.DB
+	cfg   ClickLogWriterConfig
+	queue chan *domain.ClickLog
+
+	stop     chan struct{}
+	done     chan struct{}
+	start    sync.Once
+	shutdown sync.Once
+
+	// mu 保护 closed，入队时持有读锁，保证 Close 之后不会再有日志进入已停止消费的队列
+	mu     sync.RWMutex
+	closed bool
+
+	spillMu sync.Mutex
+
+	enqueued         atomic.Uint64
+	written          atomic.Uint64
+	dropped          atomic.Uint64
+	spilled          atomic.Uint64
+	failed           atomic.Uint64
+	lastBatchLatency atomic.Int64
+
+	logger *zap.Logger
+}
+
+// NewClickLogWriter 创建点击日志写入器
+func NewClickLogWriter(db *gorm.DB, cfg ClickLogWriterConfig, logger *zap.Logger) (*ClickLogWriter, error) {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultClickLogQueueSize
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultClickLogBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultClickLogFlushInterval
+	}
+	if cfg.BlockTimeout <= 0 {
+		cfg.BlockTimeout = defaultClickLogBlockTimeout
+	}
+	switch cfg.Overflow {
+	case "":
+		cfg.Overflow = OverflowBlock
+	case OverflowBlock, OverflowDrop:
+	case OverflowSpill:
+		if cfg.SpillPath == "" {
+			cfg.SpillPath = defaultClickLogSpillPath
+		}
+		if err := os.MkdirAll(filepath.Dir(cfg.SpillPath), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create spill directory: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown click log overflow policy: %s", cfg.Overflow)
+	}
+
+	return &ClickLogWriter{
+		db:     db,
+		cfg:    cfg,
+		queue:  make(chan *domain.ClickLog, cfg.QueueSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		logger: logger.Named("click_log_writer"),
+	}, nil
+}
+
+// Start 启动后台写入协程
+func (w *ClickLogWriter) Start() {
+	w.start.Do(func() {
+		go w.run()
+	})
+}
+
+// Enqueue 将点击日志加入写入队列
+// 队列已满或写入器已关闭时按配置的策略溢出到磁盘，否则丢弃并返回 ErrClickLogDropped
+func (w *ClickLogWriter) Enqueue(ctx context.Context, log *domain.ClickLog) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return w.overflow(log)
+	}
+
+	select {
+	case w.queue <- log:
+		w.enqueued.Add(1)
+		return nil
+	default:
+	}
+
+	if w.cfg.Overflow == OverflowBlock {
+		timer := time.NewTimer(w.cfg.BlockTimeout)
+		defer timer.Stop()
+
+		select {
+		case w.queue <- log:
+			w.enqueued.Add(1)
+			return nil
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+
+	return w.overflow(log)
+}
+
+// overflow 处理无法进入队列的点击日志
+func (w *ClickLogWriter) overflow(log *domain.ClickLog) error {
+	if w.cfg.Overflow == OverflowSpill {
+		if err := w.spill([]*domain.ClickLog{log}); err == nil {
+			w.spilled.Add(1)
+			return nil
+		}
+	}
+	w.dropped.Add(1)
+	return ErrClickLogDropped
+}
+
+// Stats 获取写入器运行状态
+func (w *ClickLogWriter) Stats() domain.ClickLogWriterStats {
+	return domain.ClickLogWriterStats{
+		QueueDepth:       len(w.queue),
+		QueueCapacity:    cap(w.queue),
+		Enqueued:         w.enqueued.Load(),
+		Written:          w.written.Load(),
+		Dropped:          w.dropped.Load(),
+		Spilled:          w.spilled.Load(),
+		Failed:           w.failed.Load(),
+		LastBatchLatency: time.Duration(w.lastBatchLatency.Load()),
+	}
+}
+
+// Close 停止接收新日志，写入队列中剩余的日志后退出
+func (w *ClickLogWriter) Close(ctx context.Context) error {
+	// 等待进行中的入队完成，之后的日志不再进入队列
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+
+	w.shutdown.Do(func() {
+		close(w.stop)
+	})
+
+	started := true
+	w.start.Do(func() {
+		started = false
+	})
+	if !started {
+		// 后台协程未启动，启动一次以写入队列中剩余的日志
+		go w.run()
+	}
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to drain click log queue: %w", ctx.Err())
+	}
+}
+
+// run 后台写入循环
+func (w *ClickLogWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*domain.ClickLog, 0, w.cfg.BatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			w.write(batch)
+			batch = batch[:0]
+		}
+	}
+
+	// 启动时先回放上次遗留的溢出文件
+	w.replay()
+
+	for {
+		select {
+		case log := <-w.queue:
+			batch = append(batch, log)
+			if len(batch) >= w.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+			// 队列较空闲时回放溢出文件
+			if len(w.queue) < cap(w.queue)/2 {
+				w.replay()
+			}
+		case <-w.stop:
+			for {
+				select {
+				case log := <-w.queue:
+					batch = append(batch, log)
+					if len(batch) >= w.cfg.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// rejectedByDatabase 判断错误是否为数据库拒绝了某一行数据，如外键或检查约束失败、字段值无效
+// 这类错误只与该行数据有关，重试不会成功
+func rejectedByDatabase(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	// 22: data_exception，23: integrity_constraint_violation
+	return strings.HasPrefix(pgErr.Code, "22") || strings.HasPrefix(pgErr.Code, "23")
+}
+
+// insertClickLogs 使用多行INSERT批量写入点击日志，已写入过的点击事件按 event_id 忽略
+// 批次被数据库拒绝时(如短链接或规则在跳转之后被删除)逐条写入，被拒绝的日志交给 reject 处理，不影响同批次的其他日志
+// 返回第一条未处理日志的下标：全部处理完成时为 len(logs)，遇到数据库不可用等其他错误时停止并返回该错误
+func insertClickLogs(db *gorm.DB, logs []*domain.ClickLog, batchSize int, reject func(i int, err error)) (int, error) {
+	err := db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(logs, batchSize).Error
+	switch {
+	case err == nil:
+		return len(logs), nil
+	case !rejectedByDatabase(err):
+		return 0, err
+	case len(logs) == 1:
+		reject(0, err)
+		return 1, nil
+	}
+
+	for i := range logs {
+		if _, err := insertClickLogs(db, logs[i:i+1], batchSize, func(_ int, err error) { reject(i, err) }); err != nil {
+			return i, err
+		}
+	}
+	return len(logs), nil
+}
+
+// insert 写入一批点击日志，被数据库拒绝的日志记录后丢弃，返回因其他错误未能写入的日志
+func (w *ClickLogWriter) insert(batch []*domain.ClickLog) ([]*domain.ClickLog, error) {
+	rejected := 0
+	n, err := insertClickLogs(w.db, batch, w.cfg.BatchSize, func(i int, err error) {
+		rejected++
+		w.logger.Warn("dropping click log rejected by database",
+			zap.Uint("short_link_id", batch[i].ShortLinkID), zap.Error(err))
+	})
+	w.written.Add(uint64(n - rejected))
+	w.failed.Add(uint64(rejected))
+	return batch[n:], err
+}
+
+// write 批量写入点击日志，数据库错误时对未写入的日志重试一次
+func (w *ClickLogWriter) write(batch []*domain.ClickLog) {
+	started := time.Now()
+	rest, err := w.insert(batch)
+	if err != nil {
+		time.Sleep(100 * time.Millisecond)
+		rest, err = w.insert(rest)
+	}
+	w.lastBatchLatency.Store(int64(time.Since(started)))
+
+	if err == nil {
+		return
+	}
+
+	w.logger.Error("failed to write click logs", zap.Int("count", len(rest)), zap.Error(err))
+	if w.cfg.Overflow == OverflowSpill {
+		if err := w.spill(rest); err == nil {
+			w.spilled.Add(uint64(len(rest)))
+			return
+		}
+	}
+	w.failed.Add(uint64(len(rest)))
+}
+
+// spill 将点击日志以JSON行的形式追加到溢出文件
+func (w *ClickLogWriter) spill(logs []*domain.ClickLog) error {
+	w.spillMu.Lock()
+	defer w.spillMu.Unlock()
+
+	f, err := os.OpenFile(w.cfg.SpillPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		w.logger.Error("failed to open spill file", zap.String("path", w.cfg.SpillPath), zap.Error(err))
+		return err
+	}
+	defer f.Close()
+
+	buf := bufio.NewWriter(f)
+	enc := json.NewEncoder(buf)
+	for _, log := range logs {
+		// 回放时由数据库重新分配ID
+		entry := *log
+		entry.ID = 0
+		if err := enc.Encode(&entry); err != nil {
+			return err
+		}
+	}
+	if err := buf.Flush(); err != nil {
+		w.logger.Error("failed to write spill file", zap.String("path", w.cfg.SpillPath), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// replay 将溢出文件中的点击日志回放到数据库
+func (w *ClickLogWriter) replay() {
+	if w.cfg.Overflow != OverflowSpill {
+		return
+	}
+
+	// 将溢出文件改名后再读取，新的溢出日志写入新文件
+	replayPath := w.cfg.SpillPath + ".replay"
+	if _, err := os.Stat(replayPath); errors.Is(err, os.ErrNotExist) {
+		w.spillMu.Lock()
+		err := os.Rename(w.cfg.SpillPath, replayPath)
+		w.spillMu.Unlock()
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				w.logger.Error("failed to rotate spill file", zap.String("path", w.cfg.SpillPath), zap.Error(err))
+			}
+			return
+		}
+	}
+
+	f, err := os.Open(replayPath)
+	if err != nil {
+		w.logger.Error("failed to open spill file", zap.String("path", replayPath), zap.Error(err))
+		return
+	}
+	defer f.Close()
+
+	var failed []*domain.ClickLog
+	batch := make([]*domain.ClickLog, 0, w.cfg.BatchSize)
+	insert := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if rest, err := w.insert(batch); err != nil {
+			w.logger.Error("failed to replay click logs", zap.Int("count", len(rest)), zap.Error(err))
+			failed = append(failed, rest...)
+		}
+		batch = make([]*domain.ClickLog, 0, w.cfg.BatchSize)
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var log domain.ClickLog
+		if err := json.Unmarshal(scanner.Bytes(), &log); err != nil {
+			w.logger.Warn("skipping malformed spilled click log", zap.Error(err))
+			continue
+		}
+		batch = append(batch, &log)
+		if len(batch) >= w.cfg.BatchSize {
+			insert()
+		}
+	}
+	insert()
+	if err := scanner.Err(); err != nil {
+		w.logger.Error("failed to read spill file", zap.String("path", replayPath), zap.Error(err))
+		return
+	}
+
+	// 回放失败的日志重新写回溢出文件，等待下次回放
+	if len(failed) > 0 {
+		if err := w.spill(failed); err != nil {
+			return
+		}
+	}
+	if err := os.Remove(replayPath); err != nil {
+		w.logger.Error("failed to remove replayed spill file", zap.String("path", replayPath), zap.Error(err))
+	}
+}
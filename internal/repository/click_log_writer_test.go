@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"linkit/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+func TestClickLogWriterEnqueueDuringClose(t *testing.T) {
+	fake, db := newFakeDB(t)
+	w, err := NewClickLogWriter(db, ClickLogWriterConfig{
+		QueueSize:     1000,
+		BatchSize:     10,
+		FlushInterval: time.Millisecond,
+		Overflow:      OverflowDrop,
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewClickLogWriter() error = %v", err)
+	}
+	w.Start()
+
+	var accepted, dropped atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				err := w.Enqueue(context.Background(), &domain.ClickLog{ShortLinkID: 1})
+				switch {
+				case err == nil:
+					accepted.Add(1)
+				case errors.Is(err, ErrClickLogDropped):
+					dropped.Add(1)
+				default:
+					t.Errorf("Enqueue() error = %v", err)
+				}
+			}
+		}()
+	}
+
+	time.Sleep(time.Millisecond)
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	wg.Wait()
+
+	if accepted.Load()+dropped.Load() != 1600 {
+		t.Fatalf("accepted %d + dropped %d, want 1600", accepted.Load(), dropped.Load())
+	}
+	// 被接受的日志必须全部写入，Close 之后入队的日志按丢弃处理
+	if got := fake.clickLogCount(); int64(got) != accepted.Load() {
+		t.Errorf("written = %d, accepted = %d", got, accepted.Load())
+	}
+	if err := w.Enqueue(context.Background(), &domain.ClickLog{ShortLinkID: 1}); !errors.Is(err, ErrClickLogDropped) {
+		t.Errorf("Enqueue() after Close error = %v, want ErrClickLogDropped", err)
+	}
+}
+
+func TestClickLogWriterSpillsAfterClose(t *testing.T) {
+	_, db := newFakeDB(t)
+	w, err := NewClickLogWriter(db, ClickLogWriterConfig{
+		Overflow:  OverflowSpill,
+		SpillPath: t.TempDir() + "/click_logs.spill",
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewClickLogWriter() error = %v", err)
+	}
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if err := w.Enqueue(context.Background(), &domain.ClickLog{ShortLinkID: 1}); err != nil {
+		t.Fatalf("Enqueue() after Close error = %v", err)
+	}
+	if stats := w.Stats(); stats.Spilled != 1 || stats.QueueDepth != 0 {
+		t.Errorf("Stats() = spilled %d, queue depth %d; want 1, 0", stats.Spilled, stats.QueueDepth)
+	}
+}
+
+func TestClickLogWriterDropsOnlyRejectedRows(t *testing.T) {
+	fake, db := newFakeDB(t)
+	spillPath := t.TempDir() + "/click_logs.spill"
+	w, err := NewClickLogWriter(db, ClickLogWriterConfig{
+		BatchSize: 10,
+		Overflow:  OverflowSpill,
+		SpillPath: spillPath,
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewClickLogWriter() error = %v", err)
+	}
+
+	// 短链接2在日志写入前被删除，第一次写入时数据库不可用
+	fake.rejectLink(2)
+	fake.failInserts(1)
+	for _, id := range []uint{1, 2, 3, 2, 4} {
+		if err := w.Enqueue(context.Background(), &domain.ClickLog{ShortLinkID: id}); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	got := fake.clickLogLinks()
+	want := []int64{1, 3, 4}
+	if len(got) != len(want) {
+		t.Fatalf("written links = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("written links = %v, want %v", got, want)
+		}
+	}
+	if stats := w.Stats(); stats.Written != 3 || stats.Failed != 2 || stats.Spilled != 0 {
+		t.Errorf("Stats() = written %d, failed %d, spilled %d; want 3, 2, 0", stats.Written, stats.Failed, stats.Spilled)
+	}
+	// 被拒绝的日志重试也不会成功，不写入溢出文件
+	if _, err := os.Stat(spillPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("spill file exists, err = %v", err)
+	}
+}
@@ -0,0 +1,452 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/logger"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultClickStreamName       = "linkit:clicks"
+	defaultClickStreamGroup      = "linkit-click-writers"
+	defaultClickStreamBatchSize  = 500
+	defaultClickStreamBlock      = 2 * time.Second
+	defaultClickStreamMinIdle    = time.Minute
+	defaultClickStreamMaxRetries = 5
+	defaultClickStreamMaxLen     = 1000000
+
+	// clickStreamEventField 流消息中存放点击事件的字段
+	clickStreamEventField = "event"
+)
+
+// ClickLogSink 接收待持久化的点击日志
+type ClickLogSink interface {
+	Enqueue(ctx context.Context, log *domain.ClickLog) error
+}
+
+// ClickStreamConfig 点击事件流配置
+type ClickStreamConfig struct {
+	Stream     string        // 流名称
+	Group      string        // 消费者组名称
+	Consumer   string        // 消费者名称，同一消费者组内唯一
+	DeadLetter string        // 死信流名称，默认为 <Stream>:dead
+	MaxLen     int64         // 流的近似最大长度
+	BatchSize  int           // 每次读取的最大消息数
+	Block      time.Duration // 读取消息时的最长阻塞时间
+	MinIdle    time.Duration // 待确认消息超过该空闲时间后被重新认领
+	MaxRetries int64         // 最大投递次数，超过后转入死信流
+}
+
+// withDefaults 填充默认配置
+func (c ClickStreamConfig) withDefaults() ClickStreamConfig {
+	if c.Stream == "" {
+		c.Stream = defaultClickStreamName
+	}
+	if c.Group == "" {
+		c.Group = defaultClickStreamGroup
+	}
+	if c.Consumer == "" {
+		host, _ := os.Hostname()
+		c.Consumer = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	if c.DeadLetter == "" {
+		c.DeadLetter = c.Stream + ":dead"
+	}
+	if c.MaxLen <= 0 {
+		c.MaxLen = defaultClickStreamMaxLen
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaultClickStreamBatchSize
+	}
+	if c.Block <= 0 {
+		c.Block = defaultClickStreamBlock
+	}
+	if c.MinIdle <= 0 {
+		c.MinIdle = defaultClickStreamMinIdle
+	}
+	if c.MaxRetries <= 0 {
+		c.MaxRetries = defaultClickStreamMaxRetries
+	}
+	return c
+}
+
+// newEventID 生成点击事件ID，用于消费端去重
+func newEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ClickStreamProducer 将点击事件追加到Redis Stream
+// 写入Redis失败时交给后备写入器处理，保证跳转不受影响
+type ClickStreamProducer struct {
+	client   redis.Cmdable
+	cfg      ClickStreamConfig
+	fallback ClickLogSink
+	logger   *zap.Logger
+}
+
+// NewClickStreamProducer 创建点击事件生产者
+func NewClickStreamProducer(client redis.Cmdable, cfg ClickStreamConfig, fallback ClickLogSink, logger *zap.Logger) *ClickStreamProducer {
+	return &ClickStreamProducer{
+		client:   client,
+		cfg:      cfg.withDefaults(),
+		fallback: fallback,
+		logger:   logger.Named("click_stream_producer"),
+	}
+}
+
+// Enqueue 将点击日志追加到事件流
+func (p *ClickStreamProducer) Enqueue(ctx context.Context, log *domain.ClickLog) error {
+	if log.EventID == nil {
+		id, err := newEventID()
+		if err != nil {
+			return fmt.Errorf("failed to generate click event id: %w", err)
+		}
+		log.EventID = &id
+	}
+
+	data, err := json.Marshal(log)
+	if err != nil {
+		return fmt.Errorf("failed to marshal click event: %w", err)
+	}
+
+	err = p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: p.cfg.Stream,
+		MaxLen: p.cfg.MaxLen,
+		Approx: true,
+		Values: map[string]interface{}{clickStreamEventField: string(data)},
+	}).Err()
+	if err != nil {
+		logger.FromContext(ctx, p.logger).Warn("failed to append click event to stream, falling back",
+			zap.String("stream", p.cfg.Stream), zap.Error(err))
+		if p.fallback != nil {
+			// 请求已超时或取消时仍需保留点击事件
+			return p.fallback.Enqueue(context.WithoutCancel(ctx), log)
+		}
+		return fmt.Errorf("failed to append click event: %w", err)
+	}
+	return nil
+}
+
+// ClickStreamConsumer 以消费者组的方式读取点击事件流并写入 click_logs
+// 写入成功后确认消息；被数据库拒绝的事件(如短链接已删除)直接转入死信流，不影响同批次的其他事件；
+// 因数据库不可用等原因失败的消息保留在待确认列表中，空闲超时后被重新认领重试，超过最大投递次数的消息转入死信流。点击事件按 event_id 去重，保证至少一次且不重复计数
+type ClickStreamConsumer struct {
+	db     *gorm.DB
+	client redis.Cmdable
+	cfg    ClickStreamConfig
+
+	stop     chan struct{}
+	done     chan struct{}
+	start    sync.Once
+	shutdown sync.Once
+
+	processed    atomic.Uint64
+	retried      atomic.Uint64
+	deadLettered atomic.Uint64
+	failed       atomic.Uint64
+
+	logger *zap.Logger
+}
+
+// NewClickStreamConsumer 创建点击事件消费者
+func NewClickStreamConsumer(db *gorm.DB, client redis.Cmdable, cfg ClickStreamConfig, logger *zap.Logger) *ClickStreamConsumer {
+	cfg = cfg.withDefaults()
+	return &ClickStreamConsumer{
+		db:     db,
+		client: client,
+		cfg:    cfg,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		logger: logger.Named("click_stream_consumer").With(zap.String("consumer", cfg.Consumer)),
+	}
+}
+
+// Start 创建消费者组并启动后台消费协程
+func (c *ClickStreamConsumer) Start(ctx context.Context) error {
+	err := c.client.XGroupCreateMkStream(ctx, c.cfg.Stream, c.cfg.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group: %w", err)
+	}
+
+	c.start.Do(func() {
+		go c.run()
+	})
+	return nil
+}
+
+// Close 停止消费，等待当前批次处理完成
+// 未确认的消息保留在待确认列表中，由其他消费者或下次启动时认领
+func (c *ClickStreamConsumer) Close(ctx context.Context) error {
+	c.shutdown.Do(func() {
+		close(c.stop)
+	})
+
+	started := true
+	c.start.Do(func() {
+		started = false
+	})
+	if !started {
+		return nil
+	}
+
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to stop click stream consumer: %w", ctx.Err())
+	}
+}
+
+// Stats 获取消费者运行状态
+func (c *ClickStreamConsumer) Stats(ctx context.Context) domain.ClickStreamStats {
+	stats := domain.ClickStreamStats{
+		Processed:    c.processed.Load(),
+		Retried:      c.retried.Load(),
+		DeadLettered: c.deadLettered.Load(),
+		Failed:       c.failed.Load(),
+	}
+	if n, err := c.client.XLen(ctx, c.cfg.Stream).Result(); err == nil {
+		stats.Length = n
+	}
+	if p, err := c.client.XPending(ctx, c.cfg.Stream, c.cfg.Group).Result(); err == nil {
+		stats.Pending = p.Count
+	}
+	if n, err := c.client.XLen(ctx, c.cfg.DeadLetter).Result(); err == nil {
+		stats.DeadLetters = n
+	}
+	return stats
+}
+
+// run 消费循环
+func (c *ClickStreamConsumer) run() {
+	defer close(c.done)
+
+	// 停止信号到来时取消阻塞中的读取
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-c.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// 启动时先处理本消费者名下遗留的待确认消息
+	c.consume(ctx, "0")
+
+	lastReclaim := time.Now()
+	for {
+		select {
+		case <-c.stop:
+			return
+		default:
+		}
+
+		if time.Since(lastReclaim) >= c.cfg.MinIdle/2 {
+			c.reclaim(ctx)
+			lastReclaim = time.Now()
+		}
+
+		c.consume(ctx, ">")
+	}
+}
+
+// consume 读取并处理一批消息，start为">"时读取新消息，为"0"时读取本消费者的待确认消息
+func (c *ClickStreamConsumer) consume(ctx context.Context, start string) {
+	streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    c.cfg.Group,
+		Consumer: c.cfg.Consumer,
+		Streams:  []string{c.cfg.Stream, start},
+		Count:    int64(c.cfg.BatchSize),
+		Block:    c.cfg.Block,
+	}).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) && ctx.Err() == nil {
+			c.logger.Error("failed to read click stream", zap.Error(err))
+			time.Sleep(time.Second)
+		}
+		return
+	}
+
+	// 已读取的批次不随停止信号取消，保证写入和确认完整执行
+	for _, stream := range streams {
+		if len(stream.Messages) > 0 {
+			c.process(context.Background(), stream.Messages)
+		}
+	}
+}
+
+// process 将一批消息写入数据库并确认
+func (c *ClickStreamConsumer) process(ctx context.Context, messages []redis.XMessage) {
+	logs := make([]*domain.ClickLog, 0, len(messages))
+	decoded := make([]redis.XMessage, 0, len(messages))
+
+	for _, msg := range messages {
+		log, err := decodeClickEvent(msg)
+		if err != nil {
+			// 无法解析的消息不会因重试而成功，直接转入死信流
+			c.logger.Warn("dead-lettering malformed click event", zap.String("message_id", msg.ID), zap.Error(err))
+			c.deadLetter(ctx, msg, err.Error())
+			continue
+		}
+		logs = append(logs, log)
+		decoded = append(decoded, msg)
+	}
+	if len(logs) == 0 {
+		return
+	}
+
+	// 按 event_id 去重，重复投递的事件不会重复写入
+	rejected := make(map[int]bool)
+	n, err := insertClickLogs(c.db.WithContext(ctx), logs, c.cfg.BatchSize, func(i int, err error) {
+		// 被数据库拒绝的事件不会因重试而成功，直接转入死信流
+		c.logger.Warn("dead-lettering click event rejected by database",
+			zap.String("message_id", decoded[i].ID), zap.Error(err))
+		c.deadLetter(ctx, decoded[i], err.Error())
+		rejected[i] = true
+	})
+	if err != nil {
+		// 未写入的消息不确认，留在待确认列表中等待重新认领
+		c.logger.Error("failed to persist click events", zap.Int("count", len(logs)-n), zap.Error(err))
+		c.failed.Add(uint64(len(logs) - n))
+	}
+
+	ids := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if !rejected[i] {
+			ids = append(ids, decoded[i].ID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	if err := c.client.XAck(ctx, c.cfg.Stream, c.cfg.Group, ids...).Err(); err != nil {
+		c.logger.Error("failed to ack click events", zap.Int("count", len(ids)), zap.Error(err))
+		return
+	}
+	c.processed.Add(uint64(len(ids)))
+}
+
+// reclaim 认领空闲超时的待确认消息，超过最大投递次数的消息转入死信流
+func (c *ClickStreamConsumer) reclaim(ctx context.Context) {
+	pending, err := c.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: c.cfg.Stream,
+		Group:  c.cfg.Group,
+		Idle:   c.cfg.MinIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  int64(c.cfg.BatchSize),
+	}).Result()
+	if err != nil {
+		if ctx.Err() == nil {
+			c.logger.Error("failed to list pending click events", zap.Error(err))
+		}
+		return
+	}
+
+	var retry, dead []string
+	for _, p := range pending {
+		if p.RetryCount >= c.cfg.MaxRetries {
+			dead = append(dead, p.ID)
+		} else {
+			retry = append(retry, p.ID)
+		}
+	}
+
+	if len(dead) > 0 {
+		messages, err := c.client.XClaim(ctx, &redis.XClaimArgs{
+			Stream:   c.cfg.Stream,
+			Group:    c.cfg.Group,
+			Consumer: c.cfg.Consumer,
+			MinIdle:  c.cfg.MinIdle,
+			Messages: dead,
+		}).Result()
+		if err == nil {
+			for _, msg := range messages {
+				c.deadLetter(ctx, msg, "max retries exceeded")
+			}
+		}
+	}
+
+	if len(retry) > 0 {
+		messages, err := c.client.XClaim(ctx, &redis.XClaimArgs{
+			Stream:   c.cfg.Stream,
+			Group:    c.cfg.Group,
+			Consumer: c.cfg.Consumer,
+			MinIdle:  c.cfg.MinIdle,
+			Messages: retry,
+		}).Result()
+		if err != nil {
+			c.logger.Error("failed to claim pending click events", zap.Int("count", len(retry)), zap.Error(err))
+			return
+		}
+		if len(messages) > 0 {
+			c.retried.Add(uint64(len(messages)))
+			c.process(context.Background(), messages)
+		}
+	}
+}
+
+// deadLetter 将消息转入死信流并确认原消息
+func (c *ClickStreamConsumer) deadLetter(ctx context.Context, msg redis.XMessage, reason string) {
+	values := make(map[string]interface{}, len(msg.Values)+2)
+	for k, v := range msg.Values {
+		values[k] = v
+	}
+	values["source_id"] = msg.ID
+	values["reason"] = reason
+
+	if err := c.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: c.cfg.DeadLetter,
+		Values: values,
+	}).Err(); err != nil {
+		c.logger.Error("failed to dead-letter click event", zap.String("message_id", msg.ID), zap.Error(err))
+		return
+	}
+	if err := c.client.XAck(ctx, c.cfg.Stream, c.cfg.Group, msg.ID).Err(); err != nil {
+		c.logger.Error("failed to ack dead-lettered click event", zap.String("message_id", msg.ID), zap.Error(err))
+		return
+	}
+	c.deadLettered.Add(1)
+}
+
+// decodeClickEvent 解析流消息中的点击事件
+func decodeClickEvent(msg redis.XMessage) (*domain.ClickLog, error) {
+	raw, ok := msg.Values[clickStreamEventField].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing %s field", clickStreamEventField)
+	}
+
+	var log domain.ClickLog
+	if err := json.Unmarshal([]byte(raw), &log); err != nil {
+		return nil, err
+	}
+	if log.EventID == nil || *log.EventID == "" {
+		// 使用消息ID作为事件ID，保证重复投递时可以去重
+		id := msg.ID
+		log.EventID = &id
+	}
+	log.ID = 0
+	return &log, nil
+}
@@ -0,0 +1,347 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"linkit/internal/domain"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// fakeStream 只实现点击事件流用到的Redis Stream命令，每个流只支持一个消费者组
+type fakeStream struct {
+	redis.Cmdable
+
+	mu        sync.Mutex
+	seq       int
+	messages  map[string][]redis.XMessage // 流名称 -> 消息
+	groups    map[string]bool             // 已创建消费者组的流
+	delivered map[string]int              // 流名称 -> 已投递给消费者组的消息数
+	pending   map[string]*fakePending     // 消息ID -> 待确认信息
+}
+
+// fakePending 待确认消息
+type fakePending struct {
+	stream    string
+	consumer  string
+	retries   int64
+	delivered time.Time
+}
+
+func newFakeStream() *fakeStream {
+	return &fakeStream{
+		messages:  make(map[string][]redis.XMessage),
+		groups:    make(map[string]bool),
+		delivered: make(map[string]int),
+		pending:   make(map[string]*fakePending),
+	}
+}
+
+func (s *fakeStream) XGroupCreateMkStream(ctx context.Context, stream, group, start string) *redis.StatusCmd {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.groups[stream] {
+		return redis.NewStatusResult("", errors.New("BUSYGROUP Consumer Group name already exists"))
+	}
+	s.groups[stream] = true
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (s *fakeStream) XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	id := fmt.Sprintf("%d-0", s.seq)
+	values := make(map[string]interface{})
+	for k, v := range a.Values.(map[string]interface{}) {
+		values[k] = fmt.Sprint(v)
+	}
+	s.messages[a.Stream] = append(s.messages[a.Stream], redis.XMessage{ID: id, Values: values})
+	return redis.NewStringResult(id, nil)
+}
+
+func (s *fakeStream) XReadGroup(ctx context.Context, a *redis.XReadGroupArgs) *redis.XStreamSliceCmd {
+	stream, start := a.Streams[0], a.Streams[1]
+	deadline := time.Now().Add(a.Block)
+	for {
+		if messages := s.read(stream, start, a.Consumer, int(a.Count)); len(messages) > 0 || start != ">" {
+			return redis.NewXStreamSliceCmdResult([]redis.XStream{{Stream: stream, Messages: messages}}, nil)
+		}
+		if ctx.Err() != nil || time.Now().After(deadline) {
+			return redis.NewXStreamSliceCmdResult(nil, redis.Nil)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// read 读取新消息或本消费者的待确认消息
+func (s *fakeStream) read(stream, start, consumer string, count int) []redis.XMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var messages []redis.XMessage
+	if start == ">" {
+		for _, msg := range s.messages[stream][s.delivered[stream]:] {
+			if len(messages) == count {
+				break
+			}
+			s.pending[msg.ID] = &fakePending{stream: stream, consumer: consumer, retries: 1, delivered: time.Now()}
+			messages = append(messages, msg)
+			s.delivered[stream]++
+		}
+		return messages
+	}
+	for _, msg := range s.messages[stream] {
+		if p, ok := s.pending[msg.ID]; ok && p.consumer == consumer && len(messages) < count {
+			p.retries++
+			p.delivered = time.Now()
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+func (s *fakeStream) XAck(ctx context.Context, stream, group string, ids ...string) *redis.IntCmd {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for _, id := range ids {
+		if _, ok := s.pending[id]; ok {
+			delete(s.pending, id)
+			n++
+		}
+	}
+	return redis.NewIntResult(n, nil)
+}
+
+func (s *fakeStream) XPendingExt(ctx context.Context, a *redis.XPendingExtArgs) *redis.XPendingExtCmd {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pending []redis.XPendingExt
+	for _, msg := range s.messages[a.Stream] {
+		p, ok := s.pending[msg.ID]
+		if !ok || time.Since(p.delivered) < a.Idle || int64(len(pending)) == a.Count {
+			continue
+		}
+		pending = append(pending, redis.XPendingExt{
+			ID:         msg.ID,
+			Consumer:   p.consumer,
+			Idle:       time.Since(p.delivered),
+			RetryCount: p.retries,
+		})
+	}
+	cmd := redis.NewXPendingExtCmd(ctx)
+	cmd.SetVal(pending)
+	return cmd
+}
+
+func (s *fakeStream) XClaim(ctx context.Context, a *redis.XClaimArgs) *redis.XMessageSliceCmd {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var messages []redis.XMessage
+	for _, msg := range s.messages[a.Stream] {
+		for _, id := range a.Messages {
+			p, ok := s.pending[id]
+			if id != msg.ID || !ok || time.Since(p.delivered) < a.MinIdle {
+				continue
+			}
+			p.consumer = a.Consumer
+			p.retries++
+			p.delivered = time.Now()
+			messages = append(messages, msg)
+		}
+	}
+	return redis.NewXMessageSliceCmdResult(messages, nil)
+}
+
+func (s *fakeStream) XLen(ctx context.Context, stream string) *redis.IntCmd {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return redis.NewIntResult(int64(len(s.messages[stream])), nil)
+}
+
+func (s *fakeStream) XPending(ctx context.Context, stream, group string) *redis.XPendingCmd {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cmd := redis.NewXPendingCmd(ctx)
+	cmd.SetVal(&redis.XPending{Count: int64(len(s.pending))})
+	return cmd
+}
+
+// deadLetters 返回死信流中的消息
+func (s *fakeStream) deadLetters() []redis.XMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]redis.XMessage(nil), s.messages[defaultClickStreamName+":dead"]...)
+}
+
+// newTestClickStream 创建使用内存数据库和内存事件流的生产者与消费者
+func newTestClickStream(t *testing.T, cfg ClickStreamConfig) (*fakeDB, *fakeStream, *ClickStreamProducer, *ClickStreamConsumer) {
+	t.Helper()
+	fake, db := newFakeDB(t)
+	stream := newFakeStream()
+	cfg.Consumer = "test"
+	producer := NewClickStreamProducer(stream, cfg, nil, zap.NewNop())
+	consumer := NewClickStreamConsumer(db, stream, cfg, zap.NewNop())
+	if err := stream.XGroupCreateMkStream(context.Background(), consumer.cfg.Stream, consumer.cfg.Group, "0").Err(); err != nil {
+		t.Fatalf("XGroupCreateMkStream() error = %v", err)
+	}
+	return fake, stream, producer, consumer
+}
+
+// produceClicks 向事件流追加指定短链接的点击事件
+func produceClicks(t *testing.T, producer *ClickStreamProducer, ids ...uint) {
+	t.Helper()
+	for _, id := range ids {
+		if err := producer.Enqueue(context.Background(), &domain.ClickLog{ShortLinkID: id}); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+	}
+}
+
+// wantClickLogLinks 检查已写入点击日志的短链接ID
+func wantClickLogLinks(t *testing.T, fake *fakeDB, want ...int64) {
+	t.Helper()
+	got := fake.clickLogLinks()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("written links = %v, want %v", got, want)
+	}
+}
+
+func TestClickStreamConsumerStartAndClose(t *testing.T) {
+	fake, _, producer, consumer := newTestClickStream(t, ClickStreamConfig{Block: 5 * time.Millisecond})
+	produceClicks(t, producer, 1, 2, 3)
+
+	// 消费者组已存在时启动不报错
+	if err := consumer.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for fake.clickLogCount() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := consumer.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	wantClickLogLinks(t, fake, 1, 2, 3)
+	if stats := consumer.Stats(context.Background()); stats.Processed != 3 || stats.Pending != 0 || stats.Length != 3 {
+		t.Errorf("Stats() = processed %d, pending %d, length %d; want 3, 0, 3", stats.Processed, stats.Pending, stats.Length)
+	}
+}
+
+func TestClickStreamConsumerDeadLettersOnlyRejectedEvents(t *testing.T) {
+	fake, stream, producer, consumer := newTestClickStream(t, ClickStreamConfig{})
+	// 短链接2在事件写入前被删除
+	fake.rejectLink(2)
+	produceClicks(t, producer, 1, 2, 3)
+	stream.XAdd(context.Background(), &redis.XAddArgs{
+		Stream: consumer.cfg.Stream,
+		Values: map[string]interface{}{clickStreamEventField: "not json"},
+	})
+
+	consumer.consume(context.Background(), ">")
+
+	wantClickLogLinks(t, fake, 1, 3)
+	dead := stream.deadLetters()
+	if len(dead) != 2 {
+		t.Fatalf("dead letters = %d, want 2", len(dead))
+	}
+	for i, sourceID := range []string{"4-0", "2-0"} {
+		if dead[i].Values["source_id"] != sourceID || dead[i].Values["reason"] == "" {
+			t.Errorf("dead letter %d = %v, want source_id %s with reason", i, dead[i].Values, sourceID)
+		}
+	}
+	stats := consumer.Stats(context.Background())
+	if stats.Processed != 2 || stats.DeadLettered != 2 || stats.Pending != 0 || stats.Failed != 0 {
+		t.Errorf("Stats() = processed %d, dead-lettered %d, pending %d, failed %d; want 2, 2, 0, 0",
+			stats.Processed, stats.DeadLettered, stats.Pending, stats.Failed)
+	}
+}
+
+func TestClickStreamConsumerReclaimsFailedEvents(t *testing.T) {
+	fake, _, producer, consumer := newTestClickStream(t, ClickStreamConfig{MinIdle: time.Millisecond})
+	produceClicks(t, producer, 1, 2)
+
+	// 数据库不可用时不确认，消息留在待确认列表中
+	fake.failInserts(1)
+	consumer.consume(context.Background(), ">")
+	wantClickLogLinks(t, fake)
+	if stats := consumer.Stats(context.Background()); stats.Pending != 2 || stats.Failed != 2 {
+		t.Fatalf("Stats() = pending %d, failed %d; want 2, 2", stats.Pending, stats.Failed)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	consumer.reclaim(context.Background())
+	wantClickLogLinks(t, fake, 1, 2)
+	if stats := consumer.Stats(context.Background()); stats.Pending != 0 || stats.Retried != 2 || stats.Processed != 2 {
+		t.Errorf("Stats() = pending %d, retried %d, processed %d; want 0, 2, 2", stats.Pending, stats.Retried, stats.Processed)
+	}
+}
+
+func TestClickStreamConsumerDeadLettersAfterMaxRetries(t *testing.T) {
+	fake, stream, producer, consumer := newTestClickStream(t, ClickStreamConfig{MinIdle: time.Millisecond, MaxRetries: 2})
+	produceClicks(t, producer, 1)
+
+	fake.failInserts(10)
+	consumer.consume(context.Background(), ">")
+	time.Sleep(2 * time.Millisecond)
+	// 第二次投递仍然失败
+	consumer.reclaim(context.Background())
+	if len(stream.deadLetters()) != 0 {
+		t.Fatalf("dead-lettered before max retries")
+	}
+	time.Sleep(2 * time.Millisecond)
+	consumer.reclaim(context.Background())
+
+	dead := stream.deadLetters()
+	if len(dead) != 1 || dead[0].Values["reason"] != "max retries exceeded" {
+		t.Fatalf("dead letters = %v, want one with max retries exceeded", dead)
+	}
+	if stats := consumer.Stats(context.Background()); stats.Pending != 0 || stats.DeadLettered != 1 {
+		t.Errorf("Stats() = pending %d, dead-lettered %d; want 0, 1", stats.Pending, stats.DeadLettered)
+	}
+	wantClickLogLinks(t, fake)
+}
+
+func TestClickStreamConsumerDeduplicatesRedeliveredEvents(t *testing.T) {
+	fake, stream, producer, consumer := newTestClickStream(t, ClickStreamConfig{})
+	eventID := "event-1"
+	if err := producer.Enqueue(context.Background(), &domain.ClickLog{ShortLinkID: 1, EventID: &eventID}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	produceClicks(t, producer, 2)
+	consumer.consume(context.Background(), ">")
+
+	// 生产者重试或消息被重复投递时，同一事件只写入一次
+	if err := producer.Enqueue(context.Background(), &domain.ClickLog{ShortLinkID: 1, EventID: &eventID}); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	consumer.consume(context.Background(), ">")
+
+	wantClickLogLinks(t, fake, 1, 2)
+	if stats := consumer.Stats(context.Background()); stats.Processed != 3 || stats.Pending != 0 {
+		t.Errorf("Stats() = processed %d, pending %d; want 3, 0", stats.Processed, stats.Pending)
+	}
+	if len(stream.deadLetters()) != 0 {
+		t.Errorf("duplicate event was dead-lettered")
+	}
+}
+
+func TestDecodeClickEventUsesMessageID(t *testing.T) {
+	log, err := decodeClickEvent(redis.XMessage{
+		ID:     "7-0",
+		Values: map[string]interface{}{clickStreamEventField: `{"id":9,"short_link_id":1}`},
+	})
+	if err != nil {
+		t.Fatalf("decodeClickEvent() error = %v", err)
+	}
+	if log.EventID == nil || *log.EventID != "7-0" || log.ID != 0 {
+		t.Errorf("decodeClickEvent() = event id %v, id %d; want 7-0, 0", log.EventID, log.ID)
+	}
+}
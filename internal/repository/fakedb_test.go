@@ -0,0 +1,440 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeDB 只理解短链接读写、规则替换、点击日志写入、点击数同步和用量统计语句的内存数据库，用于在没有 PostgreSQL 的环境下测试仓储
+type fakeDB struct {
+	mu        sync.Mutex
+	links     map[string]map[string]driver.Value // 短码 -> 列 -> 值
+	rules     [][]driver.Value                   // 批量替换写入的规则，按 INSERT 参数顺序保存，不区分短链接
+	clickLogs []map[string]driver.Value          // 已写入的点击日志
+
+	rejectLinks    map[int64]bool // 写入这些短链接的点击日志时返回外键约束错误
+	insertFailures int            // 接下来写入点击日志失败的次数，模拟数据库不可用
+
+	advisory map[string]*sync.Mutex // 事务级咨询锁，按锁键划分
+	nextID   int64                  // 最近分配的短链接ID
+}
+
+// errFakeUnavailable 模拟数据库不可用
+var errFakeUnavailable = errors.New("fakedb: connection refused")
+
+var (
+	fakeSetColumn   = regexp.MustCompile(`"(\w+)"=\$(\d+)`)
+	fakeWhereID     = regexp.MustCompile(`"id" = \$(\d+)`)
+	fakeSelectWords = regexp.MustCompile(`^SELECT (.+?) FROM`)
+	fakeInsertCols  = regexp.MustCompile(`^INSERT INTO "(\w+)" \(([^)]*)\)`)
+)
+
+// newFakeDB 创建使用 fakeDB 的 GORM 实例
+func newFakeDB(t *testing.T) (*fakeDB, *gorm.DB) {
+	t.Helper()
+	fake := &fakeDB{
+		links:       make(map[string]map[string]driver.Value),
+		rejectLinks: make(map[int64]bool),
+		advisory:    make(map[string]*sync.Mutex),
+	}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(fake)}), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("failed to open fake db: %v", err)
+	}
+	return fake, db
+}
+
+// insert 写入一条短链接记录
+func (f *fakeDB) insert(row map[string]driver.Value) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.links[row["short_code"].(string)] = row
+	if id := row["id"].(int64); id > f.nextID {
+		f.nextID = id
+	}
+}
+
+// insertClickLog 直接写入一条点击日志记录
+func (f *fakeDB) insertClickLog(row map[string]driver.Value) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.clickLogs = append(f.clickLogs, row)
+}
+
+// linkCount 返回短链接记录数
+func (f *fakeDB) linkCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.links)
+}
+
+// clickLogCount 返回已写入的点击日志条数
+func (f *fakeDB) clickLogCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.clickLogs)
+}
+
+// clickLogLinks 按写入顺序返回点击日志的短链接ID
+func (f *fakeDB) clickLogLinks() []int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ids := make([]int64, 0, len(f.clickLogs))
+	for _, row := range f.clickLogs {
+		ids = append(ids, row["short_link_id"].(int64))
+	}
+	return ids
+}
+
+// rejectLink 使写入该短链接的点击日志失败，模拟短链接已被删除
+func (f *fakeDB) rejectLink(id int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rejectLinks[id] = true
+}
+
+// failInserts 使接下来 n 次写入点击日志失败
+func (f *fakeDB) failInserts(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.insertFailures = n
+}
+
+// insertedRules 返回批量替换写入的规则参数
+func (f *fakeDB) insertedRules() [][]driver.Value {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]driver.Value{}, f.rules...)
+}
+
+// row 返回短链接记录的副本
+func (f *fakeDB) row(code string) map[string]driver.Value {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	row := make(map[string]driver.Value, len(f.links[code]))
+	for k, v := range f.links[code] {
+		row[k] = v
+	}
+	return row
+}
+
+// Connect 实现 driver.Connector
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{db: f}, nil
+}
+
+// Driver 实现 driver.Connector
+func (f *fakeDB) Driver() driver.Driver {
+	return f
+}
+
+// Open 实现 driver.Driver
+func (f *fakeDB) Open(string) (driver.Conn, error) {
+	return &fakeConn{db: f}, nil
+}
+
+// fakeConn fakeDB 的连接，事务只做占位，语句立即生效；事务中获取的咨询锁在提交或回滚时释放
+type fakeConn struct {
+	db   *fakeDB
+	held []*sync.Mutex
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("fakedb: prepared statements not supported")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return c, nil }
+
+func (c *fakeConn) Commit() error {
+	c.unlockAll()
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.unlockAll()
+	return nil
+}
+
+// unlockAll 释放事务中获取的咨询锁
+func (c *fakeConn) unlockAll() {
+	for _, l := range c.held {
+		l.Unlock()
+	}
+	c.held = nil
+}
+
+// advisoryLock 获取事务级咨询锁，其他事务持有同一个锁时阻塞
+func (c *fakeConn) advisoryLock(key string) {
+	c.db.mu.Lock()
+	l, ok := c.db.advisory[key]
+	if !ok {
+		l = &sync.Mutex{}
+		c.db.advisory[key] = l
+	}
+	c.db.mu.Unlock()
+
+	l.Lock()
+	c.held = append(c.held, l)
+}
+
+// ExecContext 执行更新语句
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if strings.HasPrefix(query, "SELECT pg_advisory_xact_lock(") {
+		c.advisoryLock(args[0].Value.(string))
+		return driver.RowsAffected(0), nil
+	}
+
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(query, `UPDATE "short_links" SET`):
+		m := fakeWhereID.FindStringSubmatch(query)
+		if m == nil {
+			return nil, fmt.Errorf("fakedb: unsupported update: %s", query)
+		}
+		id := arg(args, m[1])
+		for _, row := range c.db.links {
+			if row["id"] != id {
+				continue
+			}
+			set := query[:strings.Index(query, " WHERE ")]
+			for _, col := range fakeSetColumn.FindAllStringSubmatch(set, -1) {
+				row[col[1]] = arg(args, col[2])
+			}
+			return driver.RowsAffected(1), nil
+		}
+		return driver.RowsAffected(0), nil
+	case strings.Contains(query, "SET clicks = s.clicks + v.n"):
+		for i := 0; i+1 < len(args); i += 2 {
+			if row, ok := c.db.links[args[i].Value.(string)]; ok {
+				row["clicks"] = row["clicks"].(int64) + args[i+1].Value.(int64)
+			}
+		}
+		return driver.RowsAffected(int64(len(args) / 2)), nil
+	case strings.HasPrefix(query, `DELETE FROM "short_links" WHERE short_code = $1`):
+		code := args[0].Value.(string)
+		if _, ok := c.db.links[code]; !ok {
+			return driver.RowsAffected(0), nil
+		}
+		delete(c.db.links, code)
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(query, `DELETE FROM "redirect_rules" WHERE short_link_id = $1`):
+		n := len(c.db.rules)
+		c.db.rules = nil
+		return driver.RowsAffected(int64(n)), nil
+	case strings.HasPrefix(strings.TrimSpace(query), "INSERT INTO redirect_rules"):
+		values := make([]driver.Value, len(args))
+		for i, a := range args {
+			values[i] = a.Value
+		}
+		c.db.rules = append(c.db.rules, values)
+		return driver.RowsAffected(1), nil
+	case strings.Contains(query, `"click_count_flushes"`):
+		return driver.RowsAffected(1), nil
+	}
+	return nil, fmt.Errorf("fakedb: unsupported exec: %s", query)
+}
+
+// QueryContext 按短码查询短链接，写入短链接或点击日志并返回分配的ID，或统计用量
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(query, `INSERT INTO "click_logs"`):
+		return c.insertClickLogs(query, args)
+	case strings.HasPrefix(query, `INSERT INTO "short_links"`):
+		return c.insertLink(query, args)
+	case strings.HasPrefix(query, `SELECT count(*) FROM "short_links"`):
+		return c.countActiveLinks(args)
+	case strings.HasPrefix(query, `SELECT count(*) FROM "click_logs" JOIN short_links`):
+		return c.countClickLogs(args)
+	}
+
+	m := fakeSelectWords.FindStringSubmatch(query)
+	if m == nil || !strings.Contains(query, "short_code = $1") {
+		return nil, fmt.Errorf("fakedb: unsupported query: %s", query)
+	}
+	columns := strings.Split(m[1], ", ")
+	rows := &fakeRows{columns: columns}
+	if row, ok := c.db.links[args[0].Value.(string)]; ok {
+		values := make([]driver.Value, len(columns))
+		for i, col := range columns {
+			values[i] = row[col]
+		}
+		rows.values = append(rows.values, values)
+	}
+	return rows, nil
+}
+
+// insertClickLogs 写入点击日志，与 PostgreSQL 一样整条语句要么全部写入要么全部失败，按 event_id 忽略重复的事件
+func (c *fakeConn) insertClickLogs(query string, args []driver.NamedValue) (driver.Rows, error) {
+	if c.db.insertFailures > 0 {
+		c.db.insertFailures--
+		return nil, errFakeUnavailable
+	}
+
+	var rows []map[string]driver.Value
+	for _, row := range insertedRows(query, args) {
+		if c.db.rejectLinks[row["short_link_id"].(int64)] {
+			return nil, &pgconn.PgError{
+				Code:    "23503",
+				Message: `insert or update on table "click_logs" violates foreign key constraint "fk_click_logs_short_link"`,
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	result := &fakeRows{columns: []string{"id"}}
+	for _, row := range rows {
+		if id, ok := row["event_id"].(string); ok && c.db.hasEvent(id) {
+			continue
+		}
+		c.db.clickLogs = append(c.db.clickLogs, row)
+		result.values = append(result.values, []driver.Value{int64(len(c.db.clickLogs))})
+	}
+	return result, nil
+}
+
+// insertLink 写入短链接并返回分配的ID，短码重复时返回唯一约束错误
+func (c *fakeConn) insertLink(query string, args []driver.NamedValue) (driver.Rows, error) {
+	result := &fakeRows{columns: []string{"id"}}
+	for _, row := range insertedRows(query, args) {
+		code := row["short_code"].(string)
+		if _, ok := c.db.links[code]; ok {
+			return nil, &pgconn.PgError{
+				Code:    "23505",
+				Message: `duplicate key value violates unique constraint "idx_short_links_short_code"`,
+			}
+		}
+		c.db.nextID++
+		row["id"] = c.db.nextID
+		c.db.links[code] = row
+		result.values = append(result.values, []driver.Value{c.db.nextID})
+	}
+	return result, nil
+}
+
+// countActiveLinks 统计工作空间(个人空间时为用户)未过期的短链接数
+// 参数依次为工作空间ID、当前时间，个人空间时还有用户ID
+func (c *fakeConn) countActiveLinks(args []driver.NamedValue) (driver.Rows, error) {
+	var userID driver.Value
+	if len(args) > 2 {
+		userID = args[2].Value
+	}
+	var count int64
+	for _, row := range c.db.links {
+		if ownedBy(row, args[0].Value, userID) && row["expires_at"].(time.Time).After(args[1].Value.(time.Time)) {
+			count++
+		}
+	}
+	return &fakeRows{columns: []string{"count"}, values: [][]driver.Value{{count}}}, nil
+}
+
+// countClickLogs 统计时间范围内的点击日志数
+// 参数依次为工作空间ID、开始时间、结束时间，个人空间时还有用户ID
+func (c *fakeConn) countClickLogs(args []driver.NamedValue) (driver.Rows, error) {
+	start, end := args[1].Value.(time.Time), args[2].Value.(time.Time)
+	var userID driver.Value
+	if len(args) > 3 {
+		userID = args[3].Value
+	}
+
+	var count int64
+	for _, log := range c.db.clickLogs {
+		at := log["created_at"].(time.Time)
+		if at.Before(start) || !at.Before(end) {
+			continue
+		}
+		for _, row := range c.db.links {
+			if row["id"] == log["short_link_id"] && ownedBy(row, args[0].Value, userID) {
+				count++
+			}
+		}
+	}
+	return &fakeRows{columns: []string{"count"}, values: [][]driver.Value{{count}}}, nil
+}
+
+// ownedBy 判断短链接是否属于指定的工作空间，userID 不为空时还需属于该用户
+func ownedBy(row map[string]driver.Value, workspaceID, userID driver.Value) bool {
+	if row["workspace_id"] != workspaceID {
+		return false
+	}
+	return userID == nil || row["user_id"] == userID
+}
+
+// insertedRows 按 INSERT 语句的列名拆分参数
+func insertedRows(query string, args []driver.NamedValue) []map[string]driver.Value {
+	m := fakeInsertCols.FindStringSubmatch(query)
+	if m == nil {
+		return nil
+	}
+	columns := strings.Split(strings.ReplaceAll(m[2], `"`, ""), ",")
+	var rows []map[string]driver.Value
+	for i := 0; i+len(columns) <= len(args); i += len(columns) {
+		row := make(map[string]driver.Value, len(columns))
+		for j, col := range columns {
+			row[col] = args[i+j].Value
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// hasEvent 判断点击事件是否已写入，调用方需持有锁
+func (f *fakeDB) hasEvent(id string) bool {
+	for _, row := range f.clickLogs {
+		if row["event_id"] == id {
+			return true
+		}
+	}
+	return false
+}
+
+// arg 按占位符序号取参数
+func arg(args []driver.NamedValue, ordinal string) driver.Value {
+	for _, a := range args {
+		if fmt.Sprint(a.Ordinal) == ordinal {
+			return a.Value
+		}
+	}
+	return nil
+}
+
+// fakeRows 查询结果
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
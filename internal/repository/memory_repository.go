@@ -0,0 +1,586 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"linkit/internal/domain"
+)
+
+// MemoryShortLinkRepository 基于进程内存实现的短链接仓储
+// 行为与 ShortLinkRepository 保持一致，适用于单元测试以及将 Linkit 嵌入到其他 Go 服务中运行
+type MemoryShortLinkRepository struct {
+	mu sync.RWMutex
+
+	links     map[string]*domain.ShortLink // 短码 -> 短链接
+	rules     map[uint]*domain.RedirectRule
+	clickLogs []domain.ClickLog
+
+	nextLinkID uint
+	nextRuleID uint
+	nextLogID  uint
+}
+
+// NewMemoryShortLinkRepository 创建内存短链接仓储实例
+func NewMemoryShortLinkRepository() domain.ShortLinkRepository {
+	return &MemoryShortLinkRepository{
+		links: make(map[string]*domain.ShortLink),
+		rules: make(map[uint]*domain.RedirectRule),
+	}
+}
+
+// copyLink 复制短链接，避免调用方修改仓储内部数据
+func copyLink(link *domain.ShortLink) domain.ShortLink {
+	c := *link
+	c.Rules = nil
+	if link.MaxVisits != nil {
+		v := *link.MaxVisits
+		c.MaxVisits = &v
+	}
+	return c
+}
+
+// copyRule 复制跳转规则
+func copyRule(rule *domain.RedirectRule) domain.RedirectRule {
+	c := *rule
+	c.Countries = append([]string{}, rule.Countries...)
+	c.Provinces = append([]string{}, rule.Provinces...)
+	c.Cities = append([]string{}, rule.Cities...)
+	if rule.StartTime != nil {
+		t := *rule.StartTime
+		c.StartTime = &t
+	}
+	if rule.EndTime != nil {
+		t := *rule.EndTime
+		c.EndTime = &t
+	}
+	if rule.Percentage != nil {
+		v := *rule.Percentage
+		c.Percentage = &v
+	}
+	if rule.MaxVisits != nil {
+		v := *rule.MaxVisits
+		c.MaxVisits = &v
+	}
+	return c
+}
+
+// checkActiveLinks 检查短链接归属空间的有效短链接数是否已达到 maxActive，调用方需持有锁
+func (r *MemoryShortLinkRepository) checkActiveLinks(link *domain.ShortLink, maxActive int64) error {
+	if maxActive <= 0 {
+		return nil
+	}
+	owner := linkUsageOwner(link)
+	now := time.Now()
+	var count int64
+	for _, stored := range r.links {
+		if stored.ID != link.ID && linkUsageOwner(stored) == owner && stored.ExpiresAt.After(now) {
+			count++
+		}
+	}
+	if count >= maxActive {
+		return domain.ErrQuotaExceeded
+	}
+	return nil
+}
+
+// Create 创建短链接，maxActive 大于0时检查有效短链接数配额
+func (r *MemoryShortLinkRepository) Create(_ context.Context, link *domain.ShortLink, maxActive int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.links[link.ShortCode]; ok {
+		return fmt.Errorf("failed to create short link: %w", domain.ErrCustomCodeExists)
+	}
+	if err := r.checkActiveLinks(link, maxActive); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	r.nextLinkID++
+	link.ID = r.nextLinkID
+	if link.CreatedAt.IsZero() {
+		link.CreatedAt = now
+	}
+	if link.UpdatedAt.IsZero() {
+		link.UpdatedAt = now
+	}
+
+	stored := copyLink(link)
+	r.links[link.ShortCode] = &stored
+	return nil
+}
+
+// GetByCode 根据短码获取短链接
+func (r *MemoryShortLinkRepository) GetByCode(_ context.Context, code string) (*domain.ShortLink, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.links[code]
+	if !ok {
+		return nil, domain.ErrShortLinkNotFound
+	}
+
+	link := copyLink(stored)
+	// 如果default_redirect为0,设置为默认值1
+	if link.DefaultRedirect == 0 {
+		link.DefaultRedirect = domain.RedirectPermanent
+	}
+	return &link, nil
+}
+
+// Update 更新短链接，maxActive 大于0时检查有效短链接数配额
+func (r *MemoryShortLinkRepository) Update(_ context.Context, link *domain.ShortLink, maxActive int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkActiveLinks(link, maxActive); err != nil {
+		return err
+	}
+
+	// 与 GORM 的 Save 语义一致：按ID更新，不存在时插入；点击数保留仓储中的值
+	var clicks uint64
+	for code, stored := range r.links {
+		if stored.ID == link.ID {
+			clicks = stored.Clicks
+			delete(r.links, code)
+			break
+		}
+	}
+	if link.ID == 0 {
+		r.nextLinkID++
+		link.ID = r.nextLinkID
+	} else if link.ID > r.nextLinkID {
+		r.nextLinkID = link.ID
+	}
+
+	stored := copyLink(link)
+	stored.Clicks = clicks
+	r.links[link.ShortCode] = &stored
+	return nil
+}
+
+// Delete 删除短链接，同时级联删除其规则和点击日志
+func (r *MemoryShortLinkRepository) Delete(_ context.Context, code string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.links[code]
+	if !ok {
+		return nil
+	}
+	delete(r.links, code)
+
+	for id, rule := range r.rules {
+		if rule.ShortLinkID == stored.ID {
+			delete(r.rules, id)
+		}
+	}
+
+	logs := r.clickLogs[:0]
+	for _, log := range r.clickLogs {
+		if log.ShortLinkID != stored.ID {
+			logs = append(logs, log)
+		}
+	}
+	r.clickLogs = logs
+
+	return nil
+}
+
+// IncrementClicks 增加点击次数
+func (r *MemoryShortLinkRepository) IncrementClicks(_ context.Context, code string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.links[code]; ok {
+		stored.Clicks++
+	}
+	return nil
+}
+
+// LogClick 记录点击日志
+func (r *MemoryShortLinkRepository) LogClick(_ context.Context, log *domain.ClickLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextLogID++
+	log.ID = r.nextLogID
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+	if log.Kind == "" {
+		log.Kind = domain.ClickKindClick
+	}
+
+	stored := *log
+	if log.RuleID != nil {
+		id := *log.RuleID
+		stored.RuleID = &id
+	}
+	r.clickLogs = append(r.clickLogs, stored)
+	return nil
+}
+
+// linkSortFields 短链接可排序字段，数据库仓储按同一列表校验排序字段
+var linkSortFields = map[string]func(a, b *domain.ShortLink) int{
+	"id":         func(a, b *domain.ShortLink) int { return compareUint64(uint64(a.ID), uint64(b.ID)) },
+	"created_at": func(a, b *domain.ShortLink) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"updated_at": func(a, b *domain.ShortLink) int { return a.UpdatedAt.Compare(b.UpdatedAt) },
+	"expires_at": func(a, b *domain.ShortLink) int { return a.ExpiresAt.Compare(b.ExpiresAt) },
+	"clicks":     func(a, b *domain.ShortLink) int { return compareUint64(a.Clicks, b.Clicks) },
+	"short_code": func(a, b *domain.ShortLink) int { return strings.Compare(a.ShortCode, b.ShortCode) },
+}
+
+// clickLogSortFields 访问记录可排序字段，数据库仓储按同一列表校验排序字段
+var clickLogSortFields = map[string]func(a, b *domain.ClickLog) int{
+	"id":         func(a, b *domain.ClickLog) int { return compareUint64(uint64(a.ID), uint64(b.ID)) },
+	"created_at": func(a, b *domain.ClickLog) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"ip":         func(a, b *domain.ClickLog) int { return strings.Compare(a.IP, b.IP) },
+	"country":    func(a, b *domain.ClickLog) int { return strings.Compare(a.Country, b.Country) },
+	"device":     func(a, b *domain.ClickLog) int { return compareUint64(uint64(a.Device), uint64(b.Device)) },
+	"rule_id": func(a, b *domain.ClickLog) int {
+		var x, y uint64
+		if a.RuleID != nil {
+			x = uint64(*a.RuleID)
+		}
+		if b.RuleID != nil {
+			y = uint64(*b.RuleID)
+		}
+		return compareUint64(x, y)
+	},
+}
+
+// compareUint64 比较两个无符号整数
+func compareUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// paginate 计算分页区间
+func paginate(total, page, pageSize int) (start, end int) {
+	start = (page - 1) * pageSize
+	if start > total {
+		start = total
+	}
+	end = start + pageSize
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
+// List 获取短链接列表
+func (r *MemoryShortLinkRepository) List(_ context.Context, query *domain.PaginationQuery) (*domain.PaginatedShortLinks, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	links := make([]domain.ShortLink, 0, len(r.links))
+
+	// 应用过滤条件
+	for _, stored := range r.links {
+		if f := query.Filter; f != nil {
+			if f.UserID != nil && stored.UserID != *f.UserID {
+				continue
+			}
+			if f.WorkspaceID != nil && stored.WorkspaceID != *f.WorkspaceID {
+				continue
+			}
+			if f.IsExpired != nil {
+				if *f.IsExpired && !stored.ExpiresAt.Before(now) {
+					continue
+				}
+				if !*f.IsExpired && !stored.ExpiresAt.After(now) {
+					continue
+				}
+			}
+			if f.StartTime != nil && stored.CreatedAt.Before(*f.StartTime) {
+				continue
+			}
+			if f.EndTime != nil && stored.CreatedAt.After(*f.EndTime) {
+				continue
+			}
+			if f.MinClicks != nil && stored.Clicks < *f.MinClicks {
+				continue
+			}
+			if f.MaxClicks != nil && stored.Clicks > *f.MaxClicks {
+				continue
+			}
+			if f.Campaign != nil && stored.UTM.Campaign != *f.Campaign {
+				continue
+			}
+		}
+		links = append(links, copyLink(stored))
+	}
+
+	// 应用排序，默认按创建时间降序
+	field, desc := "created_at", true
+	if query.Sort != nil && query.Sort.Field != "" {
+		field, desc = query.Sort.Field, query.Sort.Direction != domain.SortAsc
+	}
+	cmp, ok := linkSortFields[field]
+	if !ok {
+		return nil, fmt.Errorf("failed to get links: unknown sort field %q", field)
+	}
+	sort.SliceStable(links, func(i, j int) bool {
+		c := cmp(&links[i], &links[j])
+		if c == 0 {
+			c = compareUint64(uint64(links[i].ID), uint64(links[j].ID))
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	// 应用分页
+	total := len(links)
+	start, end := paginate(total, query.Page, query.PageSize)
+
+	return &domain.PaginatedShortLinks{
+		Total:       int64(total),
+		TotalPages:  (total + query.PageSize - 1) / query.PageSize,
+		CurrentPage: query.Page,
+		PageSize:    query.PageSize,
+		Data:        links[start:end],
+	}, nil
+}
+
+// ListClickLogs 获取访问记录列表
+func (r *MemoryShortLinkRepository) ListClickLogs(_ context.Context, shortLinkID uint, query *domain.ClickLogQuery) (*domain.PaginatedClickLogs, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	logs := make([]domain.ClickLog, 0)
+
+	// 应用过滤条件
+	for _, log := range r.clickLogs {
+		if log.ShortLinkID != shortLinkID {
+			continue
+		}
+		if f := query.Filter; f != nil {
+			if f.StartTime != nil && log.CreatedAt.Before(*f.StartTime) {
+				continue
+			}
+			if f.EndTime != nil && log.CreatedAt.After(*f.EndTime) {
+				continue
+			}
+			if f.IP != nil && log.IP != *f.IP {
+				continue
+			}
+			if f.Country != nil && log.Country != *f.Country {
+				continue
+			}
+			if f.Device != nil && log.Device != *f.Device {
+				continue
+			}
+			if f.RuleID != nil && (log.RuleID == nil || *log.RuleID != *f.RuleID) {
+				continue
+			}
+			if f.Kind != nil && log.Kind != *f.Kind {
+				continue
+			}
+			if f.Campaign != nil && log.Campaign != *f.Campaign {
+				continue
+			}
+		}
+		logs = append(logs, log)
+	}
+
+	// 应用排序，默认按创建时间降序
+	field, desc := "created_at", true
+	if query.Sort != nil && query.Sort.Field != "" {
+		field, desc = query.Sort.Field, query.Sort.Direction != domain.SortAsc
+	}
+	cmp, ok := clickLogSortFields[field]
+	if !ok {
+		return nil, fmt.Errorf("failed to get logs: unknown sort field %q", field)
+	}
+	sort.SliceStable(logs, func(i, j int) bool {
+		c := cmp(&logs[i], &logs[j])
+		if c == 0 {
+			c = compareUint64(uint64(logs[i].ID), uint64(logs[j].ID))
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	// 应用分页
+	total := len(logs)
+	start, end := paginate(total, query.Page, query.PageSize)
+
+	return &domain.PaginatedClickLogs{
+		Total:       int64(total),
+		TotalPages:  (total + query.PageSize - 1) / query.PageSize,
+		CurrentPage: query.Page,
+		PageSize:    query.PageSize,
+		Data:        logs[start:end],
+	}, nil
+}
+
+// CampaignStats 按UTM活动统计点击数
+func (r *MemoryShortLinkRepository) CampaignStats(_ context.Context, query *domain.CampaignStatsQuery) ([]domain.CampaignStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	owners := make(map[uint]*domain.ShortLink, len(r.links))
+	for _, link := range r.links {
+		owners[link.ID] = link
+	}
+
+	byCampaign := make(map[string]*domain.CampaignStats)
+	links := make(map[string]map[uint]bool)
+	for _, log := range r.clickLogs {
+		link, ok := owners[log.ShortLinkID]
+		if !ok || log.Kind != domain.ClickKindClick {
+			continue
+		}
+		if query.UserID != nil && link.UserID != *query.UserID {
+			continue
+		}
+		if query.WorkspaceID != nil && link.WorkspaceID != *query.WorkspaceID {
+			continue
+		}
+		if !query.Start.IsZero() && log.CreatedAt.Before(query.Start) {
+			continue
+		}
+		if !query.End.IsZero() && !log.CreatedAt.Before(query.End) {
+			continue
+		}
+		stats, ok := byCampaign[log.Campaign]
+		if !ok {
+			stats = &domain.CampaignStats{Campaign: log.Campaign}
+			byCampaign[log.Campaign] = stats
+			links[log.Campaign] = make(map[uint]bool)
+		}
+		stats.Clicks++
+		links[log.Campaign][log.ShortLinkID] = true
+	}
+
+	result := make([]domain.CampaignStats, 0, len(byCampaign))
+	for campaign, stats := range byCampaign {
+		stats.Links = int64(len(links[campaign]))
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Clicks != result[j].Clicks {
+			return result[i].Clicks > result[j].Clicks
+		}
+		return result[i].Campaign < result[j].Campaign
+	})
+	return result, nil
+}
+
+// CreateRule 创建跳转规则
+func (r *MemoryShortLinkRepository) CreateRule(_ context.Context, rule *domain.RedirectRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.nextRuleID++
+	rule.ID = r.nextRuleID
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	stored := copyRule(rule)
+	r.rules[rule.ID] = &stored
+	return nil
+}
+
+// UpdateRule 更新跳转规则
+func (r *MemoryShortLinkRepository) UpdateRule(_ context.Context, rule *domain.RedirectRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.rules[rule.ID]
+	if !ok || stored.ShortLinkID != rule.ShortLinkID {
+		return domain.ErrRuleNotFound
+	}
+
+	updated := copyRule(rule)
+	updated.ShortLinkID = stored.ShortLinkID
+	updated.CreatedAt = stored.CreatedAt
+	updated.UpdatedAt = time.Now()
+	r.rules[rule.ID] = &updated
+	rule.CreatedAt = updated.CreatedAt
+	rule.UpdatedAt = updated.UpdatedAt
+	return nil
+}
+
+// DeleteRule 删除跳转规则
+func (r *MemoryShortLinkRepository) DeleteRule(_ context.Context, shortLinkID uint, ruleID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.rules[ruleID]
+	if !ok || stored.ShortLinkID != shortLinkID {
+		return domain.ErrRuleNotFound
+	}
+	delete(r.rules, ruleID)
+	return nil
+}
+
+// GetRules 获取短链接的所有规则，按优先级降序排列
+func (r *MemoryShortLinkRepository) GetRules(_ context.Context, shortLinkID uint) ([]domain.RedirectRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rules := make([]domain.RedirectRule, 0)
+	for _, rule := range r.rules {
+		if rule.ShortLinkID == shortLinkID {
+			rules = append(rules, copyRule(rule))
+		}
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority > rules[j].Priority
+		}
+		return rules[i].ID < rules[j].ID
+	})
+	return rules, nil
+}
+
+// UpdateRules 批量更新规则
+func (r *MemoryShortLinkRepository) UpdateRules(_ context.Context, shortLinkID uint, rules []domain.RedirectRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 删除现有规则
+	for id, rule := range r.rules {
+		if rule.ShortLinkID == shortLinkID {
+			delete(r.rules, id)
+		}
+	}
+
+	// 插入新规则，未指定时间的规则使用当前时间
+	now := time.Now()
+	for i := range rules {
+		rule := &rules[i]
+		if rule.CreatedAt.IsZero() {
+			rule.CreatedAt = now
+		}
+		if rule.UpdatedAt.IsZero() {
+			rule.UpdatedAt = now
+		}
+		r.nextRuleID++
+		stored := copyRule(rule)
+		stored.ID = r.nextRuleID
+		stored.ShortLinkID = shortLinkID
+		r.rules[stored.ID] = &stored
+	}
+
+	return nil
+}
@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/cache"
+
+	"go.uber.org/zap"
+)
+
+// TestShortLinkRepositoryNotFound 内存仓储与数据库仓储对不存在的短码返回相同的结果
+func TestShortLinkRepositoryNotFound(t *testing.T) {
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	memory := NewMemoryShortLinkRepository()
+	if err := memory.Create(ctx, &domain.ShortLink{ShortCode: "abc", LongURL: "https://example.com", ExpiresAt: expiresAt}, 0); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	fake, db := newFakeDB(t)
+	fake.insert(map[string]driver.Value{
+		"id":               int64(1),
+		"short_code":       "abc",
+		"long_url":         "https://example.com",
+		"clicks":           int64(0),
+		"expires_at":       expiresAt,
+		"default_redirect": int64(0),
+	})
+	c := cache.NewMemoryCache(0)
+	sqlRepo := NewShortLinkRepository(db, c, NewClickCounter(db, c, time.Hour, 10, zap.NewNop()), nil, zap.NewNop())
+
+	repos := []struct {
+		name string
+		repo domain.ShortLinkRepository
+	}{
+		{"memory", memory},
+		{"sql", sqlRepo},
+	}
+	for _, r := range repos {
+		t.Run(r.name, func(t *testing.T) {
+			link, err := r.repo.GetByCode(ctx, "abc")
+			if err != nil {
+				t.Fatalf("GetByCode(abc) error = %v", err)
+			}
+			if link.DefaultRedirect != domain.RedirectPermanent {
+				t.Errorf("GetByCode(abc) default redirect = %d, want %d", link.DefaultRedirect, domain.RedirectPermanent)
+			}
+
+			// 用例层直接比较错误值，仓储不能包装 ErrShortLinkNotFound
+			for i := 0; i < 2; i++ {
+				if _, err := r.repo.GetByCode(ctx, "missing"); err != domain.ErrShortLinkNotFound {
+					t.Errorf("GetByCode(missing) error = %v, want ErrShortLinkNotFound", err)
+				}
+			}
+
+			if err := r.repo.Delete(ctx, "missing"); err != nil {
+				t.Errorf("Delete(missing) error = %v, want nil", err)
+			}
+			if err := r.repo.Delete(ctx, "abc"); err != nil {
+				t.Fatalf("Delete(abc) error = %v", err)
+			}
+			if _, err := r.repo.GetByCode(ctx, "abc"); err != domain.ErrShortLinkNotFound {
+				t.Errorf("GetByCode after Delete error = %v, want ErrShortLinkNotFound", err)
+			}
+		})
+	}
+}
+
+// TestMemoryShortLinkRepositoryListClickLogs 期望结果按 ShortLinkRepository.ListClickLogs 的SQL语义给出：
+// 时间范围包含两端，其余条件为等值匹配，默认按创建时间降序，按 OFFSET/LIMIT 分页
+func TestMemoryShortLinkRepositoryListClickLogs(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryShortLinkRepository()
+
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ruleA, ruleB := uint(1), uint(2)
+	logs := []domain.ClickLog{
+		{ShortLinkID: 1, IP: "10.0.0.1", Country: "CN", Device: domain.DeviceMobile, Campaign: "spring"},
+		{ShortLinkID: 1, IP: "10.0.0.2", Country: "US", Device: domain.DeviceDesktop, RuleID: &ruleA, Campaign: "spring"},
+		{ShortLinkID: 1, IP: "10.0.0.1", Country: "US", Device: domain.DeviceMobile, RuleID: &ruleA, Kind: domain.ClickKindPreview},
+		{ShortLinkID: 1, IP: "10.0.0.3", Country: "CN", Device: domain.DeviceTablet, RuleID: &ruleB, Campaign: "summer"},
+		{ShortLinkID: 1, IP: "10.0.0.2", Country: "JP", Device: domain.DeviceDesktop},
+		{ShortLinkID: 2, IP: "10.0.0.1", Country: "CN", Device: domain.DeviceMobile, Campaign: "spring"},
+	}
+	for i := range logs {
+		logs[i].CreatedAt = t0.Add(time.Duration(i) * time.Minute)
+		if err := repo.LogClick(ctx, &logs[i]); err != nil {
+			t.Fatalf("LogClick() error = %v", err)
+		}
+	}
+
+	ptr := func(s string) *string { return &s }
+	at := func(minutes int) *time.Time {
+		v := t0.Add(time.Duration(minutes) * time.Minute)
+		return &v
+	}
+	device := func(d domain.DeviceType) *domain.DeviceType { return &d }
+	kind := func(k domain.ClickKind) *domain.ClickKind { return &k }
+
+	tests := []struct {
+		name       string
+		linkID     uint
+		query      domain.ClickLogQuery
+		wantIDs    []uint
+		wantTotal  int64
+		wantPages  int
+		wantErrors bool
+	}{
+		{name: "default order", linkID: 1, query: domain.ClickLogQuery{Page: 1, PageSize: 10},
+			wantIDs: []uint{5, 4, 3, 2, 1}, wantTotal: 5, wantPages: 1},
+		{name: "time range is inclusive", linkID: 1, query: domain.ClickLogQuery{Page: 1, PageSize: 10,
+			Filter: &domain.ClickLogFilter{StartTime: at(1), EndTime: at(3)}},
+			wantIDs: []uint{4, 3, 2}, wantTotal: 3, wantPages: 1},
+		{name: "ip", linkID: 1, query: domain.ClickLogQuery{Page: 1, PageSize: 10,
+			Filter: &domain.ClickLogFilter{IP: ptr("10.0.0.1")}},
+			wantIDs: []uint{3, 1}, wantTotal: 2, wantPages: 1},
+		{name: "country", linkID: 1, query: domain.ClickLogQuery{Page: 1, PageSize: 10,
+			Filter: &domain.ClickLogFilter{Country: ptr("US")}},
+			wantIDs: []uint{3, 2}, wantTotal: 2, wantPages: 1},
+		{name: "device", linkID: 1, query: domain.ClickLogQuery{Page: 1, PageSize: 10,
+			Filter: &domain.ClickLogFilter{Device: device(domain.DeviceMobile)}},
+			wantIDs: []uint{3, 1}, wantTotal: 2, wantPages: 1},
+		{name: "rule", linkID: 1, query: domain.ClickLogQuery{Page: 1, PageSize: 10,
+			Filter: &domain.ClickLogFilter{RuleID: &ruleA}},
+			wantIDs: []uint{3, 2}, wantTotal: 2, wantPages: 1},
+		{name: "kind", linkID: 1, query: domain.ClickLogQuery{Page: 1, PageSize: 10,
+			Filter: &domain.ClickLogFilter{Kind: kind(domain.ClickKindPreview)}},
+			wantIDs: []uint{3}, wantTotal: 1, wantPages: 1},
+		{name: "campaign", linkID: 1, query: domain.ClickLogQuery{Page: 1, PageSize: 10,
+			Filter: &domain.ClickLogFilter{Campaign: ptr("spring")}},
+			wantIDs: []uint{2, 1}, wantTotal: 2, wantPages: 1},
+		{name: "empty campaign", linkID: 1, query: domain.ClickLogQuery{Page: 1, PageSize: 10,
+			Filter: &domain.ClickLogFilter{Campaign: ptr("")}},
+			wantIDs: []uint{5, 3}, wantTotal: 2, wantPages: 1},
+		{name: "combined filters", linkID: 1, query: domain.ClickLogQuery{Page: 1, PageSize: 10,
+			Filter: &domain.ClickLogFilter{Country: ptr("CN"), Kind: kind(domain.ClickKindClick)}},
+			wantIDs: []uint{4, 1}, wantTotal: 2, wantPages: 1},
+		{name: "sort ascending", linkID: 1, query: domain.ClickLogQuery{Page: 1, PageSize: 10,
+			Sort: &domain.ClickLogSort{Field: "created_at", Direction: domain.SortAsc}},
+			wantIDs: []uint{1, 2, 3, 4, 5}, wantTotal: 5, wantPages: 1},
+		{name: "sort by id descending", linkID: 1, query: domain.ClickLogQuery{Page: 1, PageSize: 10,
+			Sort: &domain.ClickLogSort{Field: "id", Direction: domain.SortDesc}},
+			wantIDs: []uint{5, 4, 3, 2, 1}, wantTotal: 5, wantPages: 1},
+		{name: "sort by country", linkID: 1, query: domain.ClickLogQuery{Page: 1, PageSize: 10,
+			Filter: &domain.ClickLogFilter{IP: ptr("10.0.0.2")},
+			Sort:   &domain.ClickLogSort{Field: "country", Direction: domain.SortAsc}},
+			wantIDs: []uint{5, 2}, wantTotal: 2, wantPages: 1},
+		{name: "second page", linkID: 1, query: domain.ClickLogQuery{Page: 2, PageSize: 2},
+			wantIDs: []uint{3, 2}, wantTotal: 5, wantPages: 3},
+		{name: "last partial page", linkID: 1, query: domain.ClickLogQuery{Page: 3, PageSize: 2},
+			wantIDs: []uint{1}, wantTotal: 5, wantPages: 3},
+		{name: "page past the end", linkID: 1, query: domain.ClickLogQuery{Page: 4, PageSize: 2},
+			wantIDs: []uint{}, wantTotal: 5, wantPages: 3},
+		{name: "other link", linkID: 3, query: domain.ClickLogQuery{Page: 1, PageSize: 10},
+			wantIDs: []uint{}, wantTotal: 0, wantPages: 0},
+		{name: "unknown sort field", linkID: 1, query: domain.ClickLogQuery{Page: 1, PageSize: 10,
+			Sort: &domain.ClickLogSort{Field: "user_agent; DROP TABLE click_logs"}},
+			wantErrors: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.ListClickLogs(ctx, tt.linkID, &tt.query)
+			if tt.wantErrors {
+				if err == nil {
+					t.Fatal("ListClickLogs() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListClickLogs() error = %v", err)
+			}
+			if got.Total != tt.wantTotal || got.TotalPages != tt.wantPages {
+				t.Errorf("total = %d, pages = %d; want %d, %d", got.Total, got.TotalPages, tt.wantTotal, tt.wantPages)
+			}
+			if got.CurrentPage != tt.query.Page || got.PageSize != tt.query.PageSize {
+				t.Errorf("page = %d, size = %d; want %d, %d", got.CurrentPage, got.PageSize, tt.query.Page, tt.query.PageSize)
+			}
+			ids := make([]uint, 0, len(got.Data))
+			for _, log := range got.Data {
+				ids = append(ids, log.ID)
+			}
+			if len(ids) != len(tt.wantIDs) {
+				t.Fatalf("ids = %v, want %v", ids, tt.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Fatalf("ids = %v, want %v", ids, tt.wantIDs)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryShortLinkRepositoryRuleNotFound(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryShortLinkRepository()
+
+	rule := &domain.RedirectRule{ShortLinkID: 1, Name: "mobile", Type: domain.RedirectTemporary}
+	if err := repo.CreateRule(ctx, rule); err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+
+	// 规则属于其他短链接时与不存在的规则一样处理
+	if err := repo.UpdateRule(ctx, &domain.RedirectRule{ID: rule.ID, ShortLinkID: 2}); err != domain.ErrRuleNotFound {
+		t.Errorf("UpdateRule() on other link error = %v, want ErrRuleNotFound", err)
+	}
+	if err := repo.DeleteRule(ctx, 2, rule.ID); err != domain.ErrRuleNotFound {
+		t.Errorf("DeleteRule() on other link error = %v, want ErrRuleNotFound", err)
+	}
+	if err := repo.DeleteRule(ctx, 1, rule.ID); err != nil {
+		t.Fatalf("DeleteRule() error = %v", err)
+	}
+	if err := repo.DeleteRule(ctx, 1, rule.ID); err != domain.ErrRuleNotFound {
+		t.Errorf("DeleteRule() twice error = %v, want ErrRuleNotFound", err)
+	}
+}
+
+// TestShortLinkRepositoryUpdateRulesTimestamps 批量替换规则时两种仓储都为未指定时间的规则写入当前时间，并保留调用方指定的时间
+func TestShortLinkRepositoryUpdateRulesTimestamps(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	fake, db := newFakeDB(t)
+	c := cache.NewMemoryCache(0)
+	sqlRepo := NewShortLinkRepository(db, c, NewClickCounter(db, c, time.Hour, 10, zap.NewNop()), nil, zap.NewNop())
+
+	repos := []struct {
+		name   string
+		repo   domain.ShortLinkRepository
+		stored func() [][2]time.Time
+	}{
+		{"memory", NewMemoryShortLinkRepository(), nil},
+		{"sql", sqlRepo, func() [][2]time.Time {
+			var stored [][2]time.Time
+			for _, args := range fake.insertedRules() {
+				stored = append(stored, [2]time.Time{args[len(args)-2].(time.Time), args[len(args)-1].(time.Time)})
+			}
+			return stored
+		}},
+	}
+	for _, r := range repos {
+		t.Run(r.name, func(t *testing.T) {
+			before := time.Now()
+			rules := []domain.RedirectRule{
+				{Name: "new", Priority: 2, Type: domain.RedirectTemporary},
+				{Name: "kept", Priority: 1, Type: domain.RedirectTemporary, CreatedAt: created, UpdatedAt: created},
+			}
+			if err := r.repo.UpdateRules(ctx, 1, rules); err != nil {
+				t.Fatalf("UpdateRules() error = %v", err)
+			}
+
+			if rules[0].CreatedAt.Before(before) || rules[0].UpdatedAt.Before(before) {
+				t.Errorf("rules[0] times = %v, %v; want current time", rules[0].CreatedAt, rules[0].UpdatedAt)
+			}
+			if !rules[1].CreatedAt.Equal(created) || !rules[1].UpdatedAt.Equal(created) {
+				t.Errorf("rules[1] times = %v, %v; want %v", rules[1].CreatedAt, rules[1].UpdatedAt, created)
+			}
+
+			// 仓储中保存的时间与写回调用方的时间一致
+			var stored [][2]time.Time
+			if r.stored != nil {
+				stored = r.stored()
+			} else {
+				got, err := r.repo.GetRules(ctx, 1)
+				if err != nil {
+					t.Fatalf("GetRules() error = %v", err)
+				}
+				for _, rule := range got {
+					stored = append(stored, [2]time.Time{rule.CreatedAt, rule.UpdatedAt})
+				}
+			}
+			if len(stored) != len(rules) {
+				t.Fatalf("stored %d rules, want %d", len(stored), len(rules))
+			}
+			for i := range rules {
+				if !stored[i][0].Equal(rules[i].CreatedAt) || !stored[i][1].Equal(rules[i].UpdatedAt) {
+					t.Errorf("stored rule %d times = %v, want %v, %v", i, stored[i], rules[i].CreatedAt, rules[i].UpdatedAt)
+				}
+			}
+		})
+	}
+}
+
+// TestShortLinkRepositoryUnknownSortField 两种仓储按同一列表校验排序字段，不在列表中的字段都返回错误
+func TestShortLinkRepositoryUnknownSortField(t *testing.T) {
+	ctx := context.Background()
+	_, db := newFakeDB(t)
+	c := cache.NewMemoryCache(0)
+	sqlRepo := NewShortLinkRepository(db, c, NewClickCounter(db, c, time.Hour, 10, zap.NewNop()), nil, zap.NewNop())
+
+	repos := []struct {
+		name string
+		repo domain.ShortLinkRepository
+	}{
+		{"memory", NewMemoryShortLinkRepository()},
+		{"sql", sqlRepo},
+	}
+	for _, r := range repos {
+		t.Run(r.name, func(t *testing.T) {
+			if _, err := r.repo.List(ctx, &domain.PaginationQuery{Page: 1, PageSize: 10,
+				Sort: &domain.ShortLinkSort{Field: "long_url; DROP TABLE short_links"}}); err == nil {
+				t.Error("List() error = nil, want unknown sort field error")
+			}
+			if _, err := r.repo.ListClickLogs(ctx, 1, &domain.ClickLogQuery{Page: 1, PageSize: 10,
+				Sort: &domain.ClickLogSort{Field: "(SELECT 1)"}}); err == nil {
+				t.Error("ListClickLogs() error = nil, want unknown sort field error")
+			}
+		})
+	}
+}
@@ -3,30 +3,65 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"linkit/internal/domain"
+	"linkit/internal/infrastructure/cache"
+	"linkit/internal/infrastructure/logger"
+	"linkit/internal/infrastructure/metrics"
 
-	"github.com/go-redis/redis/v8"
 	"github.com/lib/pq"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 // ShortLinkRepository 实现短链接仓储接口
 type ShortLinkRepository struct {
-	db    *gorm.DB
-	redis *redis.Client
+	db      *gorm.DB
+	cache   cache.Cache
+	counter *ClickCounter
+	writer  ClickLogSink
+	logger  *zap.Logger
 }
 
 // NewShortLinkRepository 创建短链接仓储实例
-func NewShortLinkRepository(db *gorm.DB, redis *redis.Client) domain.ShortLinkRepository {
+func NewShortLinkRepository(db *gorm.DB, cache cache.Cache, counter *ClickCounter, writer ClickLogSink, logger *zap.Logger) domain.ShortLinkRepository {
 	return &ShortLinkRepository{
-		db:    db,
-		redis: redis,
+		db:      db,
+		cache:   cache,
+		counter: counter,
+		writer:  writer,
+		logger:  logger.Named("repository"),
 	}
 }
 
+// log 返回带有请求ID的日志实例
+func (r *ShortLinkRepository) log(ctx context.Context) *zap.Logger {
+	return logger.FromContext(ctx, r.logger)
+}
+
+// cachedLink 短链接缓存数据结构
+type cachedLink struct {
+	ID               uint            `json:"id"`
+	LongURL          string          `json:"long_url"`
+	UserID           uint            `json:"user_id"`
+	WorkspaceID      uint            `json:"workspace_id"`
+	ExpiresAt        time.Time       `json:"expires_at"`
+	Clicks           uint64          `json:"clicks"`
+	MaxVisits        *uint64         `json:"max_visits"`
+	DefaultRedirect  uint            `json:"default_redirect"`
+	NeverExpire      bool            `json:"never_expire"`
+	PasswordHash     string          `json:"password_hash,omitempty"`
+	QueryPassthrough string          `json:"query_passthrough,omitempty"`
+	UTM              domain.UTM      `json:"utm"`
+	AppLinks         domain.AppLinks `json:"app_links"`
+	PathForwarding   bool            `json:"path_forwarding,omitempty"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
 // getCacheKey 获取缓存键
 func (r *ShortLinkRepository) getCacheKey(code string) string {
 	return fmt.Sprintf("link:%s", code)
@@ -41,26 +76,23 @@ func (r *ShortLinkRepository) setCache(ctx context.Context, link *domain.ShortLi
 	}
 
 	// 创建缓存数据结构
-	cacheData := struct {
-		ID              uint      `json:"id"`
-		LongURL         string    `json:"long_url"`
-		ExpiresAt       time.Time `json:"expires_at"`
-		Clicks          uint64    `json:"clicks"`
-		MaxVisits       *uint64   `json:"max_visits"`
-		DefaultRedirect uint      `json:"default_redirect"`
-		NeverExpire     bool      `json:"never_expire"`
-		CreatedAt       time.Time `json:"created_at"`
-		UpdatedAt       time.Time `json:"updated_at"`
-	}{
-		ID:              link.ID,
-		LongURL:         link.LongURL,
-		ExpiresAt:       link.ExpiresAt,
-		Clicks:          link.Clicks,
-		MaxVisits:       link.MaxVisits,
-		DefaultRedirect: uint(link.DefaultRedirect),
-		NeverExpire:     link.NeverExpire,
-		CreatedAt:       link.CreatedAt,
-		UpdatedAt:       link.UpdatedAt,
+	cacheData := cachedLink{
+		ID:               link.ID,
+		LongURL:          link.LongURL,
+		UserID:           link.UserID,
+		WorkspaceID:      link.WorkspaceID,
+		ExpiresAt:        link.ExpiresAt,
+		Clicks:           link.Clicks,
+		MaxVisits:        link.MaxVisits,
+		DefaultRedirect:  uint(link.DefaultRedirect),
+		NeverExpire:      link.NeverExpire,
+		PasswordHash:     link.PasswordHash,
+		QueryPassthrough: string(link.QueryPassthrough),
+		UTM:              link.UTM,
+		AppLinks:         link.AppLinks,
+		PathForwarding:   link.PathForwarding,
+		CreatedAt:        link.CreatedAt,
+		UpdatedAt:        link.UpdatedAt,
 	}
 
 	// 序列化数据
@@ -70,24 +102,25 @@ func (r *ShortLinkRepository) setCache(ctx context.Context, link *domain.ShortLi
 	}
 
 	// 设置缓存，过期时间与短链接一致
-	return r.redis.Set(ctx, r.getCacheKey(link.ShortCode), string(data), expiration).Err()
+	return r.cache.Set(ctx, r.getCacheKey(link.ShortCode), string(data), expiration)
 }
 
-// Create 创建短链接
-func (r *ShortLinkRepository) Create(link *domain.ShortLink) error {
+// Create 创建短链接，maxActive 大于0时在同一事务中检查有效短链接数配额
+func (r *ShortLinkRepository) Create(ctx context.Context, link *domain.ShortLink, maxActive int64) error {
 	// 使用事务
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		fmt.Printf("Creating short link: %s -> %s\n", link.ShortCode, link.LongURL)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkActiveLinks(tx, linkUsageOwner(link), maxActive); err != nil {
+			return err
+		}
 		if err := tx.Table("short_links").Create(link).Error; err != nil {
-			fmt.Printf("Failed to create short link: %v\n", err)
 			return fmt.Errorf("failed to create short link: %w", err)
 		}
-		fmt.Printf("Short link created successfully: %s\n", link.ShortCode)
+		r.log(ctx).Info("short link created", zap.String("code", link.ShortCode), zap.Uint("id", link.ID))
 
 		// 设置缓存
-		if err := r.setCache(context.Background(), link); err != nil {
+		if err := r.setCache(ctx, link); err != nil {
 			// 缓存错误只记录，不影响事务
-			fmt.Printf("Failed to set cache: %v\n", err)
+			r.log(ctx).Warn("failed to set cache", zap.String("code", link.ShortCode), zap.Error(err))
 		}
 
 		return nil
@@ -95,67 +128,62 @@ func (r *ShortLinkRepository) Create(link *domain.ShortLink) error {
 }
 
 // GetByCode 根据短码获取短链接
-func (r *ShortLinkRepository) GetByCode(code string) (*domain.ShortLink, error) {
-	ctx := context.Background()
+func (r *ShortLinkRepository) GetByCode(ctx context.Context, code string) (*domain.ShortLink, error) {
+	started := time.Now()
 	cacheKey := r.getCacheKey(code)
 
-	fmt.Printf("[GetByCode] Starting to get short link for code: %s\n", code)
-
-	// 先从Redis缓存中获取
-	if data, err := r.redis.Get(ctx, cacheKey).Result(); err == nil && data != "" {
-		fmt.Printf("[GetByCode] Found in cache: %s, data: %s\n", code, data)
-
+	// 先从缓存中获取
+	if data, err := r.cache.Get(ctx, cacheKey); err == nil && data != "" {
 		// 解析缓存数据
-		var cacheData struct {
-			ID              uint      `json:"id"`
-			LongURL         string    `json:"long_url"`
-			ExpiresAt       time.Time `json:"expires_at"`
-			Clicks          uint64    `json:"clicks"`
-			MaxVisits       *uint64   `json:"max_visits"`
-			DefaultRedirect uint      `json:"default_redirect"`
-			NeverExpire     bool      `json:"never_expire"`
-			CreatedAt       time.Time `json:"created_at"`
-			UpdatedAt       time.Time `json:"updated_at"`
-		}
+		var cacheData cachedLink
 		if err := json.Unmarshal([]byte(data), &cacheData); err != nil {
-			fmt.Printf("[GetByCode] Failed to unmarshal cache data: %v\n", err)
+			r.log(ctx).Warn("failed to unmarshal cached link", zap.String("code", code), zap.Error(err))
 		} else {
-			fmt.Printf("[GetByCode] Cache data parsed successfully: %+v\n", cacheData)
+			metrics.ObserveCache(metrics.CacheGetByCode, true)
+			r.log(ctx).Debug("get short link",
+				zap.String("code", code),
+				zap.Bool("cache_hit", true),
+				zap.Duration("latency", time.Since(started)))
 			return &domain.ShortLink{
-				ID:              cacheData.ID,
-				ShortCode:       code,
-				LongURL:         cacheData.LongURL,
-				ExpiresAt:       cacheData.ExpiresAt,
-				Clicks:          cacheData.Clicks,
-				MaxVisits:       cacheData.MaxVisits,
-				DefaultRedirect: domain.RedirectType(cacheData.DefaultRedirect),
-				NeverExpire:     cacheData.NeverExpire,
-				CreatedAt:       cacheData.CreatedAt,
-				UpdatedAt:       cacheData.UpdatedAt,
+				ID:               cacheData.ID,
+				ShortCode:        code,
+				LongURL:          cacheData.LongURL,
+				UserID:           cacheData.UserID,
+				WorkspaceID:      cacheData.WorkspaceID,
+				ExpiresAt:        cacheData.ExpiresAt,
+				Clicks:           cacheData.Clicks,
+				MaxVisits:        cacheData.MaxVisits,
+				DefaultRedirect:  domain.RedirectType(cacheData.DefaultRedirect),
+				NeverExpire:      cacheData.NeverExpire,
+				PasswordHash:     cacheData.PasswordHash,
+				QueryPassthrough: domain.QueryPassthrough(cacheData.QueryPassthrough),
+				UTM:              cacheData.UTM,
+				AppLinks:         cacheData.AppLinks,
+				PathForwarding:   cacheData.PathForwarding,
+				CreatedAt:        cacheData.CreatedAt,
+				UpdatedAt:        cacheData.UpdatedAt,
 			}, nil
 		}
-	} else {
-		fmt.Printf("[GetByCode] Cache miss for code: %s, error: %v\n", code, err)
+	} else if err != nil && !errors.Is(err, cache.ErrCacheMiss) {
+		r.log(ctx).Warn("failed to get cached link", zap.String("code", code), zap.Error(err))
 	}
 
 	// 从数据库中获取
+	metrics.ObserveCache(metrics.CacheGetByCode, false)
 	var link domain.ShortLink
-	fmt.Printf("[GetByCode] Starting database query for code: %s\n", code)
 
-	err := r.db.Table("short_links").
-		Select("id, short_code, long_url, user_id, clicks, max_visits, expires_at, never_expire, default_redirect, created_at, updated_at").
+	err := r.db.WithContext(ctx).Table("short_links").
+		Select("id, short_code, long_url, user_id, workspace_id, clicks, max_visits, expires_at, never_expire, default_redirect, password_hash, query_passthrough, utm_source, utm_medium, utm_campaign, utm_term, utm_content, app_ios_url, app_ios_fallback_url, app_android_url, app_android_fallback_url, path_forwarding, created_at, updated_at").
 		Where("short_code = ?", code).
 		First(&link).Error
 
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			fmt.Printf("[GetByCode] No record found in database for code: %s\n", code)
 			// 设置空值缓存，防止缓存穿透
-			r.redis.Set(ctx, cacheKey, "", 5*time.Minute)
+			r.cache.Set(ctx, cacheKey, "", 5*time.Minute)
 			return nil, domain.ErrShortLinkNotFound
 		}
-		fmt.Printf("[GetByCode] Database error for code %s: %v\n", code, err)
-		return nil, domain.ErrShortLinkNotFound
+		return nil, fmt.Errorf("failed to get short link: %w", err)
 	}
 
 	// 如果default_redirect为0,设置为默认值1
@@ -163,28 +191,39 @@ func (r *ShortLinkRepository) GetByCode(code string) (*domain.ShortLink, error)
 		link.DefaultRedirect = domain.RedirectPermanent
 	}
 
-	fmt.Printf("[GetByCode] Found in database: %+v\n", link)
+	// 加上尚未同步到数据库的点击数
+	if pending, err := r.counter.Pending(ctx, code); err == nil && pending > 0 {
+		link.Clicks += uint64(pending)
+	}
 
 	// 设置缓存
 	if err := r.setCache(ctx, &link); err != nil {
-		fmt.Printf("[GetByCode] Failed to set cache for code %s: %v\n", code, err)
-	} else {
-		fmt.Printf("[GetByCode] Cache set successfully for code: %s\n", code)
+		r.log(ctx).Warn("failed to set cache", zap.String("code", code), zap.Error(err))
 	}
 
+	r.log(ctx).Debug("get short link",
+		zap.String("code", code),
+		zap.Bool("cache_hit", false),
+		zap.Duration("latency", time.Since(started)))
+
 	return &link, nil
 }
 
 // Update 更新短链接
-func (r *ShortLinkRepository) Update(link *domain.ShortLink) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Table("short_links").Save(link).Error; err != nil {
+// 点击数只由点击计数器累加，读取到的 Clicks 包含尚未同步的点击数，写回会导致下一次同步重复计数
+// maxActive 大于0时在同一事务中检查有效短链接数配额，用于已过期的短链接重新生效
+func (r *ShortLinkRepository) Update(ctx context.Context, link *domain.ShortLink, maxActive int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkActiveLinks(tx, linkUsageOwner(link), maxActive); err != nil {
+			return err
+		}
+		if err := tx.Table("short_links").Omit("clicks").Save(link).Error; err != nil {
 			return fmt.Errorf("failed to update short link: %w", err)
 		}
 
 		// 更新缓存
-		if err := r.setCache(context.Background(), link); err != nil {
-			fmt.Printf("failed to update cache: %v\n", err)
+		if err := r.setCache(ctx, link); err != nil {
+			r.log(ctx).Warn("failed to update cache", zap.String("code", link.ShortCode), zap.Error(err))
 		}
 
 		return nil
@@ -192,20 +231,17 @@ func (r *ShortLinkRepository) Update(link *domain.ShortLink) error {
 }
 
 // Delete 删除短链接
-func (r *ShortLinkRepository) Delete(code string) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		fmt.Printf("Deleting short link: %s\n", code)
+func (r *ShortLinkRepository) Delete(ctx context.Context, code string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 删除数据库记录
 		if err := tx.Table("short_links").Where("short_code = ?", code).Delete(&domain.ShortLink{}).Error; err != nil {
-			fmt.Printf("Failed to delete short link: %v\n", err)
 			return fmt.Errorf("failed to delete short link: %w", err)
 		}
-		fmt.Printf("Short link deleted successfully: %s\n", code)
+		r.log(ctx).Info("short link deleted", zap.String("code", code))
 
 		// 删除缓存
-		ctx := context.Background()
-		if err := r.redis.Del(ctx, r.getCacheKey(code)).Err(); err != nil {
-			fmt.Printf("Failed to delete cache: %v\n", err)
+		if err := r.cache.Del(ctx, r.getCacheKey(code)); err != nil {
+			r.log(ctx).Warn("failed to delete cache", zap.String("code", code), zap.Error(err))
 		}
 
 		return nil
@@ -224,21 +260,29 @@ func (r *ShortLinkRepository) setRulesCache(ctx context.Context, shortLinkID uin
 		return err
 	}
 	// 缓存规则,过期时间5分钟
-	return r.redis.Set(ctx, r.getRulesCacheKey(shortLinkID), string(data), 5*time.Minute).Err()
+	return r.cache.Set(ctx, r.getRulesCacheKey(shortLinkID), string(data), 5*time.Minute)
+}
+
+// delRulesCache 删除规则缓存，规则变更后调用
+func (r *ShortLinkRepository) delRulesCache(ctx context.Context, shortLinkID uint) {
+	if err := r.cache.Del(ctx, r.getRulesCacheKey(shortLinkID)); err != nil {
+		r.log(ctx).Warn("failed to delete rules cache", zap.Uint("short_link_id", shortLinkID), zap.Error(err))
+	}
 }
 
 // GetRules 获取短链接的所有规则
-func (r *ShortLinkRepository) GetRules(shortLinkID uint) ([]domain.RedirectRule, error) {
-	ctx := context.Background()
+func (r *ShortLinkRepository) GetRules(ctx context.Context, shortLinkID uint) ([]domain.RedirectRule, error) {
 	cacheKey := r.getRulesCacheKey(shortLinkID)
 
 	// 尝试从缓存获取
-	if data, err := r.redis.Get(ctx, cacheKey).Result(); err == nil {
+	if data, err := r.cache.Get(ctx, cacheKey); err == nil {
 		var rules []domain.RedirectRule
 		if err := json.Unmarshal([]byte(data), &rules); err == nil {
+			metrics.ObserveCache(metrics.CacheGetRules, true)
 			return rules, nil
 		}
 	}
+	metrics.ObserveCache(metrics.CacheGetRules, false)
 
 	// 缓存未命中,从数据库查询
 	var rules []domain.RedirectRule
@@ -260,6 +304,7 @@ func (r *ShortLinkRepository) GetRules(shortLinkID uint) ([]domain.RedirectRule,
 		Cities      pq.StringArray `gorm:"column:cities;type:text[]"`
 		Percentage  *int           `gorm:"column:percentage"`
 		MaxVisits   *int           `gorm:"column:max_visits"`
+		UTM         domain.UTM     `gorm:"embedded;embeddedPrefix:utm_"`
 		CreatedAt   time.Time      `gorm:"column:created_at"`
 		UpdatedAt   time.Time      `gorm:"column:updated_at"`
 	}
@@ -269,12 +314,13 @@ func (r *ShortLinkRepository) GetRules(shortLinkID uint) ([]domain.RedirectRule,
 	sql := `
 		SELECT id, short_link_id, name, description, priority, type, target_url,
 			device, start_time, end_time, countries, provinces, cities,
-			percentage, max_visits, created_at, updated_at
+			percentage, max_visits, utm_source, utm_medium, utm_campaign, utm_term,
+			utm_content, created_at, updated_at
 		FROM redirect_rules 
 		WHERE short_link_id = ?
 		ORDER BY priority DESC`
 
-	if err := r.db.Raw(sql, shortLinkID).Scan(&tempRules).Error; err != nil {
+	if err := r.db.WithContext(ctx).Raw(sql, shortLinkID).Scan(&tempRules).Error; err != nil {
 		return nil, fmt.Errorf("failed to get rules: %w", err)
 	}
 
@@ -297,236 +343,168 @@ func (r *ShortLinkRepository) GetRules(shortLinkID uint) ([]domain.RedirectRule,
 			Cities:      []string(tr.Cities),
 			Percentage:  tr.Percentage,
 			MaxVisits:   tr.MaxVisits,
+			UTM:         tr.UTM,
 			CreatedAt:   tr.CreatedAt,
 			UpdatedAt:   tr.UpdatedAt,
 		}
 	}
 
-	// 设置缓存
-	go r.setRulesCache(ctx, shortLinkID, rules)
+	// 设置缓存，不随请求结束而取消
+	go r.setRulesCache(context.WithoutCancel(ctx), shortLinkID, rules)
 
 	return rules, nil
 }
 
-// IncrementClicks 增加点击次数(异步)
-func (r *ShortLinkRepository) IncrementClicks(code string) error {
-	// 使用Redis原子递增
-	key := fmt.Sprintf("clicks:%s", code)
-	syncKey := fmt.Sprintf("clicks_sync:%s", code)
+// IncrementClicks 增加点击次数
+// 点击数累加到缓存计数器，由 ClickCounter 后台批量同步到数据库
+func (r *ShortLinkRepository) IncrementClicks(ctx context.Context, code string) error {
 	cacheKey := r.getCacheKey(code)
 
-	// 递增Redis计数器
-	if err := r.redis.Incr(context.Background(), key).Err(); err != nil {
+	// 递增计数器
+	if _, err := r.counter.Incr(ctx, code); err != nil {
 		return err
 	}
 
 	// 如果有缓存,也更新缓存中的clicks
-	if data, err := r.redis.Get(context.Background(), cacheKey).Result(); err == nil && data != "" {
-		var cacheData struct {
-			ID              uint      `json:"id"`
-			LongURL         string    `json:"long_url"`
-			ExpiresAt       time.Time `json:"expires_at"`
-			Clicks          uint64    `json:"clicks"`
-			MaxVisits       *uint64   `json:"max_visits"`
-			DefaultRedirect uint      `json:"default_redirect"`
-			CreatedAt       time.Time `json:"created_at"`
-			UpdatedAt       time.Time `json:"updated_at"`
-		}
+	if data, err := r.cache.Get(ctx, cacheKey); err == nil && data != "" {
+		var cacheData cachedLink
 		if err := json.Unmarshal([]byte(data), &cacheData); err == nil {
 			cacheData.Clicks++
 			if newData, err := json.Marshal(cacheData); err == nil {
 				// 使用原有的过期时间
-				ttl := r.redis.TTL(context.Background(), cacheKey).Val()
-				r.redis.Set(context.Background(), cacheKey, string(newData), ttl)
+				r.cache.Set(ctx, cacheKey, string(newData), -1)
 			}
 		}
 	}
 
-	// 异步更新数据库
-	go func() {
-		// 获取同步锁,防止并发更新
-		if !r.redis.SetNX(context.Background(), syncKey, "1", 10*time.Second).Val() {
-			return
-		}
-		defer r.redis.Del(context.Background(), syncKey)
-
-		// 获取当前计数
-		count, err := r.redis.Get(context.Background(), key).Int64()
-		if err != nil {
-			if err != redis.Nil {
-				fmt.Printf("Failed to get click count: %v\n", err)
-			}
-			return
-		}
-
-		// 如果计数大于0,同步到数据库
-		if count > 0 {
-			// 使用事务保证原子性
-			err := r.db.Transaction(func(tx *gorm.DB) error {
-				// 更新数据库
-				if err := tx.Exec("UPDATE short_links SET clicks = clicks + ? WHERE short_code = ?", count, code).Error; err != nil {
-					return err
-				}
-				// 重置计数器
-				if err := r.redis.DecrBy(context.Background(), key, count).Err(); err != nil {
-					return err
-				}
-				return nil
-			})
-
-			if err != nil {
-				fmt.Printf("Failed to sync clicks: %v\n", err)
-				// 出错时等待1秒后重试
-				time.Sleep(time.Second)
-				return
-			}
-		}
-	}()
-
-	// 启动定时同步
-	go func() {
-		ticker := time.NewTicker(60 * time.Second)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			// 获取同步锁
-			if !r.redis.SetNX(context.Background(), syncKey, "1", 10*time.Second).Val() {
-				continue
-			}
-			defer r.redis.Del(context.Background(), syncKey)
-
-			// 获取当前计数
-			count, err := r.redis.Get(context.Background(), key).Int64()
-			if err != nil {
-				if err != redis.Nil {
-					fmt.Printf("Failed to get click count in timer: %v\n", err)
-				}
-				continue
-			}
-
-			// 如果有计数,同步到数据库
-			if count > 0 {
-				err := r.db.Transaction(func(tx *gorm.DB) error {
-					if err := tx.Exec("UPDATE short_links SET clicks = clicks + ? WHERE short_code = ?", count, code).Error; err != nil {
-						return err
-					}
-					if err := r.redis.DecrBy(context.Background(), key, count).Err(); err != nil {
-						return err
-					}
-					return nil
-				})
-
-				if err != nil {
-					fmt.Printf("Failed to sync clicks in timer: %v\n", err)
-				}
-			}
-		}
-	}()
-
 	return nil
 }
 
 // LogClick 记录点击日志(异步)
-func (r *ShortLinkRepository) LogClick(log *domain.ClickLog) error {
-	// 异步写入日志
-	go func() {
-		if err := r.db.Create(log).Error; err != nil {
-			fmt.Printf("Failed to create click log: %v\n", err)
-		}
-	}()
+// 点击日志进入有界队列由 ClickLogWriter 批量写入数据库，或追加到Redis Stream由消费者组写入
+func (r *ShortLinkRepository) LogClick(ctx context.Context, log *domain.ClickLog) error {
+	// 队列已满被丢弃的日志已计入写入器的丢弃计数，不影响跳转
+	if err := r.writer.Enqueue(ctx, log); err != nil && !errors.Is(err, ErrClickLogDropped) {
+		return err
+	}
 	return nil
 }
 
 // CreateRule 创建跳转规则
-func (r *ShortLinkRepository) CreateRule(rule *domain.RedirectRule) error {
-	fmt.Printf("Creating redirect rule for short link ID: %d\n", rule.ShortLinkID)
-
+func (r *ShortLinkRepository) CreateRule(ctx context.Context, rule *domain.RedirectRule) error {
 	// 构建SQL语句
 	sql := `
 		INSERT INTO redirect_rules (
 			short_link_id, name, description, priority, type, target_url,
 			device, start_time, end_time, countries, provinces, cities,
-			percentage, max_visits, created_at, updated_at
+			percentage, max_visits, utm_source, utm_medium, utm_campaign, utm_term,
+			utm_content, created_at, updated_at
 		) VALUES (
 			?, ?, ?, ?, ?, ?,
 			?, ?, ?, ?::text[], ?::text[], ?::text[],
-			?, ?, ?, ?
+			?, ?, ?, ?, ?, ?,
+			?, ?, ?
 		) RETURNING id`
 
 	// 准备参数
 	now := time.Now()
-	err := r.db.Raw(sql,
+	err := r.db.WithContext(ctx).Raw(sql,
 		rule.ShortLinkID, rule.Name, rule.Description, rule.Priority, rule.Type, rule.TargetURL,
 		rule.Device, rule.StartTime, rule.EndTime, pq.Array(rule.Countries), pq.Array(rule.Provinces), pq.Array(rule.Cities),
-		rule.Percentage, rule.MaxVisits, now, now,
+		rule.Percentage, rule.MaxVisits, rule.UTM.Source, rule.UTM.Medium, rule.UTM.Campaign, rule.UTM.Term,
+		rule.UTM.Content, now, now,
 	).Scan(&rule.ID).Error
 
 	if err != nil {
-		fmt.Printf("Failed to create rule: %v\n", err)
 		return fmt.Errorf("failed to create rule: %w", err)
 	}
 
-	fmt.Printf("Rule created successfully with ID: %d\n", rule.ID)
+	r.log(ctx).Info("redirect rule created", zap.Uint("short_link_id", rule.ShortLinkID), zap.Uint("rule_id", rule.ID))
+	r.delRulesCache(ctx, rule.ShortLinkID)
 	return nil
 }
 
 // UpdateRule 更新跳转规则
-func (r *ShortLinkRepository) UpdateRule(rule *domain.RedirectRule) error {
-	fmt.Printf("Updating redirect rule ID: %d\n", rule.ID)
-
+func (r *ShortLinkRepository) UpdateRule(ctx context.Context, rule *domain.RedirectRule) error {
 	// 构建SQL语句
 	sql := `
 		UPDATE redirect_rules SET
 			name = ?, description = ?, priority = ?, type = ?, target_url = ?,
 			device = ?, start_time = ?, end_time = ?, countries = ?::text[],
 			provinces = ?::text[], cities = ?::text[], percentage = ?,
-			max_visits = ?, updated_at = ?
-		WHERE id = ?`
+			max_visits = ?, utm_source = ?, utm_medium = ?, utm_campaign = ?,
+			utm_term = ?, utm_content = ?, updated_at = ?
+		WHERE id = ? AND short_link_id = ?
+		RETURNING created_at, updated_at`
 
 	// 准备参数
 	now := time.Now()
-	err := r.db.Exec(sql,
+	var updated []struct {
+		CreatedAt time.Time `gorm:"column:created_at"`
+		UpdatedAt time.Time `gorm:"column:updated_at"`
+	}
+	err := r.db.WithContext(ctx).Raw(sql,
 		rule.Name, rule.Description, rule.Priority, rule.Type, rule.TargetURL,
 		rule.Device, rule.StartTime, rule.EndTime, pq.Array(rule.Countries),
 		pq.Array(rule.Provinces), pq.Array(rule.Cities), rule.Percentage,
-		rule.MaxVisits, now, rule.ID,
-	).Error
+		rule.MaxVisits, rule.UTM.Source, rule.UTM.Medium, rule.UTM.Campaign,
+		rule.UTM.Term, rule.UTM.Content, now, rule.ID, rule.ShortLinkID,
+	).Scan(&updated).Error
 
 	if err != nil {
-		fmt.Printf("Failed to update rule: %v\n", err)
 		return fmt.Errorf("failed to update rule: %w", err)
 	}
+	if len(updated) == 0 {
+		return domain.ErrRuleNotFound
+	}
+	rule.CreatedAt = updated[0].CreatedAt
+	rule.UpdatedAt = updated[0].UpdatedAt
 
-	fmt.Printf("Rule updated successfully: %d\n", rule.ID)
+	r.log(ctx).Info("redirect rule updated", zap.Uint("short_link_id", rule.ShortLinkID), zap.Uint("rule_id", rule.ID))
+	r.delRulesCache(ctx, rule.ShortLinkID)
 	return nil
 }
 
 // DeleteRule 删除跳转规则
-func (r *ShortLinkRepository) DeleteRule(ruleID uint) error {
-	fmt.Printf("Deleting redirect rule ID: %d\n", ruleID)
-	if err := r.db.Table("redirect_rules").Delete(&domain.RedirectRule{}, ruleID).Error; err != nil {
-		fmt.Printf("Failed to delete rule: %v\n", err)
-		return fmt.Errorf("failed to delete rule: %w", err)
+func (r *ShortLinkRepository) DeleteRule(ctx context.Context, shortLinkID uint, ruleID uint) error {
+	result := r.db.WithContext(ctx).Table("redirect_rules").
+		Where("id = ? AND short_link_id = ?", ruleID, shortLinkID).
+		Delete(&domain.RedirectRule{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete rule: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrRuleNotFound
 	}
-	fmt.Printf("Rule deleted successfully: %d\n", ruleID)
+
+	r.log(ctx).Info("redirect rule deleted", zap.Uint("short_link_id", shortLinkID), zap.Uint("rule_id", ruleID))
+	r.delRulesCache(ctx, shortLinkID)
 	return nil
 }
 
 // List 获取短链接列表
-func (r *ShortLinkRepository) List(query *domain.PaginationQuery) (*domain.PaginatedShortLinks, error) {
-	fmt.Printf("[List] Starting to get short links with query: %+v\n", query)
-
+func (r *ShortLinkRepository) List(ctx context.Context, query *domain.PaginationQuery) (*domain.PaginatedShortLinks, error) {
 	var total int64
 	var links []domain.ShortLink
 
+	// 排序字段与内存仓储一致，不在列表中的字段直接拒绝，避免拼接到 ORDER BY 中
+	if query.Sort != nil && query.Sort.Field != "" {
+		if _, ok := linkSortFields[query.Sort.Field]; !ok {
+			return nil, fmt.Errorf("failed to get links: unknown sort field %q", query.Sort.Field)
+		}
+	}
+
 	// 构建查询
-	db := r.db.Table("short_links")
+	db := r.db.WithContext(ctx).Table("short_links")
 
 	// 应用过滤条件
 	if query.Filter != nil {
 		if query.Filter.UserID != nil {
 			db = db.Where("user_id = ?", *query.Filter.UserID)
 		}
+		if query.Filter.WorkspaceID != nil {
+			db = db.Where("workspace_id = ?", *query.Filter.WorkspaceID)
+		}
 		if query.Filter.IsExpired != nil {
 			if *query.Filter.IsExpired {
 				db = db.Where("expires_at < ?", time.Now())
@@ -546,11 +524,13 @@ func (r *ShortLinkRepository) List(query *domain.PaginationQuery) (*domain.Pagin
 		if query.Filter.MaxClicks != nil {
 			db = db.Where("clicks <= ?", query.Filter.MaxClicks)
 		}
+		if query.Filter.Campaign != nil {
+			db = db.Where("utm_campaign = ?", *query.Filter.Campaign)
+		}
 	}
 
 	// 获取总记录数
 	if err := db.Count(&total).Error; err != nil {
-		fmt.Printf("[List] Failed to get total count: %v\n", err)
 		return nil, fmt.Errorf("failed to get total count: %w", err)
 	}
 
@@ -572,12 +552,9 @@ func (r *ShortLinkRepository) List(query *domain.PaginationQuery) (*domain.Pagin
 	// 应用分页
 	offset := (query.Page - 1) * query.PageSize
 	if err := db.Offset(offset).Limit(query.PageSize).Find(&links).Error; err != nil {
-		fmt.Printf("[List] Failed to get links: %v\n", err)
 		return nil, fmt.Errorf("failed to get links: %w", err)
 	}
 
-	fmt.Printf("[List] Successfully got %d links\n", len(links))
-
 	return &domain.PaginatedShortLinks{
 		Total:       total,
 		TotalPages:  totalPages,
@@ -588,30 +565,41 @@ func (r *ShortLinkRepository) List(query *domain.PaginationQuery) (*domain.Pagin
 }
 
 // UpdateRules 批量更新规则
-func (r *ShortLinkRepository) UpdateRules(shortLinkID uint, rules []domain.RedirectRule) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+func (r *ShortLinkRepository) UpdateRules(ctx context.Context, shortLinkID uint, rules []domain.RedirectRule) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 删除现有规则
 		if err := tx.Where("short_link_id = ?", shortLinkID).Delete(&domain.RedirectRule{}).Error; err != nil {
 			return fmt.Errorf("failed to delete existing rules: %w", err)
 		}
 
-		// 插入新规则
-		for _, rule := range rules {
+		// 插入新规则，未指定时间的规则使用当前时间
+		now := time.Now()
+		for i := range rules {
+			rule := &rules[i]
+			if rule.CreatedAt.IsZero() {
+				rule.CreatedAt = now
+			}
+			if rule.UpdatedAt.IsZero() {
+				rule.UpdatedAt = now
+			}
 			sql := `
 				INSERT INTO redirect_rules (
 					short_link_id, name, description, priority, type, target_url,
 					device, start_time, end_time, countries, provinces, cities,
-					percentage, max_visits, created_at, updated_at
+					percentage, max_visits, utm_source, utm_medium, utm_campaign, utm_term,
+					utm_content, created_at, updated_at
 				) VALUES (
 					?, ?, ?, ?, ?, ?,
 					?, ?, ?, ?::text[], ?::text[], ?::text[],
-					?, ?, ?, ?
+					?, ?, ?, ?, ?, ?,
+					?, ?, ?
 				)`
 
 			err := tx.Exec(sql,
 				shortLinkID, rule.Name, rule.Description, rule.Priority, rule.Type, rule.TargetURL,
 				rule.Device, rule.StartTime, rule.EndTime, pq.Array(rule.Countries), pq.Array(rule.Provinces), pq.Array(rule.Cities),
-				rule.Percentage, rule.MaxVisits, rule.CreatedAt, rule.UpdatedAt,
+				rule.Percentage, rule.MaxVisits, rule.UTM.Source, rule.UTM.Medium, rule.UTM.Campaign, rule.UTM.Term,
+				rule.UTM.Content, rule.CreatedAt, rule.UpdatedAt,
 			).Error
 
 			if err != nil {
@@ -619,25 +607,31 @@ func (r *ShortLinkRepository) UpdateRules(shortLinkID uint, rules []domain.Redir
 			}
 		}
 
-		// 删除规则缓存
-		ctx := context.Background()
-		if err := r.redis.Del(ctx, r.getRulesCacheKey(shortLinkID)).Err(); err != nil {
-			fmt.Printf("Failed to delete rules cache: %v\n", err)
-		}
-
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	// 事务提交后删除规则缓存，避免并发读取在提交前回填旧规则
+	r.delRulesCache(ctx, shortLinkID)
+	return nil
 }
 
 // ListClickLogs 获取访问记录列表
-func (r *ShortLinkRepository) ListClickLogs(shortLinkID uint, query *domain.ClickLogQuery) (*domain.PaginatedClickLogs, error) {
-	fmt.Printf("[ListClickLogs] Starting to get click logs for short link %d with query: %+v\n", shortLinkID, query)
-
+func (r *ShortLinkRepository) ListClickLogs(ctx context.Context, shortLinkID uint, query *domain.ClickLogQuery) (*domain.PaginatedClickLogs, error) {
 	var total int64
 	var logs []domain.ClickLog
 
+	// 排序字段与内存仓储一致，不在列表中的字段直接拒绝，避免拼接到 ORDER BY 中
+	if query.Sort != nil && query.Sort.Field != "" {
+		if _, ok := clickLogSortFields[query.Sort.Field]; !ok {
+			return nil, fmt.Errorf("failed to get logs: unknown sort field %q", query.Sort.Field)
+		}
+	}
+
 	// 构建查询
-	db := r.db.Table("click_logs").Where("short_link_id = ?", shortLinkID)
+	db := r.db.WithContext(ctx).Table("click_logs").Where("short_link_id = ?", shortLinkID)
 
 	// 应用过滤条件
 	if query.Filter != nil {
@@ -659,11 +653,16 @@ func (r *ShortLinkRepository) ListClickLogs(shortLinkID uint, query *domain.Clic
 		if query.Filter.RuleID != nil {
 			db = db.Where("rule_id = ?", *query.Filter.RuleID)
 		}
+		if query.Filter.Kind != nil {
+			db = db.Where("kind = ?", *query.Filter.Kind)
+		}
+		if query.Filter.Campaign != nil {
+			db = db.Where("campaign = ?", *query.Filter.Campaign)
+		}
 	}
 
 	// 获取总记录数
 	if err := db.Count(&total).Error; err != nil {
-		fmt.Printf("[ListClickLogs] Failed to get total count: %v\n", err)
 		return nil, fmt.Errorf("failed to get total count: %w", err)
 	}
 
@@ -685,12 +684,9 @@ func (r *ShortLinkRepository) ListClickLogs(shortLinkID uint, query *domain.Clic
 	// 应用分页
 	offset := (query.Page - 1) * query.PageSize
 	if err := db.Offset(offset).Limit(query.PageSize).Find(&logs).Error; err != nil {
-		fmt.Printf("[ListClickLogs] Failed to get logs: %v\n", err)
 		return nil, fmt.Errorf("failed to get logs: %w", err)
 	}
 
-	fmt.Printf("[ListClickLogs] Successfully got %d logs\n", len(logs))
-
 	return &domain.PaginatedClickLogs{
 		Total:       total,
 		TotalPages:  totalPages,
@@ -699,3 +695,30 @@ func (r *ShortLinkRepository) ListClickLogs(shortLinkID uint, query *domain.Clic
 		Data:        logs,
 	}, nil
 }
+
+// CampaignStats 按UTM活动统计点击数，活动名称取跳转时写入访问日志的值
+func (r *ShortLinkRepository) CampaignStats(ctx context.Context, query *domain.CampaignStatsQuery) ([]domain.CampaignStats, error) {
+	db := r.db.WithContext(ctx).Table("click_logs").
+		Select("click_logs.campaign AS campaign, COUNT(*) AS clicks, COUNT(DISTINCT click_logs.short_link_id) AS links").
+		Joins("JOIN short_links ON short_links.id = click_logs.short_link_id").
+		Where("click_logs.kind = ?", domain.ClickKindClick)
+
+	if query.UserID != nil {
+		db = db.Where("short_links.user_id = ?", *query.UserID)
+	}
+	if query.WorkspaceID != nil {
+		db = db.Where("short_links.workspace_id = ?", *query.WorkspaceID)
+	}
+	if !query.Start.IsZero() {
+		db = db.Where("click_logs.created_at >= ?", query.Start)
+	}
+	if !query.End.IsZero() {
+		db = db.Where("click_logs.created_at < ?", query.End)
+	}
+
+	stats := make([]domain.CampaignStats, 0)
+	if err := db.Group("click_logs.campaign").Order("clicks DESC, campaign ASC").Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("failed to get campaign stats: %w", err)
+	}
+	return stats, nil
+}
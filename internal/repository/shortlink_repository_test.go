@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/cache"
+
+	"go.uber.org/zap"
+)
+
+func TestShortLinkRepositoryUpdateKeepsPendingClicks(t *testing.T) {
+	ctx := context.Background()
+	fake, db := newFakeDB(t)
+	now := time.Now()
+	fake.insert(map[string]driver.Value{
+		"id":               int64(1),
+		"short_code":       "abc",
+		"long_url":         "https://example.com/old",
+		"user_id":          int64(1),
+		"workspace_id":     int64(0),
+		"clicks":           int64(10),
+		"expires_at":       now.Add(time.Hour),
+		"default_redirect": int64(1),
+		"created_at":       now,
+		"updated_at":       now,
+	})
+
+	c := cache.NewMemoryCache(0)
+	counter := NewClickCounter(db, c, time.Hour, 10, zap.NewNop())
+	repo := NewShortLinkRepository(db, c, counter, nil, zap.NewNop())
+
+	for i := 0; i < 3; i++ {
+		if _, err := counter.Incr(ctx, "abc"); err != nil {
+			t.Fatalf("Incr() error = %v", err)
+		}
+	}
+
+	link, err := repo.GetByCode(ctx, "abc")
+	if err != nil {
+		t.Fatalf("GetByCode() error = %v", err)
+	}
+	if link.Clicks != 13 {
+		t.Fatalf("GetByCode() clicks = %d, want 13", link.Clicks)
+	}
+
+	link.LongURL = "https://example.com/new"
+	if err := repo.Update(ctx, link, 0); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := counter.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	row := fake.row("abc")
+	if row["clicks"] != int64(13) {
+		t.Errorf("clicks after flush = %v, want 13", row["clicks"])
+	}
+	if row["long_url"] != "https://example.com/new" {
+		t.Errorf("long_url = %v, want https://example.com/new", row["long_url"])
+	}
+}
+
+func TestMemoryShortLinkRepositoryUpdateKeepsClicks(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryShortLinkRepository()
+	link := &domain.ShortLink{ShortCode: "abc", LongURL: "https://example.com/old", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repo.Create(ctx, link, 0); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := repo.IncrementClicks(ctx, "abc"); err != nil {
+		t.Fatalf("IncrementClicks() error = %v", err)
+	}
+
+	link.LongURL = "https://example.com/new"
+	link.Clicks = 100
+	if err := repo.Update(ctx, link, 0); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	got, err := repo.GetByCode(ctx, "abc")
+	if err != nil {
+		t.Fatalf("GetByCode() error = %v", err)
+	}
+	if got.Clicks != 1 || got.LongURL != "https://example.com/new" {
+		t.Errorf("GetByCode() = clicks %d, url %s; want clicks 1, url https://example.com/new", got.Clicks, got.LongURL)
+	}
+}
@@ -0,0 +1,321 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/cache"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// meteringDaysKey 存放有跳转计数的日期集合
+	meteringDaysKey = "metering:days"
+	// meteringLockKey 汇总锁，保证同一时刻只有一个进程在汇总
+	meteringLockKey = "metering:lock"
+
+	defaultMeteringInterval = 5 * time.Minute
+	// meteringDayFormat 计数器键中的日期格式
+	meteringDayFormat = "20060102"
+)
+
+// UsageMeter 用量计量器
+// 跳转次数先累加到缓存中按工作空间和日期划分的计数器，
+// 由后台协程按固定间隔将当天和前一天的创建数、跳转数和访问日志数汇总写入 usage_records。
+// 前一天之后的日期视为已结束，汇总后清除其计数器；已写入的数值只增不减，删除短链接不影响已计量的用量
+type UsageMeter struct {
+	db       *gorm.DB
+	cache    cache.Cache
+	interval time.Duration
+
+	stop     chan struct{}
+	done     chan struct{}
+	start    sync.Once
+	shutdown sync.Once
+
+	logger *zap.Logger
+}
+
+// NewUsageMeter 创建用量计量器
+func NewUsageMeter(db *gorm.DB, cache cache.Cache, interval time.Duration, logger *zap.Logger) *UsageMeter {
+	if interval <= 0 {
+		interval = defaultMeteringInterval
+	}
+	return &UsageMeter{
+		db:       db,
+		cache:    cache,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		logger:   logger.Named("usage_meter"),
+	}
+}
+
+// meteringDay 获取时间所在日期(UTC)的零点
+func meteringDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// redirectsKey 获取工作空间某一天的跳转计数器键
+func redirectsKey(day, workspaceID string) string {
+	return fmt.Sprintf("metering:redirects:%s:%s", day, workspaceID)
+}
+
+// workspacesKey 获取某一天有跳转计数的工作空间集合键
+func workspacesKey(day string) string {
+	return fmt.Sprintf("metering:workspaces:%s", day)
+}
+
+// Start 启动后台汇总协程
+func (m *UsageMeter) Start() {
+	m.start.Do(func() {
+		go m.run()
+	})
+}
+
+// run 按固定间隔汇总用量
+func (m *UsageMeter) run() {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.Flush(context.Background()); err != nil {
+				m.logger.Error("failed to aggregate usage", zap.Error(err))
+			}
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// Close 停止后台协程并执行最后一次汇总
+func (m *UsageMeter) Close(ctx context.Context) error {
+	m.shutdown.Do(func() {
+		close(m.stop)
+	})
+
+	// 等待进行中的汇总完成(未启动时直接跳过)
+	started := true
+	m.start.Do(func() {
+		started = false
+	})
+	if !started {
+		return nil
+	}
+	select {
+	case <-m.done:
+	case <-ctx.Done():
+		return fmt.Errorf("failed to stop usage meter: %w", ctx.Err())
+	}
+	return m.Flush(ctx)
+}
+
+// IncrRedirects 记录一次跳转，当天首次计数时登记工作空间和日期
+func (m *UsageMeter) IncrRedirects(ctx context.Context, workspaceID uint, at time.Time) error {
+	day := meteringDay(at).Format(meteringDayFormat)
+	ws := strconv.FormatUint(uint64(workspaceID), 10)
+	n, err := m.cache.IncrBy(ctx, redirectsKey(day, ws), 1)
+	if err != nil {
+		return fmt.Errorf("failed to increment redirects: %w", err)
+	}
+	if n == 1 {
+		if err := m.cache.SAdd(ctx, workspacesKey(day), ws); err != nil {
+			return fmt.Errorf("failed to track metering workspace: %w", err)
+		}
+		if err := m.cache.SAdd(ctx, meteringDaysKey, day); err != nil {
+			return fmt.Errorf("failed to track metering day: %w", err)
+		}
+	}
+	return nil
+}
+
+// Flush 汇总当天、前一天以及仍有计数器的日期，并清除已结束日期的计数器
+func (m *UsageMeter) Flush(ctx context.Context) error {
+	// 获取汇总锁，防止多个进程重复汇总
+	lock, err := acquireCacheLock(ctx, m.cache, meteringLockKey, m.interval+30*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to acquire metering lock: %w", err)
+	}
+	if lock == nil {
+		return nil
+	}
+	defer func() {
+		if err := lock.release(context.Background()); err != nil {
+			m.logger.Warn("failed to release metering lock", zap.Error(err))
+		}
+	}()
+
+	today := meteringDay(time.Now())
+	yesterday := today.AddDate(0, 0, -1)
+
+	pending, err := m.cache.SMembers(ctx, meteringDaysKey)
+	if err != nil {
+		return fmt.Errorf("failed to get metering days: %w", err)
+	}
+	days := map[time.Time]bool{today: true, yesterday: true}
+	for _, s := range pending {
+		day, err := time.Parse(meteringDayFormat, s)
+		if err != nil {
+			m.logger.Warn("discarding malformed metering day", zap.String("day", s))
+			_ = m.cache.SRem(ctx, meteringDaysKey, s)
+			continue
+		}
+		days[day] = true
+	}
+
+	for day := range days {
+		if err := m.Aggregate(ctx, day); err != nil {
+			return err
+		}
+		if day.Before(yesterday) {
+			if err := m.release(ctx, day.Format(meteringDayFormat)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// release 清除已结束日期的跳转计数器
+func (m *UsageMeter) release(ctx context.Context, day string) error {
+	members, err := m.cache.SMembers(ctx, workspacesKey(day))
+	if err != nil {
+		return fmt.Errorf("failed to get metering workspaces: %w", err)
+	}
+	keys := make([]string, 0, len(members)+1)
+	for _, ws := range members {
+		keys = append(keys, redirectsKey(day, ws))
+	}
+	keys = append(keys, workspacesKey(day))
+	if err := m.cache.Del(ctx, keys...); err != nil {
+		return fmt.Errorf("failed to release metering counters: %w", err)
+	}
+	return m.cache.SRem(ctx, meteringDaysKey, day)
+}
+
+// workspaceCount 按工作空间分组的计数
+type workspaceCount struct {
+	WorkspaceID uint
+	N           int64
+}
+
+// Aggregate 汇总指定日期的用量
+// 创建数来自 short_links，访问日志数来自 click_logs，跳转数来自缓存计数器
+func (m *UsageMeter) Aggregate(ctx context.Context, day time.Time) error {
+	start := meteringDay(day)
+	end := start.AddDate(0, 0, 1)
+	records := make(map[uint]*domain.UsageRecord)
+	record := func(workspaceID uint) *domain.UsageRecord {
+		r, ok := records[workspaceID]
+		if !ok {
+			r = &domain.UsageRecord{WorkspaceID: workspaceID, Day: start}
+			records[workspaceID] = r
+		}
+		return r
+	}
+
+	var links []workspaceCount
+	if err := m.db.WithContext(ctx).Raw(`
+		SELECT workspace_id, COUNT(*) AS n FROM short_links
+		WHERE created_at >= ? AND created_at < ?
+		GROUP BY workspace_id`, start, end).Scan(&links).Error; err != nil {
+		return fmt.Errorf("failed to count created links: %w", err)
+	}
+	for _, c := range links {
+		record(c.WorkspaceID).LinksCreated = c.N
+	}
+
+	var clicks []workspaceCount
+	if err := m.db.WithContext(ctx).Raw(`
+		SELECT s.workspace_id, COUNT(*) AS n FROM click_logs AS c
+		JOIN short_links AS s ON s.id = c.short_link_id
+		WHERE c.created_at >= ? AND c.created_at < ?
+		GROUP BY s.workspace_id`, start, end).Scan(&clicks).Error; err != nil {
+		return fmt.Errorf("failed to count click logs: %w", err)
+	}
+	for _, c := range clicks {
+		record(c.WorkspaceID).ClickLogs = c.N
+	}
+
+	dayKey := start.Format(meteringDayFormat)
+	members, err := m.cache.SMembers(ctx, workspacesKey(dayKey))
+	if err != nil {
+		return fmt.Errorf("failed to get metering workspaces: %w", err)
+	}
+	for _, ws := range members {
+		workspaceID, err := strconv.ParseUint(ws, 10, 32)
+		if err != nil {
+			continue
+		}
+		data, err := m.cache.Get(ctx, redirectsKey(dayKey, ws))
+		if errors.Is(err, cache.ErrCacheMiss) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get redirects: %w", err)
+		}
+		n, err := strconv.ParseInt(data, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse redirects for workspace %s: %w", ws, err)
+		}
+		record(uint(workspaceID)).Redirects = n
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	// 日期以字符串传入，避免按会话时区转换为其他日期；已计量的数值只增不减，删除短链接或计数器丢失不会减少用量
+	now := time.Now()
+	values := make([]string, 0, len(records))
+	args := make([]interface{}, 0, 6*len(records))
+	for _, r := range records {
+		values = append(values, "(?, ?::date, ?::bigint, ?::bigint, ?::bigint, ?::timestamptz)")
+		args = append(args, r.WorkspaceID, r.Day.Format(time.DateOnly), r.LinksCreated, r.Redirects, r.ClickLogs, now)
+	}
+	sql := `
+		INSERT INTO usage_records (workspace_id, day, links_created, redirects, click_logs, updated_at)
+		VALUES ` + strings.Join(values, ", ") + `
+		ON CONFLICT (workspace_id, day) DO UPDATE SET
+			links_created = GREATEST(usage_records.links_created, EXCLUDED.links_created),
+			redirects = GREATEST(usage_records.redirects, EXCLUDED.redirects),
+			click_logs = GREATEST(usage_records.click_logs, EXCLUDED.click_logs),
+			updated_at = EXCLUDED.updated_at`
+	if err := m.db.WithContext(ctx).Exec(sql, args...).Error; err != nil {
+		return fmt.Errorf("failed to save usage records: %w", err)
+	}
+	return nil
+}
+
+// List 按工作空间和日期查询计量记录，按日期和工作空间排序
+func (m *UsageMeter) List(ctx context.Context, query *domain.UsageRecordQuery) ([]domain.UsageRecord, error) {
+	db := m.db.WithContext(ctx).Model(&domain.UsageRecord{}).
+		Where("day >= ? AND day < ?", query.Start.Format(time.DateOnly), query.End.Format(time.DateOnly))
+	if query.WorkspaceID != nil {
+		db = db.Where("workspace_id = ?", *query.WorkspaceID)
+	}
+
+	var records []domain.UsageRecord
+	if err := db.Order("day ASC, workspace_id ASC").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to list usage records: %w", err)
+	}
+	// date 列不带时区，统一按UTC日期返回
+	for i := range records {
+		d := records[i].Day
+		records[i].Day = time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return records, nil
+}
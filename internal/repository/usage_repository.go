@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/cache"
+
+	"gorm.io/gorm"
+)
+
+// UsageRepository 实现用量统计仓储接口
+// 有效短链接数直接查询数据库；月度点击数保存在缓存计数器中，使用Redis时多个实例共享，
+// 计数器不存在时按数据库中当月已写入的访问日志数初始化，缓存重启后不会清零
+type UsageRepository struct {
+	db    *gorm.DB
+	cache cache.Cache
+}
+
+// NewUsageRepository 创建用量统计仓储实例
+func NewUsageRepository(db *gorm.DB, cache cache.Cache) domain.UsageRepository {
+	return &UsageRepository{db: db, cache: cache}
+}
+
+// clicksKey 获取月度点击数的计数器键
+func clicksKey(owner domain.UsageOwner, month time.Time) string {
+	if owner.WorkspaceID != 0 {
+		return fmt.Sprintf("usage:clicks:w%d:%s", owner.WorkspaceID, month.Format("200601"))
+	}
+	return fmt.Sprintf("usage:clicks:u%d:%s", owner.UserID, month.Format("200601"))
+}
+
+// linkUsageOwner 获取短链接的用量归属
+func linkUsageOwner(link *domain.ShortLink) domain.UsageOwner {
+	if link.WorkspaceID != 0 {
+		return domain.UsageOwner{WorkspaceID: link.WorkspaceID}
+	}
+	return domain.UsageOwner{UserID: link.UserID}
+}
+
+// activeLinksLockKey 获取有效短链接数检查的咨询锁键
+func activeLinksLockKey(owner domain.UsageOwner) string {
+	if owner.WorkspaceID != 0 {
+		return fmt.Sprintf("usage:active_links:w%d", owner.WorkspaceID)
+	}
+	return fmt.Sprintf("usage:active_links:u%d", owner.UserID)
+}
+
+// activeLinks 限定查询范围为用量归属的未过期短链接，永不过期的短链接过期时间为100年后，同样计入
+func activeLinks(db *gorm.DB, owner domain.UsageOwner) *gorm.DB {
+	db = db.Model(&domain.ShortLink{}).
+		Where("workspace_id = ? AND expires_at > ?", owner.WorkspaceID, time.Now())
+	if owner.WorkspaceID == 0 {
+		db = db.Where("user_id = ?", owner.UserID)
+	}
+	return db
+}
+
+// checkActiveLinks 在事务中检查用量归属的有效短链接数是否已达到 maxActive
+// 先获取按用量归属划分的事务级咨询锁，同一归属的并发创建串行执行，锁在事务结束时释放
+func checkActiveLinks(tx *gorm.DB, owner domain.UsageOwner, maxActive int64) error {
+	if maxActive <= 0 {
+		return nil
+	}
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", activeLinksLockKey(owner)).Error; err != nil {
+		return fmt.Errorf("failed to lock active links: %w", err)
+	}
+
+	var count int64
+	if err := activeLinks(tx, owner).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count active links: %w", err)
+	}
+	if count >= maxActive {
+		return domain.ErrQuotaExceeded
+	}
+	return nil
+}
+
+// CountActiveLinks 统计未过期的短链接数
+func (r *UsageRepository) CountActiveLinks(ctx context.Context, owner domain.UsageOwner) (int64, error) {
+	var count int64
+	if err := activeLinks(r.db.WithContext(ctx), owner).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count active links: %w", err)
+	}
+	return count, nil
+}
+
+// countClickLogs 统计指定月份已写入数据库的访问日志数，包括预览记录
+// 尚在缓冲区或事件流中的访问日志不计入，已删除短链接的访问日志随短链接一起删除，同样不计入
+func (r *UsageRepository) countClickLogs(ctx context.Context, owner domain.UsageOwner, month time.Time) (int64, error) {
+	db := r.db.WithContext(ctx).Table("click_logs").
+		Joins("JOIN short_links ON short_links.id = click_logs.short_link_id").
+		Where("short_links.workspace_id = ? AND click_logs.created_at >= ? AND click_logs.created_at < ?",
+			owner.WorkspaceID, month, month.AddDate(0, 1, 0))
+	if owner.WorkspaceID == 0 {
+		db = db.Where("short_links.user_id = ?", owner.UserID)
+	}
+
+	var count int64
+	if err := db.Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count monthly click logs: %w", err)
+	}
+	return count, nil
+}
+
+// IncrClicks 增加指定月份的点击数
+// 递增后的值等于 n 表示计数器刚刚创建，此时计入数据库中已写入的访问日志数，并删除上个月的计数器，避免计数器无限累积
+func (r *UsageRepository) IncrClicks(ctx context.Context, owner domain.UsageOwner, month time.Time, n int64) (int64, error) {
+	key := clicksKey(owner, month)
+	count, err := r.cache.IncrBy(ctx, key, n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment monthly clicks: %w", err)
+	}
+	if n <= 0 || count != n {
+		return count, nil
+	}
+
+	_ = r.cache.Del(ctx, clicksKey(owner, month.AddDate(0, -1, 0)))
+	logged, err := r.countClickLogs(ctx, owner, month)
+	if err != nil {
+		// 计数器已创建，之后不会再初始化，撤销本次递增以便下次重试
+		_, _ = r.cache.IncrBy(ctx, key, -n)
+		return 0, err
+	}
+	if logged == 0 {
+		return count, nil
+	}
+	if count, err = r.cache.IncrBy(ctx, key, logged); err != nil {
+		return 0, fmt.Errorf("failed to increment monthly clicks: %w", err)
+	}
+	return count, nil
+}
+
+// GetClicks 获取指定月份的点击数，计数器不存在时按数据库中已写入的访问日志数初始化
+func (r *UsageRepository) GetClicks(ctx context.Context, owner domain.UsageOwner, month time.Time) (int64, error) {
+	key := clicksKey(owner, month)
+	data, err := r.cache.Get(ctx, key)
+	if err == nil {
+		return strconv.ParseInt(data, 10, 64)
+	}
+	if !errors.Is(err, cache.ErrCacheMiss) {
+		return 0, fmt.Errorf("failed to get monthly clicks: %w", err)
+	}
+
+	logged, err := r.countClickLogs(ctx, owner, month)
+	if err != nil {
+		return 0, err
+	}
+	// 其他请求已创建计数器时以计数器为准
+	if ok, err := r.cache.SetNX(ctx, key, strconv.FormatInt(logged, 10), 0); err != nil || ok {
+		return logged, nil
+	}
+	if data, err = r.cache.Get(ctx, key); err != nil {
+		return logged, nil
+	}
+	return strconv.ParseInt(data, 10, 64)
+}
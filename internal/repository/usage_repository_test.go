@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/cache"
+
+	"go.uber.org/zap"
+)
+
+// createConcurrently 并发创建短链接，返回成功和超出配额的次数
+func createConcurrently(t *testing.T, repo domain.ShortLinkRepository, n int, maxActive int64, link func(i int) *domain.ShortLink) (created, exceeded int) {
+	t.Helper()
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := repo.Create(context.Background(), link(i), maxActive)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				created++
+			case errors.Is(err, domain.ErrQuotaExceeded):
+				exceeded++
+			default:
+				t.Errorf("Create() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	return created, exceeded
+}
+
+func TestShortLinkRepositoryCreateActiveLinksQuota(t *testing.T) {
+	fake, db := newFakeDB(t)
+	now := time.Now()
+	// 已过期的短链接和其他用户、工作空间的短链接不计入
+	for i, row := range []map[string]driver.Value{
+		{"user_id": int64(1), "workspace_id": int64(0), "expires_at": now.Add(-time.Hour)},
+		{"user_id": int64(1), "workspace_id": int64(0), "expires_at": now.Add(time.Hour)},
+		{"user_id": int64(2), "workspace_id": int64(0), "expires_at": now.Add(time.Hour)},
+		{"user_id": int64(1), "workspace_id": int64(5), "expires_at": now.Add(time.Hour)},
+	} {
+		row["id"] = int64(i + 1)
+		row["short_code"] = fmt.Sprintf("seed%d", i)
+		fake.insert(row)
+	}
+	repo := NewShortLinkRepository(db, cache.NewMemoryCache(0), nil, nil, zap.NewNop())
+
+	created, exceeded := createConcurrently(t, repo, 10, 3, func(i int) *domain.ShortLink {
+		return &domain.ShortLink{ShortCode: fmt.Sprintf("u%d", i), LongURL: "https://example.com", UserID: 1, ExpiresAt: now.Add(time.Hour)}
+	})
+	if created != 2 || exceeded != 8 {
+		t.Errorf("created %d, exceeded %d; want 2 created within the quota of 3", created, exceeded)
+	}
+
+	created, exceeded = createConcurrently(t, repo, 5, 2, func(i int) *domain.ShortLink {
+		return &domain.ShortLink{ShortCode: fmt.Sprintf("w%d", i), LongURL: "https://example.com", UserID: uint(i + 1), WorkspaceID: 5, ExpiresAt: now.Add(time.Hour)}
+	})
+	if created != 1 || exceeded != 4 {
+		t.Errorf("workspace created %d, exceeded %d; want 1 created within the quota of 2", created, exceeded)
+	}
+	if n := fake.linkCount(); n != 7 {
+		t.Errorf("link count = %d, want 7", n)
+	}
+
+	// 不限制时不检查
+	if err := repo.Create(context.Background(), &domain.ShortLink{ShortCode: "free", LongURL: "https://example.com", UserID: 1, ExpiresAt: now.Add(time.Hour)}, 0); err != nil {
+		t.Errorf("Create() without quota error = %v", err)
+	}
+
+	// 已过期的短链接重新生效时同样检查
+	expired := &domain.ShortLink{ID: 1, ShortCode: "seed0", LongURL: "https://example.com", UserID: 1, ExpiresAt: now.Add(time.Hour)}
+	if err := repo.Update(context.Background(), expired, 4); !errors.Is(err, domain.ErrQuotaExceeded) {
+		t.Errorf("Update() reactivating beyond the quota error = %v, want ErrQuotaExceeded", err)
+	}
+	if err := repo.Update(context.Background(), expired, 5); err != nil {
+		t.Errorf("Update() reactivating within the quota error = %v", err)
+	}
+}
+
+func TestMemoryShortLinkRepositoryCreateActiveLinksQuota(t *testing.T) {
+	repo := NewMemoryShortLinkRepository()
+	now := time.Now()
+	expired := &domain.ShortLink{ShortCode: "old", LongURL: "https://example.com", UserID: 1, ExpiresAt: now.Add(-time.Hour)}
+	if err := repo.Create(context.Background(), expired, 0); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	created, exceeded := createConcurrently(t, repo, 10, 3, func(i int) *domain.ShortLink {
+		return &domain.ShortLink{ShortCode: fmt.Sprintf("u%d", i), LongURL: "https://example.com", UserID: 1, ExpiresAt: now.Add(time.Hour)}
+	})
+	if created != 3 || exceeded != 7 {
+		t.Errorf("created %d, exceeded %d; want 3 created within the quota", created, exceeded)
+	}
+	if err := repo.Create(context.Background(), &domain.ShortLink{ShortCode: "other", LongURL: "https://example.com", UserID: 2, ExpiresAt: now.Add(time.Hour)}, 3); err != nil {
+		t.Errorf("Create() for another user error = %v", err)
+	}
+
+	expired.ExpiresAt = now.Add(time.Hour)
+	if err := repo.Update(context.Background(), expired, 3); !errors.Is(err, domain.ErrQuotaExceeded) {
+		t.Errorf("Update() reactivating beyond the quota error = %v, want ErrQuotaExceeded", err)
+	}
+	if err := repo.Update(context.Background(), expired, 4); err != nil {
+		t.Errorf("Update() reactivating within the quota error = %v", err)
+	}
+}
+
+func TestUsageRepositoryMonthlyClicks(t *testing.T) {
+	ctx := context.Background()
+	fake, db := newFakeDB(t)
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i, row := range []map[string]driver.Value{
+		{"user_id": int64(1), "workspace_id": int64(0)},
+		{"user_id": int64(2), "workspace_id": int64(0)},
+		{"user_id": int64(1), "workspace_id": int64(5)},
+	} {
+		row["id"] = int64(i + 1)
+		row["short_code"] = fmt.Sprintf("link%d", i+1)
+		row["expires_at"] = now.Add(time.Hour)
+		fake.insert(row)
+	}
+	for _, log := range []struct {
+		link int64
+		at   time.Time
+	}{
+		{1, month.Add(time.Hour)},
+		{1, now},
+		{1, month.AddDate(0, -1, 0).Add(time.Hour)}, // 上个月
+		{2, now},
+		{3, now},
+	} {
+		fake.insertClickLog(map[string]driver.Value{"short_link_id": log.link, "created_at": log.at})
+	}
+
+	user := domain.UsageOwner{UserID: 1}
+	workspace := domain.UsageOwner{WorkspaceID: 5}
+
+	c := cache.NewMemoryCache(0)
+	repo := NewUsageRepository(db, c)
+	if active, err := repo.CountActiveLinks(ctx, user); err != nil || active != 1 {
+		t.Errorf("CountActiveLinks() = %d, %v; want 1", active, err)
+	}
+	if clicks, err := repo.GetClicks(ctx, user, month); err != nil || clicks != 2 {
+		t.Errorf("GetClicks() = %d, %v; want the 2 click logs of this month", clicks, err)
+	}
+	if clicks, err := repo.GetClicks(ctx, workspace, month); err != nil || clicks != 1 {
+		t.Errorf("GetClicks(workspace) = %d, %v; want 1", clicks, err)
+	}
+	if clicks, err := repo.IncrClicks(ctx, user, month, 1); err != nil || clicks != 3 {
+		t.Errorf("IncrClicks() after GetClicks = %d, %v; want 3", clicks, err)
+	}
+
+	// 缓存重启后计数器按数据库中的访问日志初始化，只初始化一次
+	c = cache.NewMemoryCache(0)
+	repo = NewUsageRepository(db, c)
+	lastMonth := clicksKey(user, month.AddDate(0, -1, 0))
+	if err := c.Set(ctx, lastMonth, "7", 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	for want := int64(3); want <= 4; want++ {
+		if clicks, err := repo.IncrClicks(ctx, user, month, 1); err != nil || clicks != want {
+			t.Errorf("IncrClicks() after restart = %d, %v; want %d", clicks, err, want)
+		}
+	}
+	if _, err := c.Get(ctx, lastMonth); !errors.Is(err, cache.ErrCacheMiss) {
+		t.Errorf("last month counter error = %v, want ErrCacheMiss", err)
+	}
+	if clicks, err := repo.IncrClicks(ctx, user, month, -1); err != nil || clicks != 3 {
+		t.Errorf("IncrClicks(-1) = %d, %v; want 3", clicks, err)
+	}
+	if clicks, err := repo.GetClicks(ctx, user, month); err != nil || clicks != 3 {
+		t.Errorf("GetClicks() = %d, %v; want 3", clicks, err)
+	}
+}
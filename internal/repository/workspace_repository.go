@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"linkit/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WorkspaceRepository 实现工作空间仓储接口
+type WorkspaceRepository struct {
+	db *gorm.DB
+}
+
+// NewWorkspaceRepository 创建工作空间仓储实例
+func NewWorkspaceRepository(db *gorm.DB) domain.WorkspaceRepository {
+	return &WorkspaceRepository{db: db}
+}
+
+// Create 创建工作空间，并将创建者添加为所有者
+func (r *WorkspaceRepository) Create(ctx context.Context, ws *domain.Workspace) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(ws).Error; err != nil {
+			return fmt.Errorf("failed to create workspace: %w", err)
+		}
+		owner := &domain.WorkspaceMember{
+			WorkspaceID: ws.ID,
+			UserID:      ws.CreatedBy,
+			Role:        domain.RoleOwner,
+		}
+		if err := tx.Create(owner).Error; err != nil {
+			return fmt.Errorf("failed to add workspace owner: %w", err)
+		}
+		ws.Role = domain.RoleOwner
+		return nil
+	})
+}
+
+// GetByID 根据ID获取工作空间
+func (r *WorkspaceRepository) GetByID(ctx context.Context, id uint) (*domain.Workspace, error) {
+	var ws domain.Workspace
+	if err := r.db.WithContext(ctx).First(&ws, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrWorkspaceNotFound
+		}
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	return &ws, nil
+}
+
+// List 获取用户所在的工作空间及其角色，userID为0时返回所有工作空间
+func (r *WorkspaceRepository) List(ctx context.Context, userID uint) ([]domain.Workspace, error) {
+	db := r.db.WithContext(ctx).Table("workspaces").Order("workspaces.id")
+	if userID != 0 {
+		db = db.Select("workspaces.*, workspace_members.role").
+			Joins("JOIN workspace_members ON workspace_members.workspace_id = workspaces.id").
+			Where("workspace_members.user_id = ?", userID)
+	}
+
+	// Role 字段不映射到表，使用临时结构体读取成员角色
+	var rows []struct {
+		domain.Workspace
+		MemberRole domain.Role `gorm:"column:role"`
+	}
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list workspaces: %w", err)
+	}
+
+	workspaces := make([]domain.Workspace, len(rows))
+	for i, row := range rows {
+		workspaces[i] = row.Workspace
+		workspaces[i].Role = row.MemberRole
+	}
+	return workspaces, nil
+}
+
+// GetMember 获取工作空间成员
+func (r *WorkspaceRepository) GetMember(ctx context.Context, workspaceID, userID uint) (*domain.WorkspaceMember, error) {
+	var member domain.WorkspaceMember
+	err := r.db.WithContext(ctx).
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrMemberNotFound
+		}
+		return nil, fmt.Errorf("failed to get workspace member: %w", err)
+	}
+	return &member, nil
+}
+
+// ListMembers 获取工作空间的所有成员
+func (r *WorkspaceRepository) ListMembers(ctx context.Context, workspaceID uint) ([]domain.WorkspaceMember, error) {
+	var members []domain.WorkspaceMember
+	if err := r.db.WithContext(ctx).
+		Where("workspace_id = ?", workspaceID).
+		Order("created_at").
+		Find(&members).Error; err != nil {
+		return nil, fmt.Errorf("failed to list workspace members: %w", err)
+	}
+	return members, nil
+}
+
+// lockWorkspace 在事务中锁定工作空间记录，同一工作空间的成员变更串行执行
+func lockWorkspace(tx *gorm.DB, workspaceID uint) error {
+	var ws domain.Workspace
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&ws, workspaceID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrWorkspaceNotFound
+		}
+		return fmt.Errorf("failed to lock workspace: %w", err)
+	}
+	return nil
+}
+
+// ensureOwnerRemains 检查移除或降级成员后工作空间仍有所有者，调用方需已锁定工作空间
+func ensureOwnerRemains(tx *gorm.DB, workspaceID, userID uint) error {
+	var member domain.WorkspaceMember
+	err := tx.Where("workspace_id = ? AND user_id = ?", workspaceID, userID).First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrMemberNotFound
+		}
+		return fmt.Errorf("failed to get workspace member: %w", err)
+	}
+	if member.Role != domain.RoleOwner {
+		return nil
+	}
+
+	var owners int64
+	if err := tx.Model(&domain.WorkspaceMember{}).
+		Where("workspace_id = ? AND role = ?", workspaceID, domain.RoleOwner).
+		Count(&owners).Error; err != nil {
+		return fmt.Errorf("failed to count workspace owners: %w", err)
+	}
+	if owners <= 1 {
+		return domain.ErrLastOwner
+	}
+	return nil
+}
+
+// SetMember 添加成员或修改成员角色，降级最后一个所有者时返回 ErrLastOwner
+// 检查和写入在同一事务中完成，并发降级不同所有者时不会同时成功
+func (r *WorkspaceRepository) SetMember(ctx context.Context, member *domain.WorkspaceMember) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockWorkspace(tx, member.WorkspaceID); err != nil {
+			return err
+		}
+		if member.Role != domain.RoleOwner {
+			if err := ensureOwnerRemains(tx, member.WorkspaceID, member.UserID); err != nil && !errors.Is(err, domain.ErrMemberNotFound) {
+				return err
+			}
+		}
+
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "workspace_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+		}).Create(member).Error
+		if err != nil {
+			return fmt.Errorf("failed to set workspace member: %w", err)
+		}
+		return nil
+	})
+}
+
+// RemoveMember 移除工作空间成员，移除最后一个所有者时返回 ErrLastOwner
+func (r *WorkspaceRepository) RemoveMember(ctx context.Context, workspaceID, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockWorkspace(tx, workspaceID); err != nil {
+			return err
+		}
+		if err := ensureOwnerRemains(tx, workspaceID, userID); err != nil {
+			return err
+		}
+
+		result := tx.Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+			Delete(&domain.WorkspaceMember{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to remove workspace member: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrMemberNotFound
+		}
+		return nil
+	})
+}
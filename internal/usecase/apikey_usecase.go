@@ -0,0 +1,254 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// APIKeyPrefix API Key的固定前缀，便于识别和扫描泄露的密钥
+	APIKeyPrefix = "lk_"
+	// apiKeyBytes API Key的随机字节数
+	apiKeyBytes = 24
+	// apiKeyDisplayLength 保存用于识别密钥的前缀长度(包含固定前缀)
+	apiKeyDisplayLength = len(APIKeyPrefix) + 8
+	// lastUsedInterval 最近使用时间的最小更新间隔，避免每个请求都写数据库
+	lastUsedInterval = time.Minute
+)
+
+// APIKeyUseCase 实现API Key用例接口
+type APIKeyUseCase struct {
+	repo     domain.APIKeyRepository
+	timeouts Timeouts
+	logger   *zap.Logger
+
+	touchMu sync.Mutex
+	touched map[uint]time.Time // API Key ID -> 本进程最近一次更新使用时间的时间
+}
+
+// NewAPIKeyUseCase 创建API Key用例实例
+func NewAPIKeyUseCase(repo domain.APIKeyRepository, timeouts Timeouts, logger *zap.Logger) domain.APIKeyUseCase {
+	return &APIKeyUseCase{
+		repo:     repo,
+		timeouts: timeouts,
+		logger:   logger.Named("apikey"),
+		touched:  make(map[uint]time.Time),
+	}
+}
+
+// log 返回带有请求ID的日志实例
+func (u *APIKeyUseCase) log(ctx context.Context) *zap.Logger {
+	return logger.FromContext(ctx, u.logger)
+}
+
+// GenerateAPIKey 生成新的API Key，返回完整密钥、前缀和哈希
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	buf := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	key = APIKeyPrefix + hex.EncodeToString(buf)
+	return key, key[:apiKeyDisplayLength], HashAPIKey(key), nil
+}
+
+// HashAPIKey 计算API Key的哈希，密钥本身具有足够的随机性，无需加盐
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// principal 获取当前调用者
+func principal(ctx context.Context) (*domain.Principal, error) {
+	p, ok := domain.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, domain.ErrUnauthorized
+	}
+	return p, nil
+}
+
+// normalizeScopes 校验并去重权限范围
+func normalizeScopes(scopes []domain.Scope) ([]domain.Scope, error) {
+	seen := make(map[domain.Scope]bool, len(scopes))
+	result := make([]domain.Scope, 0, len(scopes))
+	for _, s := range scopes {
+		if !domain.ValidScope(s) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrInvalidScope, s)
+		}
+		if !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", domain.ErrInvalidScope)
+	}
+	return result, nil
+}
+
+// Create 创建API Key，仅管理员可以创建，未指定用户时属于调用者
+func (u *APIKeyUseCase) Create(ctx context.Context, input *domain.CreateAPIKeyInput) (*domain.CreatedAPIKey, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Write)
+	defer cancel()
+
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !p.IsAdmin() {
+		return nil, domain.ErrForbidden
+	}
+
+	scopes, err := normalizeScopes(input.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, domain.ErrInvalidExpiration
+	}
+
+	userID := input.UserID
+	if userID == 0 {
+		userID = p.UserID
+	}
+
+	key, prefix, hash, err := GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	apiKey := &domain.APIKey{
+		Name:      strings.TrimSpace(input.Name),
+		Prefix:    prefix,
+		KeyHash:   hash,
+		UserID:    userID,
+		Scopes:    scopes,
+		ExpiresAt: input.ExpiresAt,
+	}
+	if err := u.repo.Create(ctx, apiKey); err != nil {
+		return nil, err
+	}
+
+	u.log(ctx).Info("api key created",
+		zap.Uint("api_key_id", apiKey.ID), zap.Uint("user_id", userID), zap.Uint("created_by", p.APIKeyID))
+	return &domain.CreatedAPIKey{APIKey: *apiKey, Key: key}, nil
+}
+
+// List 获取API Key列表，管理员可以查看所有用户的API Key
+func (u *APIKeyUseCase) List(ctx context.Context) ([]domain.APIKey, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Read)
+	defer cancel()
+
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var userID uint
+	if !p.IsAdmin() {
+		userID = p.UserID
+	}
+	return u.repo.List(ctx, userID)
+}
+
+// Revoke 吊销API Key，非管理员只能吊销自己的API Key
+func (u *APIKeyUseCase) Revoke(ctx context.Context, id uint) error {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Write)
+	defer cancel()
+
+	p, err := principal(ctx)
+	if err != nil {
+		return err
+	}
+	if !p.IsAdmin() {
+		key, err := u.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if key.UserID != p.UserID {
+			return domain.ErrAPIKeyNotFound
+		}
+	}
+
+	if err := u.repo.Revoke(ctx, id, time.Now()); err != nil {
+		return err
+	}
+	u.log(ctx).Info("api key revoked", zap.Uint("api_key_id", id), zap.Uint("revoked_by", p.APIKeyID))
+	return nil
+}
+
+// Authenticate 校验密钥，密钥不存在、已吊销或已过期时返回 ErrUnauthorized
+func (u *APIKeyUseCase) Authenticate(ctx context.Context, key string) (*domain.Principal, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Read)
+	defer cancel()
+
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, domain.ErrUnauthorized
+	}
+
+	apiKey, err := u.repo.GetByHash(ctx, HashAPIKey(key))
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		return nil, domain.ErrUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if apiKey.RevokedAt != nil {
+		u.log(ctx).Debug("revoked api key used", zap.Uint("api_key_id", apiKey.ID))
+		return nil, domain.ErrUnauthorized
+	}
+	if apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(now) {
+		u.log(ctx).Debug("expired api key used", zap.Uint("api_key_id", apiKey.ID))
+		return nil, domain.ErrUnauthorized
+	}
+
+	// 更新最近使用时间，不阻塞请求
+	if u.shouldTouch(apiKey, now) {
+		go func(ctx context.Context, id uint) {
+			ctx, cancel := withTimeout(ctx, u.timeouts.Write)
+			defer cancel()
+			if err := u.repo.TouchLastUsed(ctx, id, now); err != nil {
+				u.log(ctx).Warn("failed to update api key last used time", zap.Uint("api_key_id", id), zap.Error(err))
+			}
+		}(context.WithoutCancel(ctx), apiKey.ID)
+	}
+
+	return &domain.Principal{
+		UserID:   apiKey.UserID,
+		APIKeyID: apiKey.ID,
+		Scopes:   apiKey.Scopes,
+	}, nil
+}
+
+// shouldTouch 判断是否需要更新API Key的最近使用时间
+// 数据库中的时间只在更新后才会变化，同一密钥的并发请求按本进程的更新记录去重，每个间隔内最多更新一次
+func (u *APIKeyUseCase) shouldTouch(apiKey *domain.APIKey, now time.Time) bool {
+	if apiKey.LastUsedAt != nil && now.Sub(*apiKey.LastUsedAt) < lastUsedInterval {
+		return false
+	}
+
+	u.touchMu.Lock()
+	defer u.touchMu.Unlock()
+	if last, ok := u.touched[apiKey.ID]; ok && now.Sub(last) < lastUsedInterval {
+		return false
+	}
+	// 清理已过间隔的记录，记录数不超过一个间隔内使用过的密钥数
+	for id, last := range u.touched {
+		if now.Sub(last) >= lastUsedInterval {
+			delete(u.touched, id)
+		}
+	}
+	u.touched[apiKey.ID] = now
+	return true
+}
@@ -0,0 +1,282 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"linkit/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+// stubAPIKeyRepository 内存中的API Key仓储，记录最近使用时间的更新次数
+type stubAPIKeyRepository struct {
+	mu      sync.Mutex
+	keys    map[uint]*domain.APIKey
+	touches int
+	err     error // 不为空时查询返回该错误
+}
+
+func newStubAPIKeyRepository() *stubAPIKeyRepository {
+	return &stubAPIKeyRepository{keys: make(map[uint]*domain.APIKey)}
+}
+
+func (r *stubAPIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key.ID = uint(len(r.keys) + 1)
+	stored := *key
+	r.keys[key.ID] = &stored
+	return nil
+}
+
+func (r *stubAPIKeyRepository) GetByID(ctx context.Context, id uint) (*domain.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	found := *key
+	return &found, nil
+}
+
+func (r *stubAPIKeyRepository) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	for _, key := range r.keys {
+		if key.KeyHash == hash {
+			found := *key
+			return &found, nil
+		}
+	}
+	return nil, domain.ErrAPIKeyNotFound
+}
+
+func (r *stubAPIKeyRepository) List(ctx context.Context, userID uint) ([]domain.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var keys []domain.APIKey
+	for _, key := range r.keys {
+		if userID == 0 || key.UserID == userID {
+			keys = append(keys, *key)
+		}
+	}
+	return keys, nil
+}
+
+func (r *stubAPIKeyRepository) Revoke(ctx context.Context, id uint, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.keys[id]
+	if !ok {
+		return domain.ErrAPIKeyNotFound
+	}
+	key.RevokedAt = &at
+	return nil
+}
+
+func (r *stubAPIKeyRepository) TouchLastUsed(ctx context.Context, id uint, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.touches++
+	r.keys[id].LastUsedAt = &at
+	return nil
+}
+
+// touchCount 返回最近使用时间的更新次数
+func (r *stubAPIKeyRepository) touchCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.touches
+}
+
+// adminContext 返回以管理员身份调用的上下文
+func adminContext() context.Context {
+	return domain.WithPrincipal(context.Background(), &domain.Principal{
+		UserID: 1,
+		Scopes: []domain.Scope{domain.ScopeAdmin},
+	})
+}
+
+// createAPIKey 以管理员身份创建API Key
+func createAPIKey(t *testing.T, uc domain.APIKeyUseCase, input domain.CreateAPIKeyInput) *domain.CreatedAPIKey {
+	t.Helper()
+	created, err := uc.Create(adminContext(), &input)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	return created
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, hash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateAPIKey() error = %v", err)
+	}
+	if !strings.HasPrefix(key, APIKeyPrefix) || len(key) != len(APIKeyPrefix)+2*apiKeyBytes {
+		t.Errorf("key = %q, want %s followed by %d hex characters", key, APIKeyPrefix, 2*apiKeyBytes)
+	}
+	if prefix != key[:apiKeyDisplayLength] {
+		t.Errorf("prefix = %q, want %q", prefix, key[:apiKeyDisplayLength])
+	}
+	// 只保存哈希，同一密钥的哈希固定，不同密钥的哈希不同
+	if hash != HashAPIKey(key) || len(hash) != 64 || strings.Contains(hash, key) {
+		t.Errorf("hash = %q, want the hex sha256 of the key", hash)
+	}
+	other, _, otherHash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateAPIKey() error = %v", err)
+	}
+	if other == key || otherHash == hash {
+		t.Error("GenerateAPIKey() returned the same key twice")
+	}
+}
+
+func TestAPIKeyUseCaseAuthenticate(t *testing.T) {
+	repo := newStubAPIKeyRepository()
+	uc := NewAPIKeyUseCase(repo, Timeouts{}, zap.NewNop())
+	created := createAPIKey(t, uc, domain.CreateAPIKeyInput{Name: "ci", Scopes: []domain.Scope{domain.ScopeWrite, domain.ScopeWrite}, UserID: 7})
+
+	p, err := uc.Authenticate(context.Background(), created.Key)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if p.UserID != 7 || p.APIKeyID != created.ID || len(p.Scopes) != 1 || p.Scopes[0] != domain.ScopeWrite {
+		t.Errorf("Authenticate() = %+v, want user 7 with write scope", p)
+	}
+
+	stored, _ := repo.GetByID(context.Background(), created.ID)
+	if stored.KeyHash != HashAPIKey(created.Key) || strings.Contains(stored.KeyHash, created.Key) {
+		t.Errorf("stored hash = %q, want the hash of the key", stored.KeyHash)
+	}
+
+	for _, key := range []string{"", "lk_unknown", strings.TrimPrefix(created.Key, APIKeyPrefix), created.Key + "x", strings.ToUpper(created.Key)} {
+		if _, err := uc.Authenticate(context.Background(), key); !errors.Is(err, domain.ErrUnauthorized) {
+			t.Errorf("Authenticate(%q) error = %v, want ErrUnauthorized", key, err)
+		}
+	}
+
+	// 数据库错误不按未认证处理
+	repo.mu.Lock()
+	repo.err = errors.New("connection refused")
+	repo.mu.Unlock()
+	if _, err := uc.Authenticate(context.Background(), created.Key); err == nil || errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("Authenticate() with repository error = %v, want the repository error", err)
+	}
+}
+
+func TestAPIKeyUseCaseExpiredAndRevoked(t *testing.T) {
+	repo := newStubAPIKeyRepository()
+	uc := NewAPIKeyUseCase(repo, Timeouts{}, zap.NewNop())
+
+	soon := time.Now().Add(time.Hour)
+	expiring := createAPIKey(t, uc, domain.CreateAPIKeyInput{Name: "expiring", Scopes: []domain.Scope{domain.ScopeRead}, ExpiresAt: &soon})
+	if _, err := uc.Authenticate(context.Background(), expiring.Key); err != nil {
+		t.Fatalf("Authenticate() before expiry error = %v", err)
+	}
+	past := time.Now().Add(-time.Second)
+	repo.mu.Lock()
+	repo.keys[expiring.ID].ExpiresAt = &past
+	repo.mu.Unlock()
+	if _, err := uc.Authenticate(context.Background(), expiring.Key); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("Authenticate() after expiry error = %v, want ErrUnauthorized", err)
+	}
+
+	revoked := createAPIKey(t, uc, domain.CreateAPIKeyInput{Name: "revoked", Scopes: []domain.Scope{domain.ScopeRead}, UserID: 2})
+	// 非管理员只能吊销自己的API Key
+	other := domain.WithPrincipal(context.Background(), &domain.Principal{UserID: 3, Scopes: []domain.Scope{domain.ScopeWrite}})
+	if err := uc.Revoke(other, revoked.ID); !errors.Is(err, domain.ErrAPIKeyNotFound) {
+		t.Errorf("Revoke() by another user error = %v, want ErrAPIKeyNotFound", err)
+	}
+	owner := domain.WithPrincipal(context.Background(), &domain.Principal{UserID: 2, Scopes: []domain.Scope{domain.ScopeRead}})
+	if err := uc.Revoke(owner, revoked.ID); err != nil {
+		t.Fatalf("Revoke() by owner error = %v", err)
+	}
+	if _, err := uc.Authenticate(context.Background(), revoked.Key); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("Authenticate() after revoke error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestAPIKeyUseCaseCreateValidation(t *testing.T) {
+	uc := NewAPIKeyUseCase(newStubAPIKeyRepository(), Timeouts{}, zap.NewNop())
+	past := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		input domain.CreateAPIKeyInput
+		want  error
+	}{
+		{"not admin", ownerContext(), domain.CreateAPIKeyInput{Name: "x", Scopes: []domain.Scope{domain.ScopeRead}}, domain.ErrForbidden},
+		{"no principal", context.Background(), domain.CreateAPIKeyInput{Name: "x", Scopes: []domain.Scope{domain.ScopeRead}}, domain.ErrUnauthorized},
+		{"unknown scope", adminContext(), domain.CreateAPIKeyInput{Name: "x", Scopes: []domain.Scope{"root"}}, domain.ErrInvalidScope},
+		{"no scopes", adminContext(), domain.CreateAPIKeyInput{Name: "x"}, domain.ErrInvalidScope},
+		{"expired", adminContext(), domain.CreateAPIKeyInput{Name: "x", Scopes: []domain.Scope{domain.ScopeRead}, ExpiresAt: &past}, domain.ErrInvalidExpiration},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := uc.Create(tt.ctx, &tt.input); !errors.Is(err, tt.want) {
+				t.Errorf("Create() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIKeyUseCaseTouchLastUsedOncePerInterval(t *testing.T) {
+	repo := newStubAPIKeyRepository()
+	uc := NewAPIKeyUseCase(repo, Timeouts{}, zap.NewNop())
+	created := createAPIKey(t, uc, domain.CreateAPIKeyInput{Name: "busy", Scopes: []domain.Scope{domain.ScopeRead}})
+
+	// 并发请求读到的最近使用时间相同，只更新一次
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := uc.Authenticate(context.Background(), created.Key); err != nil {
+				t.Errorf("Authenticate() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for repo.touchCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := uc.Authenticate(context.Background(), created.Key); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if n := repo.touchCount(); n != 1 {
+		t.Errorf("TouchLastUsed() called %d times, want 1", n)
+	}
+
+	// 超过更新间隔后再次更新
+	old := time.Now().Add(-2 * lastUsedInterval)
+	repo.mu.Lock()
+	repo.keys[created.ID].LastUsedAt = &old
+	repo.mu.Unlock()
+	u := uc.(*APIKeyUseCase)
+	u.touchMu.Lock()
+	u.touched[created.ID] = old
+	u.touchMu.Unlock()
+	if _, err := uc.Authenticate(context.Background(), created.Key); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	deadline = time.Now().Add(5 * time.Second)
+	for repo.touchCount() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := repo.touchCount(); n != 2 {
+		t.Errorf("TouchLastUsed() called %d times after the interval, want 2", n)
+	}
+}
@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"fmt"
+	"net/url"
+
+	"linkit/internal/domain"
+)
+
+// maxAppURLLength App深度链接和应用商店地址的最大长度
+const maxAppURLLength = 2048
+
+// blockedAppSchemes 不允许用作深度链接的scheme，这些地址会在中间页中执行脚本或读取本地内容
+var blockedAppSchemes = map[string]bool{
+	"javascript": true,
+	"vbscript":   true,
+	"data":       true,
+	"blob":       true,
+	"file":       true,
+	"about":      true,
+}
+
+// validateAppURL 验证App深度链接或应用商店地址，允许自定义scheme，http(s)地址必须包含主机名
+func validateAppURL(field, appURL string) error {
+	if len(appURL) > maxAppURLLength {
+		return fmt.Errorf("%w: %s too long", domain.ErrInvalidAppLink, field)
+	}
+	parsed, err := url.Parse(appURL)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", domain.ErrInvalidAppLink, field, err)
+	}
+	switch {
+	case parsed.Scheme == "":
+		return fmt.Errorf("%w: %s has no scheme", domain.ErrInvalidAppLink, field)
+	case blockedAppSchemes[parsed.Scheme]:
+		return fmt.Errorf("%w: %s scheme %q not allowed", domain.ErrInvalidAppLink, field, parsed.Scheme)
+	case (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host == "":
+		return fmt.Errorf("%w: %s has no host", domain.ErrInvalidAppLink, field)
+	}
+	return nil
+}
+
+// validateAppLinks 验证短链接的App深度链接配置，应用商店地址需要和同一系统的深度链接一起设置
+func validateAppLinks(links domain.AppLinks) error {
+	for _, os := range []struct {
+		name     string
+		deepLink string
+		fallback string
+	}{
+		{"ios", links.IOSURL, links.IOSFallbackURL},
+		{"android", links.AndroidURL, links.AndroidFallbackURL},
+	} {
+		if os.deepLink == "" {
+			if os.fallback != "" {
+				return fmt.Errorf("%w: %s_fallback_url requires %s_url", domain.ErrInvalidAppLink, os.name, os.name)
+			}
+			continue
+		}
+		if err := validateAppURL(os.name+"_url", os.deepLink); err != nil {
+			return err
+		}
+		if os.fallback != "" {
+			if err := validateAppURL(os.name+"_fallback_url", os.fallback); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// appLaunch 返回访问者系统对应的App跳转，未配置深度链接时返回nil
+// 未设置应用商店地址时回退到网页目标
+func appLaunch(links domain.AppLinks, os domain.MobileOS, webTarget string) *domain.AppLaunch {
+	deepLink, fallback := links.For(os)
+	if deepLink == "" {
+		return nil
+	}
+	if fallback == "" {
+		fallback = webTarget
+	}
+	return &domain.AppLaunch{URL: deepLink, Fallback: fallback}
+}
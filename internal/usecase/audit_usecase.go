@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"linkit/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+// auditIgnoredFields 计算审计差异时忽略的字段，这些字段会随访问或保存自动变化
+var auditIgnoredFields = map[string]bool{
+	"clicks":     true,
+	"updated_at": true,
+	"rules":      true,
+}
+
+// auditDiff 计算修改前后发生变化的字段
+// 两者均为JSON对象时只保留发生变化的字段，否则保存完整数据，nil 表示不存在
+func auditDiff(before, after interface{}) (domain.AuditData, domain.AuditData, error) {
+	marshal := func(v interface{}) (map[string]json.RawMessage, []byte, error) {
+		if v == nil {
+			return nil, nil, nil
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, nil, err
+		}
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(data, &fields) != nil {
+			return nil, data, nil
+		}
+		for k := range fields {
+			if auditIgnoredFields[k] {
+				delete(fields, k)
+			}
+		}
+		return fields, data, nil
+	}
+
+	beforeFields, beforeData, err := marshal(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterFields, afterData, err := marshal(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch {
+	case beforeFields != nil && afterFields != nil:
+		for k, v := range beforeFields {
+			if bytes.Equal(v, afterFields[k]) {
+				delete(beforeFields, k)
+				delete(afterFields, k)
+			}
+		}
+	case beforeFields != nil:
+		afterFields = nil
+	case afterFields != nil:
+		beforeFields = nil
+	default:
+		return beforeData, afterData, nil
+	}
+
+	encode := func(fields map[string]json.RawMessage) (domain.AuditData, error) {
+		if fields == nil {
+			return nil, nil
+		}
+		return json.Marshal(fields)
+	}
+	if beforeData, err = encode(beforeFields); err != nil {
+		return nil, nil, err
+	}
+	if afterData, err = encode(afterFields); err != nil {
+		return nil, nil, err
+	}
+	return beforeData, afterData, nil
+}
+
+// recordAudit 记录管理操作的审计日志
+// 操作已经生效，写入失败时只记录错误日志，不影响请求结果
+func (u *ShortLinkUseCase) recordAudit(ctx context.Context, action domain.AuditAction, link *domain.ShortLink, ruleID *uint, before, after interface{}) {
+	log := u.log(ctx)
+	p, err := principal(ctx)
+	if err != nil {
+		log.Error("failed to record audit log", zap.String("action", string(action)), zap.Error(err))
+		return
+	}
+
+	entry := &domain.AuditLog{
+		Action:      action,
+		ShortLinkID: link.ID,
+		ShortCode:   link.ShortCode,
+		RuleID:      ruleID,
+		WorkspaceID: link.WorkspaceID,
+		ActorID:     p.UserID,
+		APIKeyID:    p.APIKeyID,
+		IP:          p.IP,
+	}
+	if entry.Before, entry.After, err = auditDiff(before, after); err != nil {
+		log.Error("failed to encode audit log", zap.String("action", string(action)), zap.Error(err))
+		return
+	}
+
+	// 请求超时或取消不应导致审计日志丢失
+	ctx, cancel := withTimeout(context.WithoutCancel(ctx), u.timeouts.Write)
+	defer cancel()
+	if err := u.audit.Create(ctx, entry); err != nil {
+		log.Error("failed to record audit log",
+			zap.String("action", string(action)), zap.String("code", link.ShortCode), zap.Error(err))
+	}
+}
+
+// AuditUseCase 实现审计日志用例接口
+type AuditUseCase struct {
+	repo     domain.AuditRepository
+	timeouts Timeouts
+	logger   *zap.Logger
+}
+
+// NewAuditUseCase 创建审计日志用例实例
+func NewAuditUseCase(repo domain.AuditRepository, timeouts Timeouts, logger *zap.Logger) domain.AuditUseCase {
+	return &AuditUseCase{
+		repo:     repo,
+		timeouts: timeouts,
+		logger:   logger.Named("audit"),
+	}
+}
+
+// List 查询审计日志
+// 工作空间内需要所有者角色，只能查看该空间的日志；个人空间只能查看自己的操作，管理员可以查看所有日志
+func (u *AuditUseCase) List(ctx context.Context, query *domain.AuditQuery) (*domain.PaginatedAuditLogs, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Read)
+	defer cancel()
+
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Can(domain.PermAuditRead) {
+		return nil, domain.ErrForbidden
+	}
+	if p.WorkspaceID != 0 || !p.IsAdmin() {
+		if query.Filter == nil {
+			query.Filter = &domain.AuditFilter{}
+		}
+		workspaceID := p.WorkspaceID
+		query.Filter.WorkspaceID = &workspaceID
+		if workspaceID == 0 {
+			actorID := p.UserID
+			query.Filter.ActorID = &actorID
+		}
+	}
+
+	return u.repo.List(ctx, query)
+}
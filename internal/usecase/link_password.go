@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"linkit/internal/domain"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// minPasswordLength 访问密码的最小长度(字节)
+	minPasswordLength = 4
+	// maxPasswordLength 访问密码的最大长度(字节)，bcrypt 只使用前72字节
+	maxPasswordLength = 72
+
+	defaultUnlockTTL = time.Hour
+)
+
+// PasswordConfig 密码保护短链接的配置
+type PasswordConfig struct {
+	Secret    []byte        // 解锁令牌的签名密钥，多个实例需要使用相同的密钥
+	UnlockTTL time.Duration // 输入密码后免密访问的有效期
+}
+
+// hashPassword 校验并计算访问密码的哈希，空密码表示不需要密码
+func hashPassword(password string) (string, error) {
+	if password == "" {
+		return "", nil
+	}
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return "", fmt.Errorf("%w: length must be between %d and %d bytes", domain.ErrInvalidPassword, minPasswordLength, maxPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// signUnlock 计算解锁令牌，令牌格式为 <过期时间戳>.<签名>
+// 签名包含密码哈希，修改或取消密码后已签发的令牌全部失效
+func (u *ShortLinkUseCase) signUnlock(code, passwordHash string, expires int64) string {
+	mac := hmac.New(sha256.New, u.config.Password.Secret)
+	fmt.Fprintf(mac, "%s\n%d\n%s", code, expires, passwordHash)
+	return strconv.FormatInt(expires, 10) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// unlocked 判断context中是否携带了短链接有效的解锁令牌
+func (u *ShortLinkUseCase) unlocked(ctx context.Context, code string, link *domain.ShortLink) bool {
+	token, ok := domain.UnlockTokenFromContext(ctx)
+	if !ok {
+		return false
+	}
+	expStr, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	expires, err := strconv.ParseInt(expStr, 10, 64)
+	if err != nil || time.Now().Unix() >= expires {
+		return false
+	}
+	return hmac.Equal([]byte(token), []byte(u.signUnlock(code, link.PasswordHash, expires)))
+}
+
+// Unlock 校验密码保护短链接的访问密码，返回解锁令牌及其过期时间
+// 同一IP对同一短链接的尝试次数受限，超出后在窗口内直接拒绝，不再校验密码
+func (u *ShortLinkUseCase) Unlock(ctx context.Context, code, password, ip string) (string, time.Time, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Redirect)
+	defer cancel()
+
+	log := u.log(ctx).With(zap.String("code", code))
+
+	shortLink, err := u.repo.GetByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, domain.ErrShortLinkNotFound) {
+			return "", time.Time{}, domain.ErrShortLinkNotFound
+		}
+		return "", time.Time{}, fmt.Errorf("failed to get short link: %w", err)
+	}
+	if time.Now().After(shortLink.ExpiresAt) {
+		return "", time.Time{}, domain.ErrShortLinkExpired
+	}
+
+	ttl := u.config.Password.UnlockTTL
+	if ttl <= 0 {
+		ttl = defaultUnlockTTL
+	}
+	expiresAt := time.Now().Add(ttl)
+
+	// 未设置密码的短链接无需解锁，直接签发令牌
+	if !shortLink.PasswordProtected() {
+		return u.signUnlock(code, "", expiresAt.Unix()), expiresAt, nil
+	}
+
+	// 计数器不可用时放行，避免缓存故障导致无法访问
+	if u.unlocks != nil {
+		allowed, retryAfter, err := u.unlocks.Attempt(ctx, code+":"+ip)
+		if err != nil {
+			log.Warn("password attempt check failed, attempt allowed", zap.Error(err))
+		} else if !allowed {
+			log.Info("password attempts locked", zap.String("ip", ip), zap.Duration("retry_after", retryAfter))
+			return "", time.Time{}, domain.ErrPasswordLocked
+		}
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(shortLink.PasswordHash), []byte(password)); err != nil {
+		log.Info("wrong link password", zap.String("ip", ip))
+		return "", time.Time{}, domain.ErrWrongPassword
+	}
+
+	log.Debug("short link unlocked", zap.String("ip", ip))
+	return u.signUnlock(code, shortLink.PasswordHash, expiresAt.Unix()), expiresAt, nil
+}
@@ -0,0 +1,206 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/cache"
+	"linkit/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// failingAttemptLimiter 模拟计数器不可用
+type failingAttemptLimiter struct{}
+
+func (failingAttemptLimiter) Attempt(context.Context, string) (bool, time.Duration, error) {
+	return false, 0, errors.New("connection refused")
+}
+
+// newPasswordUseCase 创建配置了解锁签名密钥和尝试次数限制的短链接用例
+func newPasswordUseCase(secret string, unlocks domain.AttemptLimiter) (*ShortLinkUseCase, domain.ShortLinkRepository) {
+	repo := repository.NewMemoryShortLinkRepository()
+	config := Config{CodeLength: 6, Password: PasswordConfig{Secret: []byte(secret), UnlockTTL: time.Hour}}
+	uc := NewShortLinkUseCase(repo, stubAuditRepository{}, nil, nil, nil, unlocks, config, zap.NewNop())
+	return uc.(*ShortLinkUseCase), repo
+}
+
+// createProtectedLink 创建密码保护的短链接
+func createProtectedLink(t *testing.T, uc *ShortLinkUseCase, code, password string) *domain.ShortLink {
+	t.Helper()
+	link, err := uc.Create(ownerContext(), &domain.CreateShortLinkInput{
+		LongURL:    "https://example.com/" + code,
+		CustomCode: code,
+		Password:   password,
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	return link
+}
+
+// redirectWithToken 携带解锁令牌访问短链接
+func redirectWithToken(uc *ShortLinkUseCase, code, token string) error {
+	ctx := context.Background()
+	if token != "" {
+		ctx = domain.WithUnlockToken(ctx, token)
+	}
+	_, err := uc.Redirect(ctx, code, domain.RedirectRequest{}, &domain.ClickLog{})
+	return err
+}
+
+func TestShortLinkUseCaseUnlockToken(t *testing.T) {
+	uc, repo := newPasswordUseCase("secret", nil)
+	createProtectedLink(t, uc, "locked", "open-sesame")
+	createProtectedLink(t, uc, "other", "open-sesame")
+
+	if err := redirectWithToken(uc, "locked", ""); !errors.Is(err, domain.ErrPasswordRequired) {
+		t.Fatalf("Redirect() without token error = %v, want ErrPasswordRequired", err)
+	}
+	if _, _, err := uc.Unlock(context.Background(), "locked", "wrong", "192.0.2.1"); !errors.Is(err, domain.ErrWrongPassword) {
+		t.Errorf("Unlock() with wrong password error = %v, want ErrWrongPassword", err)
+	}
+
+	token, expiresAt, err := uc.Unlock(context.Background(), "locked", "open-sesame", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+	if d := time.Until(expiresAt); d <= 0 || d > time.Hour {
+		t.Errorf("Unlock() expires in %v, want within the unlock ttl", d)
+	}
+	if err := redirectWithToken(uc, "locked", token); err != nil {
+		t.Fatalf("Redirect() with token error = %v", err)
+	}
+
+	exp, sig, _ := strings.Cut(token, ".")
+	expires, _ := strconv.ParseInt(exp, 10, 64)
+	link, err := repo.GetByCode(context.Background(), "locked")
+	if err != nil {
+		t.Fatalf("GetByCode() error = %v", err)
+	}
+	otherSecret, _ := newPasswordUseCase("another secret", nil)
+
+	tests := []struct {
+		name  string
+		code  string
+		token string
+	}{
+		{"empty", "locked", "."},
+		{"no separator", "locked", exp + sig},
+		{"no expiry", "locked", "." + sig},
+		{"no signature", "locked", exp + "."},
+		{"invalid expiry", "locked", "soon." + sig},
+		{"forged signature", "locked", exp + "." + strings.Repeat("A", len(sig))},
+		{"extended expiry", "locked", strconv.FormatInt(expires+3600, 10) + "." + sig},
+		{"another link", "other", token},
+		{"another secret", "locked", otherSecret.signUnlock("locked", link.PasswordHash, expires)},
+		{"without password hash", "locked", uc.signUnlock("locked", "", expires)},
+		{"expired", "locked", uc.signUnlock("locked", link.PasswordHash, time.Now().Add(-time.Second).Unix())},
+		{"expires now", "locked", uc.signUnlock("locked", link.PasswordHash, time.Now().Unix())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := redirectWithToken(uc, tt.code, tt.token); !errors.Is(err, domain.ErrPasswordRequired) {
+				t.Errorf("Redirect(%s) with token %q error = %v, want ErrPasswordRequired", tt.code, tt.token, err)
+			}
+		})
+	}
+}
+
+func TestShortLinkUseCaseUnlockTokenPasswordChanged(t *testing.T) {
+	uc, _ := newPasswordUseCase("secret", nil)
+	createProtectedLink(t, uc, "locked", "open-sesame")
+
+	token, _, err := uc.Unlock(context.Background(), "locked", "open-sesame", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	// 修改密码后已签发的令牌失效，即使设置为相同的密码
+	for _, password := range []string{"new-password", "open-sesame"} {
+		if _, err := uc.Update(ownerContext(), "locked", &domain.UpdateShortLinkInput{Password: &password}); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		if err := redirectWithToken(uc, "locked", token); !errors.Is(err, domain.ErrPasswordRequired) {
+			t.Errorf("Redirect() with token issued before setting password %q error = %v, want ErrPasswordRequired", password, err)
+		}
+		if token, _, err = uc.Unlock(context.Background(), "locked", password, "192.0.2.1"); err != nil {
+			t.Fatalf("Unlock() error = %v", err)
+		}
+	}
+
+	// 取消密码后无需令牌，重新设置密码后取消前的令牌不能复用
+	none := ""
+	if _, err := uc.Update(ownerContext(), "locked", &domain.UpdateShortLinkInput{Password: &none}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := redirectWithToken(uc, "locked", ""); err != nil {
+		t.Fatalf("Redirect() after removing password error = %v", err)
+	}
+	publicToken, _, err := uc.Unlock(context.Background(), "locked", "", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Unlock() without password error = %v", err)
+	}
+	password := "open-sesame"
+	if _, err := uc.Update(ownerContext(), "locked", &domain.UpdateShortLinkInput{Password: &password}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	for _, old := range []string{token, publicToken} {
+		if err := redirectWithToken(uc, "locked", old); !errors.Is(err, domain.ErrPasswordRequired) {
+			t.Errorf("Redirect() with token %q after password reset error = %v, want ErrPasswordRequired", old, err)
+		}
+	}
+}
+
+func TestShortLinkUseCaseUnlockLockout(t *testing.T) {
+	limiter := repository.NewAttemptLimiter(cache.NewMemoryCache(0), "unlock", 3, time.Minute)
+	uc, _ := newPasswordUseCase("secret", limiter)
+	createProtectedLink(t, uc, "locked", "open-sesame")
+	createProtectedLink(t, uc, "other", "open-sesame")
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := uc.Unlock(ctx, "locked", "guess", "192.0.2.1"); !errors.Is(err, domain.ErrWrongPassword) {
+			t.Fatalf("Unlock() attempt %d error = %v, want ErrWrongPassword", i+1, err)
+		}
+	}
+	// 超出次数后即使密码正确也拒绝
+	if _, _, err := uc.Unlock(ctx, "locked", "open-sesame", "192.0.2.1"); !errors.Is(err, domain.ErrPasswordLocked) {
+		t.Errorf("Unlock() after lockout error = %v, want ErrPasswordLocked", err)
+	}
+	// 计数按短链接和IP区分
+	if _, _, err := uc.Unlock(ctx, "locked", "open-sesame", "192.0.2.2"); err != nil {
+		t.Errorf("Unlock() from another IP error = %v", err)
+	}
+	if _, _, err := uc.Unlock(ctx, "other", "open-sesame", "192.0.2.1"); err != nil {
+		t.Errorf("Unlock() of another link error = %v", err)
+	}
+
+	// 成功的尝试同样计数
+	for i := 0; i < 2; i++ {
+		if _, _, err := uc.Unlock(ctx, "other", "open-sesame", "192.0.2.1"); err != nil {
+			t.Fatalf("Unlock() error = %v", err)
+		}
+	}
+	if _, _, err := uc.Unlock(ctx, "other", "open-sesame", "192.0.2.1"); !errors.Is(err, domain.ErrPasswordLocked) {
+		t.Errorf("Unlock() beyond the limit error = %v, want ErrPasswordLocked", err)
+	}
+}
+
+func TestShortLinkUseCaseUnlockLimiterUnavailable(t *testing.T) {
+	uc, _ := newPasswordUseCase("secret", failingAttemptLimiter{})
+	createProtectedLink(t, uc, "locked", "open-sesame")
+
+	// 计数器不可用时放行，仍然校验密码
+	if _, _, err := uc.Unlock(context.Background(), "locked", "guess", "192.0.2.1"); !errors.Is(err, domain.ErrWrongPassword) {
+		t.Errorf("Unlock() with wrong password error = %v, want ErrWrongPassword", err)
+	}
+	if _, _, err := uc.Unlock(context.Background(), "locked", "open-sesame", "192.0.2.1"); err != nil {
+		t.Errorf("Unlock() error = %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// MeteringUseCase 实现用量计量用例接口
+type MeteringUseCase struct {
+	repo     domain.MeteringRepository
+	timeouts Timeouts
+	logger   *zap.Logger
+}
+
+// NewMeteringUseCase 创建用量计量用例实例
+func NewMeteringUseCase(repo domain.MeteringRepository, timeouts Timeouts, logger *zap.Logger) domain.MeteringUseCase {
+	return &MeteringUseCase{
+		repo:     repo,
+		timeouts: timeouts,
+		logger:   logger.Named("metering"),
+	}
+}
+
+// log 返回带有请求ID的日志实例
+func (u *MeteringUseCase) log(ctx context.Context) *zap.Logger {
+	return logger.FromContext(ctx, u.logger)
+}
+
+// periodStart 获取日期所在统计周期的开始时间
+func periodStart(day time.Time, granularity domain.UsageGranularity) time.Time {
+	if granularity == domain.GranularityMonth {
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
+
+// periodEnd 获取统计周期的结束时间(不含)
+func periodEnd(start time.Time, granularity domain.UsageGranularity) time.Time {
+	if granularity == domain.GranularityMonth {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// Export 导出用量计量记录
+// 管理员可以导出所有工作空间，工作空间内需要 owner 角色且只能导出该空间；
+// 当天和前一天尚未结束汇总，导出前先按实时数据重新汇总
+func (u *MeteringUseCase) Export(ctx context.Context, query *domain.UsageExportQuery) (*domain.UsageExport, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Read)
+	defer cancel()
+
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	q := query.UsageRecordQuery
+	switch {
+	case p.WorkspaceID != 0:
+		if !p.Can(domain.PermUsageExport) {
+			return nil, domain.ErrForbidden
+		}
+		workspaceID := p.WorkspaceID
+		q.WorkspaceID = &workspaceID
+	case !p.IsAdmin():
+		return nil, domain.ErrForbidden
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+		if day.Before(q.Start) || !day.Before(q.End) {
+			continue
+		}
+		if err := u.repo.Aggregate(ctx, day); err != nil {
+			return nil, err
+		}
+	}
+
+	records, err := u.repo.List(ctx, &q)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按工作空间和统计周期合并每日记录
+	type periodKey struct {
+		workspaceID uint
+		start       time.Time
+	}
+	periods := make(map[periodKey]*domain.UsagePeriod)
+	for _, r := range records {
+		key := periodKey{r.WorkspaceID, periodStart(r.Day, query.Granularity)}
+		period, ok := periods[key]
+		if !ok {
+			// 统计周期不超出导出范围
+			period = &domain.UsagePeriod{
+				WorkspaceID: r.WorkspaceID,
+				PeriodStart: key.start,
+				PeriodEnd:   periodEnd(key.start, query.Granularity),
+			}
+			if period.PeriodStart.Before(q.Start) {
+				period.PeriodStart = q.Start
+			}
+			if period.PeriodEnd.After(q.End) {
+				period.PeriodEnd = q.End
+			}
+			periods[key] = period
+		}
+		period.LinksCreated += r.LinksCreated
+		period.Redirects += r.Redirects
+		period.ClickLogs += r.ClickLogs
+	}
+
+	result := &domain.UsageExport{
+		Start:       q.Start,
+		End:         q.End,
+		Granularity: query.Granularity,
+		GeneratedAt: time.Now().UTC(),
+		Records:     make([]domain.UsagePeriod, 0, len(periods)),
+	}
+	for _, period := range periods {
+		result.Records = append(result.Records, *period)
+	}
+	sort.Slice(result.Records, func(i, j int) bool {
+		a, b := result.Records[i], result.Records[j]
+		if !a.PeriodStart.Equal(b.PeriodStart) {
+			return a.PeriodStart.Before(b.PeriodStart)
+		}
+		return a.WorkspaceID < b.WorkspaceID
+	})
+
+	u.log(ctx).Info("usage exported",
+		zap.Uint("user_id", p.UserID), zap.Time("start", q.Start), zap.Time("end", q.End),
+		zap.String("granularity", string(query.Granularity)), zap.Int("records", len(result.Records)))
+	return result, nil
+}
@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"linkit/internal/domain"
+)
+
+// queryPair 查询字符串中的一个参数，raw 为编码后的原始文本
+type queryPair struct {
+	key string
+	raw string
+}
+
+// splitQuery 按顺序拆分查询字符串，无法解码的参数名按原始文本比较
+func splitQuery(rawQuery string) []queryPair {
+	var pairs []queryPair
+	for _, part := range strings.Split(rawQuery, "&") {
+		if part == "" {
+			continue
+		}
+		rawKey, _, _ := strings.Cut(part, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		pairs = append(pairs, queryPair{key: key, raw: part})
+	}
+	return pairs
+}
+
+// visitorQuery 拆分访问者的查询字符串，忽略参数名为空或无法解码的参数
+func visitorQuery(rawQuery string) []queryPair {
+	pairs := splitQuery(rawQuery)
+	valid := pairs[:0]
+	for _, p := range pairs {
+		rawKey, _, _ := strings.Cut(p.raw, "=")
+		if _, err := url.QueryUnescape(rawKey); err != nil || p.key == "" {
+			continue
+		}
+		valid = append(valid, p)
+	}
+	return valid
+}
+
+// canonicalPair 重新编码访问者的查询参数，避免未编码的字符原样写入目标URL
+func canonicalPair(p queryPair) string {
+	rawKey, rawValue, hasValue := strings.Cut(p.raw, "=")
+	key, _ := url.QueryUnescape(rawKey)
+	if !hasValue {
+		return url.QueryEscape(key)
+	}
+	value, err := url.QueryUnescape(rawValue)
+	if err != nil {
+		value = rawValue
+	}
+	return url.QueryEscape(key) + "=" + url.QueryEscape(value)
+}
+
+// mergeQuery 按转发方式将访问者的查询参数合并到跳转目标
+// 目标URL中的参数保持原有编码和顺序，访问者的参数按原顺序追加在后面；片段(#...)始终保留在URL末尾
+// append 保留所有参数，同名参数中目标URL的值在前；override 先删除目标URL中与访问者同名的参数
+func mergeQuery(target, rawQuery string, mode domain.QueryPassthrough) (string, error) {
+	if rawQuery == "" || mode == "" || mode == domain.PassthroughOff {
+		return target, nil
+	}
+	visitor := visitorQuery(rawQuery)
+	if len(visitor) == 0 {
+		return target, nil
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse target url: %w", err)
+	}
+	existing := splitQuery(u.RawQuery)
+
+	parts := make([]string, 0, len(existing)+len(visitor))
+	switch mode {
+	case domain.PassthroughAppend:
+		// 不处理同名参数，只读取第一个值的服务端得到目标URL中的值
+		for _, p := range existing {
+			parts = append(parts, p.raw)
+		}
+		for _, p := range visitor {
+			parts = append(parts, canonicalPair(p))
+		}
+	case domain.PassthroughOverride:
+		// 访问者的参数优先，替换目标URL中的所有同名参数
+		override := make(map[string]bool, len(visitor))
+		for _, p := range visitor {
+			override[p.key] = true
+		}
+		for _, p := range existing {
+			if !override[p.key] {
+				parts = append(parts, p.raw)
+			}
+		}
+		for _, p := range visitor {
+			parts = append(parts, canonicalPair(p))
+		}
+	default:
+		return target, nil
+	}
+
+	u.RawQuery = strings.Join(parts, "&")
+	return u.String(), nil
+}
+
+// utmQuery 将UTM参数编码为查询字符串，为空的字段不写入
+func utmQuery(utm domain.UTM) string {
+	values := url.Values{}
+	for key, v := range map[string]string{
+		"utm_source":   utm.Source,
+		"utm_medium":   utm.Medium,
+		"utm_campaign": utm.Campaign,
+		"utm_term":     utm.Term,
+		"utm_content":  utm.Content,
+	} {
+		if v != "" {
+			values.Set(key, v)
+		}
+	}
+	return values.Encode()
+}
+
+// splitForwardPath 校验访问者请求的转发路径并返回去掉开头斜杠的原始编码路径
+// 路径段不能是 . 或 ..，不能包含编码的斜杠、反斜杠或控制字符，除末尾外不能为空，避免路径穿越或改变目标主机
+func splitForwardPath(rawPath string) (string, error) {
+	rawPath = strings.TrimPrefix(rawPath, "/")
+	if rawPath == "" {
+		return "", nil
+	}
+	segments := strings.Split(rawPath, "/")
+	for i, seg := range segments {
+		if seg == "" {
+			if i == len(segments)-1 {
+				continue
+			}
+			return "", fmt.Errorf("%w: empty segment", domain.ErrInvalidForwardPath)
+		}
+		decoded, err := url.PathUnescape(seg)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", domain.ErrInvalidForwardPath, err)
+		}
+		if decoded == "." || decoded == ".." {
+			return "", fmt.Errorf("%w: dot segment", domain.ErrInvalidForwardPath)
+		}
+		for _, r := range decoded {
+			if r == '/' || r == '\\' || r < 0x20 || r == 0x7f {
+				return "", fmt.Errorf("%w: invalid character in segment", domain.ErrInvalidForwardPath)
+			}
+		}
+	}
+	return rawPath, nil
+}
+
+// forwardPath 将已校验的转发路径拼接到跳转目标的路径之后，查询参数和片段保持不变
+// 只转发到带主机名的URL，拼接后的主机和scheme必须与原目标一致
+func forwardPath(target, rawPath string) (string, error) {
+	if rawPath == "" {
+		return target, nil
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse target url: %w", err)
+	}
+	if u.Opaque != "" || u.Host == "" {
+		return "", fmt.Errorf("target url %q does not accept a path", u.Redacted())
+	}
+	scheme, host := u.Scheme, u.Host
+
+	escaped := strings.TrimSuffix(u.EscapedPath(), "/") + "/" + rawPath
+	path, err := url.PathUnescape(escaped)
+	if err != nil {
+		return "", fmt.Errorf("failed to unescape path: %w", err)
+	}
+	u.Path, u.RawPath = path, escaped
+
+	forwarded := u.String()
+	check, err := url.Parse(forwarded)
+	if err != nil || check.Scheme != scheme || check.Host != host {
+		return "", fmt.Errorf("forwarded url changes the target host")
+	}
+	return forwarded, nil
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"linkit/internal/domain"
+)
+
+func TestMergeQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		query  string
+		mode   domain.QueryPassthrough
+		want   string
+	}{
+		{"off", "https://example.com/a?x=1", "ref=news", domain.PassthroughOff, "https://example.com/a?x=1"},
+		{"empty mode", "https://example.com/a", "ref=news", "", "https://example.com/a"},
+		{"unknown mode", "https://example.com/a", "ref=news", "merge", "https://example.com/a"},
+		{"empty query", "https://example.com/a?x=1", "", domain.PassthroughAppend, "https://example.com/a?x=1"},
+
+		{"append to bare target", "https://example.com/a", "ref=news&id=7", domain.PassthroughAppend, "https://example.com/a?ref=news&id=7"},
+		{"append after target params", "https://example.com/a?x=1", "ref=news", domain.PassthroughAppend, "https://example.com/a?x=1&ref=news"},
+		{"append keeps both values", "https://example.com/a?ref=site&x=1", "ref=news", domain.PassthroughAppend, "https://example.com/a?ref=site&x=1&ref=news"},
+		{"append repeated keys", "https://example.com/a?tag=a", "tag=b&tag=c", domain.PassthroughAppend, "https://example.com/a?tag=a&tag=b&tag=c"},
+
+		{"override replaces target params", "https://example.com/a?ref=site&x=1", "ref=news", domain.PassthroughOverride, "https://example.com/a?x=1&ref=news"},
+		{"override removes every target value", "https://example.com/a?tag=a&x=1&tag=b", "tag=c", domain.PassthroughOverride, "https://example.com/a?x=1&tag=c"},
+		{"override keeps repeated visitor keys", "https://example.com/a?tag=a", "tag=b&tag=c", domain.PassthroughOverride, "https://example.com/a?tag=b&tag=c"},
+
+		{"target encoding kept", "https://example.com/a?q=a%20b&s=x+y", "ref=news", domain.PassthroughAppend, "https://example.com/a?q=a%20b&s=x+y&ref=news"},
+		{"visitor values re-encoded", "https://example.com/a", "q=a b&next=/x?y=1&name=%E4%B8%AD", domain.PassthroughAppend, "https://example.com/a?q=a+b&next=%2Fx%3Fy%3D1&name=%E4%B8%AD"},
+		{"visitor encoded key matches target", "https://example.com/a?a+b=1", "a%20b=2", domain.PassthroughOverride, "https://example.com/a?a+b=2"},
+		{"visitor param without value", "https://example.com/a", "debug&ref=", domain.PassthroughAppend, "https://example.com/a?debug&ref="},
+		{"invalid visitor escapes", "https://example.com/a", "%zz=1&ok=%zz", domain.PassthroughAppend, "https://example.com/a?ok=%25zz"},
+		{"empty visitor keys ignored", "https://example.com/a", "=1&&ref=news", domain.PassthroughAppend, "https://example.com/a?ref=news"},
+		{"only ignored params", "https://example.com/a?x=1", "=1&&", domain.PassthroughAppend, "https://example.com/a?x=1"},
+
+		{"fragment stays last", "https://example.com/a?x=1#top", "ref=news", domain.PassthroughAppend, "https://example.com/a?x=1&ref=news#top"},
+		{"fragment on bare target", "https://example.com/a#section-2", "ref=news", domain.PassthroughOverride, "https://example.com/a?ref=news#section-2"},
+		{"visitor hash is a value", "https://example.com/a", "ref=%23news", domain.PassthroughAppend, "https://example.com/a?ref=%23news"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergeQuery(tt.target, tt.query, tt.mode)
+			if err != nil {
+				t.Fatalf("mergeQuery() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("mergeQuery() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeQueryInvalidTarget(t *testing.T) {
+	if _, err := mergeQuery("https://exa mple.com/%zz", "ref=news", domain.PassthroughAppend); err == nil {
+		t.Error("mergeQuery() with unparsable target error = nil")
+	}
+}
+
+func TestSplitForwardPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"empty", "", "", false},
+		{"root", "/", "", false},
+		{"segments", "/docs/install", "docs/install", false},
+		{"trailing slash", "/docs/", "docs/", false},
+		{"encoding kept", "/a%20b/%E4%B8%AD", "a%20b/%E4%B8%AD", false},
+		{"dots inside segment", "/v1.2/file..txt", "v1.2/file..txt", false},
+
+		{"dot dot", "/..", "", true},
+		{"dot dot in middle", "/docs/../admin", "", true},
+		{"single dot", "/./docs", "", true},
+		{"encoded dot dot", "/%2e%2e/admin", "", true},
+		{"encoded dot dot upper", "/docs/%2E%2E", "", true},
+		{"mixed dot dot", "/.%2e/admin", "", true},
+		{"encoded slash", "/a%2fb", "", true},
+		{"encoded slash upper", "/a%2F..%2Fb", "", true},
+		{"encoded backslash", "/a%5c..%5cb", "", true},
+		{"raw backslash", `/a\b`, "", true},
+		{"null byte", "/a%00b", "", true},
+		{"crlf", "/a%0d%0aLocation:%20x", "", true},
+		{"delete", "/a%7f", "", true},
+		{"tab", "/a%09b", "", true},
+		{"empty segment", "/a//b", "", true},
+		{"protocol relative", "//evil.example.com", "", true},
+		{"invalid escape", "/a%zz", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitForwardPath(tt.path)
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrInvalidForwardPath) {
+					t.Errorf("splitForwardPath(%q) error = %v, want ErrInvalidForwardPath", tt.path, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("splitForwardPath(%q) = %q, %v; want %q", tt.path, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestForwardPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"no path", "https://example.com/a", "", "https://example.com/a", false},
+		{"bare host", "https://example.com", "docs/install", "https://example.com/docs/install", false},
+		{"base path", "https://example.com/base", "docs", "https://example.com/base/docs", false},
+		{"base path with slash", "https://example.com/base/", "docs/", "https://example.com/base/docs/", false},
+		{"query and fragment kept", "https://example.com/a?x=1#top", "b", "https://example.com/a/b?x=1#top", false},
+		{"encoding kept", "https://example.com/a%20b", "c%2Bd", "https://example.com/a%20b/c%2Bd", false},
+		{"userinfo lookalike stays in path", "https://example.com", "@evil.example.com", "https://example.com/@evil.example.com", false},
+		{"host lookalike stays in path", "https://example.com", "evil.example.com:443", "https://example.com/evil.example.com:443", false},
+		{"port kept", "http://example.com:8080/a", "b", "http://example.com:8080/a/b", false},
+
+		{"opaque target", "mailto:team@example.com", "x", "", true},
+		{"scheme without slashes", "https:example.com", "x", "", true},
+		{"relative target", "/local", "x", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := forwardPath(tt.target, tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("forwardPath(%q, %q) = %s, want error", tt.target, tt.path, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("forwardPath(%q, %q) = %s, %v; want %s", tt.target, tt.path, got, err, tt.want)
+			}
+		})
+	}
+}
@@ -1,27 +1,116 @@
 package usecase
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net/url"
 	"time"
 
 	"linkit/internal/domain"
+	"linkit/internal/infrastructure/logger"
+	"linkit/internal/infrastructure/metrics"
 	"linkit/pkg/utils"
 
-	"github.com/spf13/viper"
+	"go.uber.org/zap"
 )
 
+// Timeouts 各类操作的超时时间，0表示不单独设置超时，仅随请求取消
+type Timeouts struct {
+	Redirect time.Duration // 跳转，包括查询短链接、匹配规则和记录点击
+	Read     time.Duration // 查询短链接、规则和访问记录
+	Write    time.Duration // 创建、更新和删除
+}
+
+// Config 短链接用例配置
+type Config struct {
+	CodeLength        int            // 生成短码的长度
+	DefaultExpireDays int            // 未指定过期时间时的默认有效期(天)，0表示永不过期
+	Timeouts          Timeouts       // 各类操作的超时时间
+	Quotas            Quotas         // 用量配额
+	Password          PasswordConfig // 密码保护
+}
+
 // ShortLinkUseCase 实现短链接用例接口
 type ShortLinkUseCase struct {
-	repo domain.ShortLinkRepository
+	repo       domain.ShortLinkRepository
+	audit      domain.AuditRepository
+	usage      domain.UsageRepository
+	workspaces domain.WorkspaceRepository
+	metering   domain.MeteringRepository
+	unlocks    domain.AttemptLimiter
+	config     Config
+	timeouts   Timeouts
+	logger     *zap.Logger
 }
 
-// NewShortLinkUseCase 创建短链接用例实例
-func NewShortLinkUseCase(repo domain.ShortLinkRepository) domain.ShortLinkUseCase {
+// NewShortLinkUseCase 创建短链接用例实例，metering 为nil时不计量跳转次数，unlocks 为nil时不限制密码尝试次数
+// workspaces 用于在预览页中展示短链接所属工作空间的名称，为nil时不展示
+func NewShortLinkUseCase(repo domain.ShortLinkRepository, audit domain.AuditRepository, usage domain.UsageRepository, workspaces domain.WorkspaceRepository, metering domain.MeteringRepository, unlocks domain.AttemptLimiter, config Config, logger *zap.Logger) domain.ShortLinkUseCase {
 	return &ShortLinkUseCase{
-		repo: repo,
+		repo:       repo,
+		audit:      audit,
+		usage:      usage,
+		workspaces: workspaces,
+		metering:   metering,
+		unlocks:    unlocks,
+		config:     config,
+		timeouts:   config.Timeouts,
+		logger:     logger.Named("usecase"),
+	}
+}
+
+// log 返回带有请求ID的日志实例
+func (u *ShortLinkUseCase) log(ctx context.Context) *zap.Logger {
+	return logger.FromContext(ctx, u.logger)
+}
+
+// canAccess 判断调用者能否访问短链接
+// 工作空间内只能访问该空间的短链接；个人空间只能访问自己创建的短链接，管理员可以访问所有短链接
+func canAccess(p *domain.Principal, link *domain.ShortLink) bool {
+	if p.WorkspaceID != 0 {
+		return link.WorkspaceID == p.WorkspaceID
+	}
+	if p.IsAdmin() {
+		return true
+	}
+	return link.WorkspaceID == 0 && link.UserID == p.UserID
+}
+
+// getOwnedLink 获取调用者有权访问的短链接，并检查调用者在当前空间中的权限
+// 短链接不在调用者可访问的范围内时按不存在处理，避免泄露短码是否被使用
+func (u *ShortLinkUseCase) getOwnedLink(ctx context.Context, code string, perm domain.Permission) (*domain.ShortLink, error) {
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Can(perm) {
+		return nil, domain.ErrForbidden
 	}
+
+	link, err := u.repo.GetByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, domain.ErrShortLinkNotFound) {
+			return nil, domain.ErrShortLinkNotFound
+		}
+		return nil, fmt.Errorf("failed to get short link: %w", err)
+	}
+
+	if !canAccess(p, link) {
+		u.log(ctx).Debug("short link not accessible",
+			zap.String("code", code), zap.Uint("user_id", p.UserID), zap.Uint("workspace_id", p.WorkspaceID))
+		return nil, domain.ErrShortLinkNotFound
+	}
+	return link, nil
+}
+
+// withTimeout 为操作设置超时时间
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
 }
 
 // validateURL 验证URL的安全性
@@ -36,6 +125,9 @@ func (u *ShortLinkUseCase) validateURL(longURL string) error {
 	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
 		return fmt.Errorf("%w: invalid scheme", domain.ErrInvalidURL)
 	}
+	if parsedURL.Host == "" {
+		return fmt.Errorf("%w: missing host", domain.ErrInvalidURL)
+	}
 
 	// 检查URL长度
 	if len(longURL) > 2048 {
@@ -45,26 +137,40 @@ func (u *ShortLinkUseCase) validateURL(longURL string) error {
 	return nil
 }
 
+// validateRule 验证跳转规则的输入，目标URL为空时使用短链接的原始URL
+func (u *ShortLinkUseCase) validateRule(input *domain.CreateRuleInput) error {
+	if input.TargetURL != "" {
+		if err := u.validateURL(input.TargetURL); err != nil {
+			return err
+		}
+	}
+	if !input.UTM.Valid() {
+		return domain.ErrInvalidUTM
+	}
+	return nil
+}
+
 // matchRule 检查规则是否匹配
-func (u *ShortLinkUseCase) matchRule(rule *domain.RedirectRule, clickLog *domain.ClickLog) bool {
-	fmt.Printf("[规则] 正在匹配: %s\n", rule.Name)
-	fmt.Printf("      访问者: %s (%s)\n", clickLog.IP, clickLog.Country)
+func (u *ShortLinkUseCase) matchRule(log *zap.Logger, rule *domain.RedirectRule, clickLog *domain.ClickLog) bool {
+	reject := func(reason string, fields ...zap.Field) bool {
+		if ce := log.Check(zap.DebugLevel, "rule not matched"); ce != nil {
+			ce.Write(append([]zap.Field{zap.Uint("rule_id", rule.ID), zap.String("reason", reason)}, fields...)...)
+		}
+		return false
+	}
 
 	// 检查设备类型
 	if rule.Device != domain.DeviceAll && rule.Device != clickLog.Device {
-		fmt.Printf("      ✗ 设备不匹配\n")
-		return false
+		return reject("device")
 	}
 
 	// 检查时间范围
 	now := time.Now()
 	if rule.StartTime != nil && now.Before(*rule.StartTime) {
-		fmt.Printf("      ✗ 未到开始时间\n")
-		return false
+		return reject("not_started")
 	}
 	if rule.EndTime != nil && now.After(*rule.EndTime) {
-		fmt.Printf("      ✗ 已过结束时间\n")
-		return false
+		return reject("ended")
 	}
 
 	// 检查地区匹配
@@ -81,8 +187,7 @@ func (u *ShortLinkUseCase) matchRule(rule *domain.RedirectRule, clickLog *domain
 				}
 			}
 			if !matched {
-				fmt.Printf("      ✗ 国家不匹配: %s\n", region.Country)
-				return false
+				return reject("country", zap.String("country", region.Country))
 			}
 		}
 
@@ -96,8 +201,7 @@ func (u *ShortLinkUseCase) matchRule(rule *domain.RedirectRule, clickLog *domain
 				}
 			}
 			if !matched {
-				fmt.Printf("      ✗ 省份不匹配: %s\n", region.Province)
-				return false
+				return reject("province", zap.String("province", region.Province))
 			}
 		}
 
@@ -111,46 +215,69 @@ func (u *ShortLinkUseCase) matchRule(rule *domain.RedirectRule, clickLog *domain
 				}
 			}
 			if !matched {
-				fmt.Printf("      ✗ 城市不匹配: %s\n", region.City)
-				return false
+				return reject("city", zap.String("city", region.City))
 			}
 		}
 	}
 
-	// 检查访问次数
-	if rule.MaxVisits != nil && *rule.MaxVisits <= 0 {
-		fmt.Printf("      ✓ 无访问次数限制\n")
-	} else if rule.MaxVisits != nil {
-		fmt.Printf("      ℹ️ 访问限制: %d (当前暂不检查具体次数)\n", *rule.MaxVisits)
-	}
+	// 规则的访问次数限制(MaxVisits)当前暂不检查
 
 	// 检查百分比
 	if rule.Percentage != nil {
 		randNum := rand.Intn(100) + 1
 		if randNum > *rule.Percentage {
-			fmt.Printf("      ✗ 未通过流量限制\n")
-			return false
+			return reject("percentage")
 		}
 	}
 
-	fmt.Printf("      ✓ 规则匹配成功\n")
 	return true
 }
 
 // Create 创建短链接
-func (u *ShortLinkUseCase) Create(input *domain.CreateShortLinkInput) (*domain.ShortLink, error) {
+func (u *ShortLinkUseCase) Create(ctx context.Context, input *domain.CreateShortLinkInput) (*domain.ShortLink, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Write)
+	defer cancel()
+
+	// 短链接属于调用者的当前空间
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Can(domain.PermLinkWrite) {
+		return nil, domain.ErrForbidden
+	}
+
 	// 验证URL安全性
 	if err := u.validateURL(input.LongURL); err != nil {
 		return nil, err
 	}
 
+	passwordHash, err := hashPassword(input.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	passthrough := input.QueryPassthrough
+	if passthrough == "" {
+		passthrough = domain.PassthroughOff
+	}
+	if !domain.ValidQueryPassthrough(passthrough) {
+		return nil, domain.ErrInvalidQueryPassthrough
+	}
+	if !input.UTM.Valid() {
+		return nil, domain.ErrInvalidUTM
+	}
+	if err := validateAppLinks(input.AppLinks); err != nil {
+		return nil, err
+	}
+
 	// 验证自定义短码
 	if input.CustomCode != "" {
 		if !utils.ValidateCustomCode(input.CustomCode) {
 			return nil, fmt.Errorf("%w: invalid format", domain.ErrInvalidCustomCode)
 		}
 		// 检查自定义短码是否已存在
-		if _, err := u.repo.GetByCode(input.CustomCode); err == nil {
+		if _, err := u.repo.GetByCode(ctx, input.CustomCode); err == nil {
 			return nil, domain.ErrCustomCodeExists
 		} else if err != domain.ErrShortLinkNotFound {
 			return nil, fmt.Errorf("failed to check custom code: %w", err)
@@ -159,11 +286,10 @@ func (u *ShortLinkUseCase) Create(input *domain.CreateShortLinkInput) (*domain.S
 
 	// 生成短码
 	var shortCode string
-	var err error
 	if input.CustomCode != "" {
 		shortCode = input.CustomCode
 	} else {
-		shortCode, err = utils.GenerateShortCode(viper.GetInt("shortlink.length"))
+		shortCode, err = utils.GenerateShortCode(u.config.CodeLength)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate short code: %w", err)
 		}
@@ -171,44 +297,60 @@ func (u *ShortLinkUseCase) Create(input *domain.CreateShortLinkInput) (*domain.S
 
 	// 设置过期时间
 	expiresAt := input.ExpiresAt
-	if input.NeverExpire {
+	neverExpire := input.NeverExpire
+	if !neverExpire && expiresAt.IsZero() {
+		if u.config.DefaultExpireDays > 0 {
+			expiresAt = time.Now().AddDate(0, 0, u.config.DefaultExpireDays)
+		} else {
+			// 未配置默认有效期时永不过期
+			neverExpire = true
+		}
+	}
+	if neverExpire {
 		// 如果设置为永不过期,将过期时间设置为100年后
 		expiresAt = time.Now().AddDate(100, 0, 0)
-	} else if expiresAt.IsZero() {
-		duration, err := time.ParseDuration(viper.GetString("shortlink.expiration"))
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse expiration duration: %w", err)
-		}
-		expiresAt = time.Now().Add(duration)
 	}
 
 	// 创建短链接
 	shortLink := &domain.ShortLink{
-		ShortCode:       shortCode,
-		LongURL:         input.LongURL,
-		UserID:          input.UserID,
-		DefaultRedirect: input.DefaultRedirect,
-		ExpiresAt:       expiresAt,
-		NeverExpire:     input.NeverExpire,
-		CreatedAt:       time.Now(),
-		UpdatedAt:       time.Now(),
+		ShortCode:        shortCode,
+		LongURL:          input.LongURL,
+		UserID:           p.UserID,
+		WorkspaceID:      p.WorkspaceID,
+		DefaultRedirect:  input.DefaultRedirect,
+		ExpiresAt:        expiresAt,
+		NeverExpire:      neverExpire,
+		PasswordHash:     passwordHash,
+		QueryPassthrough: passthrough,
+		UTM:              input.UTM,
+		AppLinks:         input.AppLinks,
+		PathForwarding:   input.PathForwarding,
+		CreatedAt:        time.Now(),
+		UpdatedAt:        time.Now(),
 	}
 
-	if err := u.repo.Create(shortLink); err != nil {
+	// 有效短链接数配额与写入在同一事务中检查，并发创建不会超出配额
+	owner := usageOwner(p)
+	if err := u.repo.Create(ctx, shortLink, u.maxActiveLinks(owner)); err != nil {
+		if errors.Is(err, domain.ErrQuotaExceeded) {
+			u.activeLinksExceeded(ctx, owner)
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to create short link: %w", err)
 	}
+	u.recordAudit(ctx, domain.AuditLinkCreate, shortLink, nil, nil, shortLink)
 
 	return shortLink, nil
 }
 
 // Get 获取短链接信息
-func (u *ShortLinkUseCase) Get(code string) (*domain.ShortLink, error) {
-	shortLink, err := u.repo.GetByCode(code)
+func (u *ShortLinkUseCase) Get(ctx context.Context, code string) (*domain.ShortLink, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Read)
+	defer cancel()
+
+	shortLink, err := u.getOwnedLink(ctx, code, domain.PermLinkRead)
 	if err != nil {
-		if err == domain.ErrShortLinkNotFound {
-			return nil, domain.ErrShortLinkNotFound
-		}
-		return nil, fmt.Errorf("failed to get short link: %w", err)
+		return nil, err
 	}
 
 	// 检查是否过期
@@ -217,9 +359,9 @@ func (u *ShortLinkUseCase) Get(code string) (*domain.ShortLink, error) {
 	}
 
 	// 加载规则
-	rules, err := u.repo.GetRules(shortLink.ID)
+	rules, err := u.repo.GetRules(ctx, shortLink.ID)
 	if err != nil {
-		fmt.Printf("failed to get rules for short link %d: %v\n", shortLink.ID, err)
+		u.log(ctx).Warn("failed to get rules", zap.String("code", code), zap.Uint("short_link_id", shortLink.ID), zap.Error(err))
 	} else {
 		shortLink.Rules = rules
 	}
@@ -227,36 +369,88 @@ func (u *ShortLinkUseCase) Get(code string) (*domain.ShortLink, error) {
 	return shortLink, nil
 }
 
-// Redirect 重定向并记录点击
-func (u *ShortLinkUseCase) Redirect(code string, clickLog *domain.ClickLog) (string, domain.RedirectType, error) {
-	fmt.Printf("[访问] 短链接: %s\n", code)
-	fmt.Printf("      来源: %s (%s)\n", clickLog.IP, clickLog.Country)
-
-	shortLink, err := u.Get(code)
+// getVisitableLink 获取可供访问者访问的短链接
+// 访问对所有访问者开放，不按调用者检查权限；依次检查是否过期、访问次数是否达到上限以及是否需要输入密码
+func (u *ShortLinkUseCase) getVisitableLink(ctx context.Context, code string) (*domain.ShortLink, error) {
+	shortLink, err := u.repo.GetByCode(ctx, code)
 	if err != nil {
-		fmt.Printf("      ✗ 获取失败: %v\n", err)
-		return "", 0, err
+		if errors.Is(err, domain.ErrShortLinkNotFound) {
+			return nil, domain.ErrShortLinkNotFound
+		}
+		return nil, fmt.Errorf("failed to get short link: %w", err)
+	}
+	if time.Now().After(shortLink.ExpiresAt) {
+		return nil, domain.ErrShortLinkExpired
 	}
 
 	// 检查访问次数限制
 	// 只有当MaxVisits不为nil，且值大于0，且当前点击数大于等于限制值时才限制访问
 	// 当MaxVisits为0时表示无限制访问
 	if shortLink.MaxVisits != nil && *shortLink.MaxVisits > 0 && shortLink.Clicks >= *shortLink.MaxVisits {
-		fmt.Printf("      ✗ 已达到最大访问次数限制\n")
-		return "", 0, domain.ErrMaxVisitsReached
+		return nil, domain.ErrMaxVisitsReached
+	}
+
+	// 密码保护的短链接需要先输入密码，未解锁的访问不计入点击数和访问日志
+	if shortLink.PasswordProtected() && !u.unlocked(ctx, code, shortLink) {
+		return nil, domain.ErrPasswordRequired
+	}
+	return shortLink, nil
+}
+
+// Redirect 重定向并记录点击
+func (u *ShortLinkUseCase) Redirect(ctx context.Context, code string, req domain.RedirectRequest, clickLog *domain.ClickLog) (*domain.RedirectResult, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Redirect)
+	defer cancel()
+
+	started := time.Now()
+	log := u.log(ctx).With(zap.String("code", code))
+
+	// 按跳转结果记录耗时
+	outcome := metrics.OutcomeError
+	defer func() {
+		metrics.RedirectDuration.WithLabelValues(outcome).Observe(time.Since(started).Seconds())
+	}()
+
+	shortLink, err := u.getVisitableLink(ctx, code)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrShortLinkNotFound):
+			outcome = metrics.OutcomeNotFound
+		case errors.Is(err, domain.ErrShortLinkExpired):
+			outcome = metrics.OutcomeExpired
+		case errors.Is(err, domain.ErrMaxVisitsReached):
+			outcome = metrics.OutcomeMaxVisits
+		case errors.Is(err, domain.ErrPasswordRequired):
+			outcome = metrics.OutcomePasswordRequired
+		}
+		log.Debug("redirect rejected", zap.Error(err))
+		return nil, err
+	}
+
+	// 未开启路径转发的短链接不接受额外路径，与开启前的行为一致
+	var forwarded string
+	if shortLink.PathForwarding {
+		if forwarded, err = splitForwardPath(req.Path); err != nil {
+			outcome = metrics.OutcomeInvalidPath
+			log.Info("unsafe forward path rejected", zap.String("path", req.Path), zap.Error(err))
+			return nil, err
+		}
+	} else if req.Path != "" && req.Path != "/" {
+		outcome = metrics.OutcomeNotFound
+		log.Debug("redirect rejected", zap.String("path", req.Path), zap.String("reason", "path forwarding disabled"))
+		return nil, domain.ErrShortLinkNotFound
 	}
 
 	// 获取所有规则
-	rules, err := u.repo.GetRules(shortLink.ID)
+	rules, err := u.repo.GetRules(ctx, shortLink.ID)
 	if err != nil {
-		fmt.Printf("      ✗ 获取规则失败\n")
-		return "", 0, fmt.Errorf("failed to get rules: %w", err)
+		return nil, fmt.Errorf("failed to get rules: %w", err)
 	}
 
 	// 按优先级排序并匹配规则
 	var matchedRule *domain.RedirectRule
 	for _, rule := range rules {
-		if u.matchRule(&rule, clickLog) {
+		if u.matchRule(log, &rule, clickLog) {
 			matchedRule = &rule
 			break
 		}
@@ -265,54 +459,192 @@ func (u *ShortLinkUseCase) Redirect(code string, clickLog *domain.ClickLog) (str
 	// 设置重定向URL和类型
 	targetURL := shortLink.LongURL
 	redirectType := shortLink.DefaultRedirect
+	utm := shortLink.UTM
 
 	if matchedRule != nil {
 		if matchedRule.TargetURL != "" {
 			targetURL = matchedRule.TargetURL
 		}
 		redirectType = matchedRule.Type
+		utm = utm.Merge(matchedRule.UTM)
 		clickLog.RuleID = &matchedRule.ID
-		fmt.Printf("      ✓ 使用规则: %s\n", matchedRule.Name)
+		metrics.RuleMatches.WithLabelValues("matched").Inc()
+	} else {
+		metrics.RuleMatches.WithLabelValues("default").Inc()
+	}
+
+	// 转发短码之后的路径，拼接失败时跳转到原目标
+	if forwarded != "" {
+		if joined, err := forwardPath(targetURL, forwarded); err != nil {
+			log.Warn("failed to forward path", zap.String("path", req.Path), zap.Error(err))
+		} else {
+			targetURL = joined
+		}
+	}
+
+	// 写入UTM参数，覆盖目标URL中的同名参数；访问者的查询参数随后按转发方式合并
+	if !utm.IsZero() {
+		if merged, err := mergeQuery(targetURL, utmQuery(utm), domain.PassthroughOverride); err != nil {
+			log.Warn("failed to apply utm", zap.Error(err))
+		} else {
+			targetURL = merged
+		}
+	}
+
+	// 按短链接的转发方式合并访问者的查询参数，合并失败时跳转到原目标
+	// 开启路径转发时 /<code>/docs/install?x=1 应跳转到 <目标>/docs/install?x=1，未设置转发方式时按 append 转发
+	passthrough := shortLink.QueryPassthrough
+	if shortLink.PathForwarding && (passthrough == "" || passthrough == domain.PassthroughOff) {
+		passthrough = domain.PassthroughAppend
+	}
+	if merged, err := mergeQuery(targetURL, req.RawQuery, passthrough); err != nil {
+		log.Warn("failed to merge query", zap.Error(err))
 	} else {
-		fmt.Printf("      ✓ 使用默认规则\n")
+		targetURL = merged
 	}
 
-	fmt.Printf("      → 目标: %s\n", targetURL)
+	// 密码保护的短链接不使用永久重定向，避免浏览器缓存跳转后绕过密码
+	if shortLink.PasswordProtected() {
+		switch redirectType {
+		case domain.RedirectTemporary, domain.RedirectTemporaryKeepMethod:
+		case domain.RedirectPermanentKeepMethod:
+			redirectType = domain.RedirectTemporaryKeepMethod
+		default:
+			redirectType = domain.RedirectTemporary
+		}
+	}
 
 	// 增加点击次数
-	if err := u.repo.IncrementClicks(code); err != nil {
-		fmt.Printf("      ✗ 更新点击失败\n")
-		return "", 0, fmt.Errorf("failed to increment clicks: %w", err)
+	if err := u.repo.IncrementClicks(ctx, code); err != nil {
+		return nil, fmt.Errorf("failed to increment clicks: %w", err)
+	}
+
+	// 记录点击日志，月度点击数配额用尽后不再记录
+	if u.trackClick(ctx, log, linkOwner(shortLink)) {
+		clickLog.ShortLinkID = shortLink.ID
+		clickLog.Kind = domain.ClickKindClick
+		clickLog.Campaign = utm.Campaign
+		if err := u.repo.LogClick(ctx, clickLog); err != nil {
+			return nil, fmt.Errorf("failed to log click: %w", err)
+		}
+	}
+
+	// 计量跳转次数，计数失败不影响跳转
+	if u.metering != nil {
+		if err := u.metering.IncrRedirects(ctx, shortLink.WorkspaceID, time.Now()); err != nil {
+			log.Warn("failed to meter redirect", zap.Error(err))
+		}
+	}
+
+	fields := []zap.Field{
+		zap.Int("redirect_type", int(redirectType)),
+		zap.Duration("latency", time.Since(started)),
+	}
+	if matchedRule != nil {
+		fields = append(fields, zap.Uint("rule_id", matchedRule.ID))
+	}
+	result := &domain.RedirectResult{
+		URL:  targetURL,
+		Type: redirectType,
+		App:  appLaunch(shortLink.AppLinks, req.OS, targetURL),
+	}
+	if result.App != nil {
+		fields = append(fields, zap.String("app_os", string(req.OS)))
+	}
+	log.Info("redirect", fields...)
+	outcome = metrics.OutcomeRedirected
+
+	return result, nil
+}
+
+// Preview 获取短链接的预览信息并记录预览
+// 预览与跳转的访问条件相同，预览单独记录访问日志，不计入点击数、不匹配规则、不计量跳转次数
+func (u *ShortLinkUseCase) Preview(ctx context.Context, code string, clickLog *domain.ClickLog) (*domain.LinkPreview, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Redirect)
+	defer cancel()
+
+	log := u.log(ctx).With(zap.String("code", code))
+
+	shortLink, err := u.getVisitableLink(ctx, code)
+	if err != nil {
+		log.Debug("preview rejected", zap.Error(err))
+		return nil, err
+	}
+
+	preview := &domain.LinkPreview{
+		ShortCode:   code,
+		LongURL:     shortLink.LongURL,
+		CreatedAt:   shortLink.CreatedAt,
+		WorkspaceID: shortLink.WorkspaceID,
+	}
+	if parsed, err := url.Parse(shortLink.LongURL); err == nil {
+		preview.Domain = parsed.Hostname()
+	}
+
+	rules, err := u.repo.GetRules(ctx, shortLink.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rules: %w", err)
+	}
+	preview.HasRules = len(rules) > 0
+
+	// 工作空间名称仅用于展示，查询失败时不影响预览
+	if shortLink.WorkspaceID != 0 && u.workspaces != nil {
+		ws, err := u.workspaces.GetByID(ctx, shortLink.WorkspaceID)
+		if err != nil {
+			log.Warn("failed to get workspace", zap.Uint("workspace_id", shortLink.WorkspaceID), zap.Error(err))
+		} else {
+			preview.WorkspaceName = ws.Name
+		}
 	}
 
-	// 记录点击日志
-	clickLog.ShortLinkID = shortLink.ID
-	if err := u.repo.LogClick(clickLog); err != nil {
-		fmt.Printf("      ✗ 记录日志失败\n")
-		return "", 0, fmt.Errorf("failed to log click: %w", err)
+	// 预览日志同样占用月度点击数配额
+	if u.trackClick(ctx, log, linkOwner(shortLink)) {
+		clickLog.ShortLinkID = shortLink.ID
+		clickLog.Kind = domain.ClickKindPreview
+		clickLog.Campaign = shortLink.UTM.Campaign
+		if err := u.repo.LogClick(ctx, clickLog); err != nil {
+			return nil, fmt.Errorf("failed to log preview: %w", err)
+		}
 	}
 
-	return targetURL, redirectType, nil
+	log.Info("preview")
+	return preview, nil
 }
 
 // Delete 删除短链接
-func (u *ShortLinkUseCase) Delete(code string) error {
-	// 检查短链接是否存在
-	if _, err := u.repo.GetByCode(code); err != nil {
-		return fmt.Errorf("failed to check short link existence: %w", err)
+func (u *ShortLinkUseCase) Delete(ctx context.Context, code string) error {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Write)
+	defer cancel()
+
+	// 检查短链接是否存在且属于调用者
+	link, err := u.getOwnedLink(ctx, code, domain.PermLinkWrite)
+	if err != nil {
+		return err
 	}
 
-	if err := u.repo.Delete(code); err != nil {
+	if err := u.repo.Delete(ctx, code); err != nil {
 		return fmt.Errorf("failed to delete short link: %w", err)
 	}
+	u.recordAudit(ctx, domain.AuditLinkDelete, link, nil, link, nil)
 
 	return nil
 }
 
 // CreateRule 创建跳转规则
-func (u *ShortLinkUseCase) CreateRule(input *domain.CreateRuleInput) (*domain.RedirectRule, error) {
+func (u *ShortLinkUseCase) CreateRule(ctx context.Context, code string, input *domain.CreateRuleInput) (*domain.RedirectRule, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Write)
+	defer cancel()
+
+	link, err := u.getOwnedLink(ctx, code, domain.PermRuleWrite)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.validateRule(input); err != nil {
+		return nil, err
+	}
+
 	rule := &domain.RedirectRule{
-		ShortLinkID: input.ShortLinkID,
+		ShortLinkID: link.ID,
 		Name:        input.Name,
 		Description: input.Description,
 		Priority:    input.Priority,
@@ -324,22 +656,40 @@ func (u *ShortLinkUseCase) CreateRule(input *domain.CreateRuleInput) (*domain.Re
 		Countries:   input.Countries,
 		Percentage:  input.Percentage,
 		MaxVisits:   input.MaxVisits,
+		UTM:         input.UTM,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	if err := u.repo.CreateRule(rule); err != nil {
+	if err := u.repo.CreateRule(ctx, rule); err != nil {
 		return nil, fmt.Errorf("failed to create rule: %w", err)
 	}
+	u.recordAudit(ctx, domain.AuditRuleCreate, link, &rule.ID, nil, rule)
 
 	return rule, nil
 }
 
 // UpdateRule 更新跳转规则
-func (u *ShortLinkUseCase) UpdateRule(ruleID uint, input *domain.CreateRuleInput) (*domain.RedirectRule, error) {
+func (u *ShortLinkUseCase) UpdateRule(ctx context.Context, code string, ruleID uint, input *domain.CreateRuleInput) (*domain.RedirectRule, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Write)
+	defer cancel()
+
+	link, err := u.getOwnedLink(ctx, code, domain.PermRuleWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := u.findRule(ctx, link.ID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.validateRule(input); err != nil {
+		return nil, err
+	}
+
 	rule := &domain.RedirectRule{
 		ID:          ruleID,
-		ShortLinkID: input.ShortLinkID,
+		ShortLinkID: link.ID,
 		Name:        input.Name,
 		Description: input.Description,
 		Priority:    input.Priority,
@@ -351,60 +701,134 @@ func (u *ShortLinkUseCase) UpdateRule(ruleID uint, input *domain.CreateRuleInput
 		Countries:   input.Countries,
 		Percentage:  input.Percentage,
 		MaxVisits:   input.MaxVisits,
+		UTM:         input.UTM,
 		UpdatedAt:   time.Now(),
 	}
 
-	if err := u.repo.UpdateRule(rule); err != nil {
+	if err := u.repo.UpdateRule(ctx, rule); err != nil {
+		if errors.Is(err, domain.ErrRuleNotFound) {
+			return nil, domain.ErrRuleNotFound
+		}
 		return nil, fmt.Errorf("failed to update rule: %w", err)
 	}
+	u.recordAudit(ctx, domain.AuditRuleUpdate, link, &ruleID, before, rule)
 
 	return rule, nil
 }
 
 // DeleteRule 删除跳转规则
-func (u *ShortLinkUseCase) DeleteRule(ruleID uint) error {
-	if err := u.repo.DeleteRule(ruleID); err != nil {
+func (u *ShortLinkUseCase) DeleteRule(ctx context.Context, code string, ruleID uint) error {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Write)
+	defer cancel()
+
+	link, err := u.getOwnedLink(ctx, code, domain.PermRuleWrite)
+	if err != nil {
+		return err
+	}
+
+	before, err := u.findRule(ctx, link.ID, ruleID)
+	if err != nil {
+		return err
+	}
+
+	if err := u.repo.DeleteRule(ctx, link.ID, ruleID); err != nil {
+		if errors.Is(err, domain.ErrRuleNotFound) {
+			return domain.ErrRuleNotFound
+		}
 		return fmt.Errorf("failed to delete rule: %w", err)
 	}
+	u.recordAudit(ctx, domain.AuditRuleDelete, link, &ruleID, before, nil)
 	return nil
 }
 
 // GetRules 获取短链接的所有规则
-func (u *ShortLinkUseCase) GetRules(shortLinkID uint) ([]domain.RedirectRule, error) {
-	rules, err := u.repo.GetRules(shortLinkID)
+func (u *ShortLinkUseCase) GetRules(ctx context.Context, code string) ([]domain.RedirectRule, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Read)
+	defer cancel()
+
+	link, err := u.getOwnedLink(ctx, code, domain.PermLinkRead)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := u.repo.GetRules(ctx, link.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get rules: %w", err)
 	}
 	return rules, nil
 }
 
+// findRule 获取短链接的指定规则，用于记录修改前的数据
+func (u *ShortLinkUseCase) findRule(ctx context.Context, shortLinkID, ruleID uint) (*domain.RedirectRule, error) {
+	rules, err := u.repo.GetRules(ctx, shortLinkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rules: %w", err)
+	}
+	for i := range rules {
+		if rules[i].ID == ruleID {
+			return &rules[i], nil
+		}
+	}
+	return nil, domain.ErrRuleNotFound
+}
+
+// linkSortFields 短链接列表允许的排序字段
+var linkSortFields = map[string]bool{
+	"created_at": true,
+	"expires_at": true,
+	"clicks":     true,
+	"short_code": true,
+}
+
+// clickLogSortFields 访问记录列表允许的排序字段
+var clickLogSortFields = map[string]bool{
+	"created_at": true,
+	"ip":         true,
+	"country":    true,
+	"device":     true,
+}
+
 // List 获取短链接列表
-func (u *ShortLinkUseCase) List(query *domain.PaginationQuery) (*domain.PaginatedShortLinks, error) {
-	// 验证排序字段
-	if query.Sort != nil && query.Sort.Field != "" {
-		// 检查排序字段是否合法
-		validFields := map[string]bool{
-			"created_at": true,
-			"expires_at": true,
-			"clicks":     true,
-			"short_code": true,
+func (u *ShortLinkUseCase) List(ctx context.Context, query *domain.PaginationQuery) (*domain.PaginatedShortLinks, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Read)
+	defer cancel()
+
+	// 按调用者的当前空间过滤：工作空间内查看该空间的短链接，个人空间只能查看自己的短链接，管理员可以查看所有短链接
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Can(domain.PermLinkRead) {
+		return nil, domain.ErrForbidden
+	}
+	if p.WorkspaceID != 0 || !p.IsAdmin() {
+		if query.Filter == nil {
+			query.Filter = &domain.ShortLinkFilter{}
 		}
-		if !validFields[query.Sort.Field] {
-			return nil, fmt.Errorf("invalid sort field: %s", query.Sort.Field)
+		workspaceID := p.WorkspaceID
+		query.Filter.WorkspaceID = &workspaceID
+		if workspaceID == 0 {
+			userID := p.UserID
+			query.Filter.UserID = &userID
 		}
 	}
 
+	// 验证排序字段
+	if query.Sort != nil && query.Sort.Field != "" && !linkSortFields[query.Sort.Field] {
+		return nil, fmt.Errorf("%w: invalid sort field %q", domain.ErrInvalidInput, query.Sort.Field)
+	}
+
 	// 调用repository层获取数据
-	result, err := u.repo.List(query)
+	result, err := u.repo.List(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list short links: %w", err)
 	}
 
 	// 对于每个短链接，检查是否需要加载规则
 	for i := range result.Data {
-		rules, err := u.repo.GetRules(result.Data[i].ID)
+		rules, err := u.repo.GetRules(ctx, result.Data[i].ID)
 		if err != nil {
-			fmt.Printf("failed to get rules for short link %d: %v\n", result.Data[i].ID, err)
+			u.log(ctx).Warn("failed to get rules", zap.Uint("short_link_id", result.Data[i].ID), zap.Error(err))
 			continue
 		}
 		result.Data[i].Rules = rules
@@ -414,12 +838,16 @@ func (u *ShortLinkUseCase) List(query *domain.PaginationQuery) (*domain.Paginate
 }
 
 // Update 更新短链接
-func (u *ShortLinkUseCase) Update(code string, input *domain.UpdateShortLinkInput) (*domain.ShortLink, error) {
+func (u *ShortLinkUseCase) Update(ctx context.Context, code string, input *domain.UpdateShortLinkInput) (*domain.ShortLink, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Write)
+	defer cancel()
+
 	// 获取现有短链接
-	link, err := u.repo.GetByCode(code)
+	link, err := u.getOwnedLink(ctx, code, domain.PermLinkWrite)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get short link: %w", err)
+		return nil, err
 	}
+	before := *link
 
 	// 更新字段
 	if input.LongURL != nil {
@@ -454,21 +882,83 @@ func (u *ShortLinkUseCase) Update(code string, input *domain.UpdateShortLinkInpu
 		link.DefaultRedirect = *input.DefaultRedirect
 	}
 
+	if input.Password != nil {
+		passwordHash, err := hashPassword(*input.Password)
+		if err != nil {
+			return nil, err
+		}
+		link.PasswordHash = passwordHash
+	}
+
+	if input.QueryPassthrough != nil {
+		if !domain.ValidQueryPassthrough(*input.QueryPassthrough) {
+			return nil, domain.ErrInvalidQueryPassthrough
+		}
+		link.QueryPassthrough = *input.QueryPassthrough
+	}
+
+	if input.UTM != nil {
+		if !input.UTM.Valid() {
+			return nil, domain.ErrInvalidUTM
+		}
+		link.UTM = *input.UTM
+	}
+
+	if input.AppLinks != nil {
+		if err := validateAppLinks(*input.AppLinks); err != nil {
+			return nil, err
+		}
+		link.AppLinks = *input.AppLinks
+	}
+
+	if input.PathForwarding != nil {
+		link.PathForwarding = *input.PathForwarding
+	}
+
+	// 已过期的短链接重新生效时检查有效短链接数配额
+	now := time.Now()
+	var maxActive int64
+	if !now.Before(before.ExpiresAt) && now.Before(link.ExpiresAt) {
+		maxActive = u.maxActiveLinks(linkOwner(link))
+	}
+
 	// 更新时间
-	link.UpdatedAt = time.Now()
+	link.UpdatedAt = now
 
 	// 保存更新
-	if err := u.repo.Update(link); err != nil {
+	if err := u.repo.Update(ctx, link, maxActive); err != nil {
+		if errors.Is(err, domain.ErrQuotaExceeded) {
+			u.activeLinksExceeded(ctx, linkOwner(link))
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to update short link: %w", err)
 	}
+	u.recordAudit(ctx, domain.AuditLinkUpdate, link, nil, &before, link)
 
 	return link, nil
 }
 
 // UpdateRules 批量更新规则
-func (u *ShortLinkUseCase) UpdateRules(shortLinkID uint, inputs []domain.CreateRuleInput) ([]domain.RedirectRule, error) {
+func (u *ShortLinkUseCase) UpdateRules(ctx context.Context, code string, inputs []domain.CreateRuleInput) ([]domain.RedirectRule, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Write)
+	defer cancel()
+
+	link, err := u.getOwnedLink(ctx, code, domain.PermRuleWrite)
+	if err != nil {
+		return nil, err
+	}
+	shortLinkID := link.ID
+
+	before, err := u.repo.GetRules(ctx, shortLinkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rules: %w", err)
+	}
+
 	rules := make([]domain.RedirectRule, len(inputs))
 	for i, input := range inputs {
+		if err := u.validateRule(&input); err != nil {
+			return nil, err
+		}
 		rule := &domain.RedirectRule{
 			ShortLinkID: shortLinkID,
 			Name:        input.Name,
@@ -482,35 +972,72 @@ func (u *ShortLinkUseCase) UpdateRules(shortLinkID uint, inputs []domain.CreateR
 			Countries:   input.Countries,
 			Percentage:  input.Percentage,
 			MaxVisits:   input.MaxVisits,
+			UTM:         input.UTM,
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
 		}
 		rules[i] = *rule
 	}
 
-	if err := u.repo.UpdateRules(shortLinkID, rules); err != nil {
+	if err := u.repo.UpdateRules(ctx, shortLinkID, rules); err != nil {
 		return nil, fmt.Errorf("failed to update rules: %w", err)
 	}
+	u.recordAudit(ctx, domain.AuditRulesReplace, link, nil, before, rules)
 
 	return rules, nil
 }
 
 // ListClickLogs 获取访问记录列表
-func (u *ShortLinkUseCase) ListClickLogs(code string, query *domain.ClickLogQuery) (*domain.PaginatedClickLogs, error) {
+func (u *ShortLinkUseCase) ListClickLogs(ctx context.Context, code string, query *domain.ClickLogQuery) (*domain.PaginatedClickLogs, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Read)
+	defer cancel()
+
+	// 验证排序字段
+	if query.Sort != nil && query.Sort.Field != "" && !clickLogSortFields[query.Sort.Field] {
+		return nil, fmt.Errorf("%w: invalid sort field %q", domain.ErrInvalidInput, query.Sort.Field)
+	}
+
 	// 先获取短链接信息
-	shortLink, err := u.repo.GetByCode(code)
+	shortLink, err := u.getOwnedLink(ctx, code, domain.PermClickLogRead)
 	if err != nil {
-		if err == domain.ErrShortLinkNotFound {
-			return nil, domain.ErrShortLinkNotFound
-		}
-		return nil, fmt.Errorf("failed to get short link: %w", err)
+		return nil, err
 	}
 
 	// 获取访问记录
-	logs, err := u.repo.ListClickLogs(shortLink.ID, query)
+	logs, err := u.repo.ListClickLogs(ctx, shortLink.ID, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get click logs: %w", err)
 	}
 
 	return logs, nil
 }
+
+// CampaignStats 按UTM活动统计点击数
+// 统计范围与 List 相同：工作空间内统计该空间的短链接，个人空间只统计自己的短链接，管理员在个人空间统计所有短链接
+func (u *ShortLinkUseCase) CampaignStats(ctx context.Context, query *domain.CampaignStatsQuery) ([]domain.CampaignStats, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Read)
+	defer cancel()
+
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Can(domain.PermClickLogRead) {
+		return nil, domain.ErrForbidden
+	}
+	query.UserID, query.WorkspaceID = nil, nil
+	if p.WorkspaceID != 0 || !p.IsAdmin() {
+		workspaceID := p.WorkspaceID
+		query.WorkspaceID = &workspaceID
+		if workspaceID == 0 {
+			userID := p.UserID
+			query.UserID = &userID
+		}
+	}
+
+	stats, err := u.repo.CampaignStats(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign stats: %w", err)
+	}
+	return stats, nil
+}
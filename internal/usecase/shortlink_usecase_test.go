@@ -0,0 +1,342 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"linkit/internal/domain"
+	"linkit/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// stubAuditRepository 丢弃审计日志
+type stubAuditRepository struct{}
+
+func (stubAuditRepository) Create(context.Context, *domain.AuditLog) error { return nil }
+
+func (stubAuditRepository) List(context.Context, *domain.AuditQuery) (*domain.PaginatedAuditLogs, error) {
+	return &domain.PaginatedAuditLogs{}, nil
+}
+
+// newTestUseCase 创建基于内存仓储的短链接用例
+func newTestUseCase() (domain.ShortLinkUseCase, domain.ShortLinkRepository) {
+	repo := repository.NewMemoryShortLinkRepository()
+	uc := NewShortLinkUseCase(repo, stubAuditRepository{}, nil, nil, nil, nil, Config{CodeLength: 6}, zap.NewNop())
+	return uc, repo
+}
+
+// ownerContext 返回以用户1身份调用的上下文
+func ownerContext() context.Context {
+	return domain.WithPrincipal(context.Background(), &domain.Principal{
+		UserID: 1,
+		Scopes: []domain.Scope{domain.ScopeRead, domain.ScopeWrite},
+	})
+}
+
+func TestShortLinkUseCaseRedirectRules(t *testing.T) {
+	ctx := ownerContext()
+	uc, repo := newTestUseCase()
+
+	link, err := uc.Create(ctx, &domain.CreateShortLinkInput{
+		LongURL:    "https://example.com/default",
+		CustomCode: "promo",
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	never := 0
+	mobile, err := uc.CreateRule(ctx, "promo", &domain.CreateRuleInput{
+		Name: "mobile", Priority: 10, Type: domain.RedirectTemporary,
+		TargetURL: "https://m.example.com", Device: domain.DeviceMobile,
+	})
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+	// 优先级更高但已结束或永不命中的规则不影响匹配
+	for _, input := range []domain.CreateRuleInput{
+		{Name: "ended", Priority: 20, Type: domain.RedirectTemporary, TargetURL: "https://old.example.com", EndTime: &past},
+		{Name: "never", Priority: 30, Type: domain.RedirectTemporary, TargetURL: "https://never.example.com", Percentage: &never},
+	} {
+		if _, err := uc.CreateRule(ctx, "promo", &input); err != nil {
+			t.Fatalf("CreateRule(%s) error = %v", input.Name, err)
+		}
+	}
+
+	rules, err := uc.GetRules(ctx, "promo")
+	if err != nil {
+		t.Fatalf("GetRules() error = %v", err)
+	}
+	if len(rules) != 3 || rules[0].Name != "never" || rules[2].Name != "mobile" {
+		t.Fatalf("GetRules() = %+v, want rules ordered by priority", rules)
+	}
+
+	tests := []struct {
+		name     string
+		device   domain.DeviceType
+		wantURL  string
+		wantType domain.RedirectType
+		wantRule *uint
+	}{
+		{"mobile rule", domain.DeviceMobile, "https://m.example.com", domain.RedirectTemporary, &mobile.ID},
+		{"desktop default", domain.DeviceDesktop, "https://example.com/default", domain.RedirectPermanent, nil},
+		{"tablet default", domain.DeviceTablet, "https://example.com/default", domain.RedirectPermanent, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clickLog := &domain.ClickLog{IP: "192.0.2.1", Device: tt.device}
+			result, err := uc.Redirect(ctx, "promo", domain.RedirectRequest{}, clickLog)
+			if err != nil {
+				t.Fatalf("Redirect() error = %v", err)
+			}
+			if result.URL != tt.wantURL || result.Type != tt.wantType {
+				t.Errorf("Redirect() = %s (%d), want %s (%d)", result.URL, result.Type, tt.wantURL, tt.wantType)
+			}
+			if (clickLog.RuleID == nil) != (tt.wantRule == nil) || (tt.wantRule != nil && *clickLog.RuleID != *tt.wantRule) {
+				t.Errorf("click log rule = %v, want %v", clickLog.RuleID, tt.wantRule)
+			}
+		})
+	}
+
+	stored, err := repo.GetByCode(ctx, "promo")
+	if err != nil {
+		t.Fatalf("GetByCode() error = %v", err)
+	}
+	if stored.Clicks != uint64(len(tests)) {
+		t.Errorf("clicks = %d, want %d", stored.Clicks, len(tests))
+	}
+	logs, err := repo.ListClickLogs(ctx, link.ID, &domain.ClickLogQuery{Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("ListClickLogs() error = %v", err)
+	}
+	if logs.Total != int64(len(tests)) {
+		t.Errorf("click logs = %d, want %d", logs.Total, len(tests))
+	}
+}
+
+func TestShortLinkUseCaseRedirectRejected(t *testing.T) {
+	ctx := ownerContext()
+	uc, repo := newTestUseCase()
+
+	one := uint64(1)
+	for _, link := range []*domain.ShortLink{
+		{ShortCode: "expired", LongURL: "https://example.com", UserID: 1, ExpiresAt: time.Now().Add(-time.Minute)},
+		{ShortCode: "limited", LongURL: "https://example.com", UserID: 1, ExpiresAt: time.Now().Add(time.Hour), MaxVisits: &one},
+	} {
+		if err := repo.Create(ctx, link, 0); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	if _, err := uc.Redirect(ctx, "limited", domain.RedirectRequest{}, &domain.ClickLog{}); err != nil {
+		t.Fatalf("first Redirect(limited) error = %v", err)
+	}
+
+	tests := []struct {
+		code string
+		want error
+	}{
+		{"missing", domain.ErrShortLinkNotFound},
+		{"expired", domain.ErrShortLinkExpired},
+		{"limited", domain.ErrMaxVisitsReached},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if _, err := uc.Redirect(ctx, tt.code, domain.RedirectRequest{}, &domain.ClickLog{}); !errors.Is(err, tt.want) {
+				t.Errorf("Redirect() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestShortLinkUseCaseRules(t *testing.T) {
+	ctx := ownerContext()
+	uc, _ := newTestUseCase()
+
+	if _, err := uc.Create(ctx, &domain.CreateShortLinkInput{LongURL: "https://example.com", CustomCode: "rules"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	rule, err := uc.CreateRule(ctx, "rules", &domain.CreateRuleInput{
+		Name: "mobile", Type: domain.RedirectTemporary, TargetURL: "https://m.example.com", Device: domain.DeviceMobile,
+	})
+	if err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+
+	updated, err := uc.UpdateRule(ctx, "rules", rule.ID, &domain.CreateRuleInput{
+		Name: "tablet", Type: domain.RedirectPermanent, TargetURL: "https://t.example.com", Device: domain.DeviceTablet,
+	})
+	if err != nil {
+		t.Fatalf("UpdateRule() error = %v", err)
+	}
+	if updated.ID != rule.ID || updated.Name != "tablet" || updated.Device != domain.DeviceTablet {
+		t.Errorf("UpdateRule() = %+v", updated)
+	}
+
+	replaced, err := uc.UpdateRules(ctx, "rules", []domain.CreateRuleInput{
+		{Name: "a", Priority: 1, Type: domain.RedirectTemporary, TargetURL: "https://a.example.com"},
+		{Name: "b", Priority: 2, Type: domain.RedirectTemporary, TargetURL: "https://b.example.com"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateRules() error = %v", err)
+	}
+	if len(replaced) != 2 {
+		t.Fatalf("UpdateRules() = %d rules, want 2", len(replaced))
+	}
+
+	if err := uc.DeleteRule(ctx, "rules", rule.ID); !errors.Is(err, domain.ErrRuleNotFound) {
+		t.Errorf("DeleteRule() of replaced rule error = %v, want ErrRuleNotFound", err)
+	}
+	if _, err := uc.CreateRule(ctx, "missing", &domain.CreateRuleInput{Name: "x", Type: domain.RedirectTemporary}); !errors.Is(err, domain.ErrShortLinkNotFound) {
+		t.Errorf("CreateRule() on missing link error = %v, want ErrShortLinkNotFound", err)
+	}
+
+	// 其他用户的短链接按不存在处理
+	other := domain.WithPrincipal(context.Background(), &domain.Principal{
+		UserID: 2,
+		Scopes: []domain.Scope{domain.ScopeRead, domain.ScopeWrite},
+	})
+	if _, err := uc.CreateRule(other, "rules", &domain.CreateRuleInput{Name: "x", Type: domain.RedirectTemporary}); !errors.Is(err, domain.ErrShortLinkNotFound) {
+		t.Errorf("CreateRule() by another user error = %v, want ErrShortLinkNotFound", err)
+	}
+}
+
+func TestShortLinkUseCaseRedirectWithoutPrincipal(t *testing.T) {
+	uc, _ := newTestUseCase()
+	if _, err := uc.Create(ownerContext(), &domain.CreateShortLinkInput{LongURL: "https://example.com", CustomCode: "public"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// 访问者没有调用者身份，跳转不检查短链接归属
+	ctx := context.Background()
+	result, err := uc.Redirect(ctx, "public", domain.RedirectRequest{}, &domain.ClickLog{})
+	if err != nil {
+		t.Fatalf("Redirect() error = %v", err)
+	}
+	if result.URL != "https://example.com" {
+		t.Errorf("Redirect() URL = %s, want https://example.com", result.URL)
+	}
+	if _, err := uc.Redirect(ctx, "missing", domain.RedirectRequest{}, &domain.ClickLog{}); !errors.Is(err, domain.ErrShortLinkNotFound) {
+		t.Errorf("Redirect(missing) error = %v, want ErrShortLinkNotFound", err)
+	}
+
+	// 管理接口仍然要求调用者身份
+	if _, err := uc.Get(ctx, "public"); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("Get() error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestShortLinkUseCaseListSortFields(t *testing.T) {
+	ctx := ownerContext()
+	uc, _ := newTestUseCase()
+	if _, err := uc.Create(ctx, &domain.CreateShortLinkInput{LongURL: "https://example.com", CustomCode: "sorted"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	for _, field := range []string{"created_at", "clicks", "short_code"} {
+		if _, err := uc.List(ctx, &domain.PaginationQuery{Page: 1, PageSize: 10, Sort: &domain.ShortLinkSort{Field: field}}); err != nil {
+			t.Errorf("List() sorted by %s error = %v", field, err)
+		}
+	}
+	for _, field := range []string{"created_at", "ip", "country", "device"} {
+		if _, err := uc.ListClickLogs(ctx, "sorted", &domain.ClickLogQuery{Page: 1, PageSize: 10, Sort: &domain.ClickLogSort{Field: field}}); err != nil {
+			t.Errorf("ListClickLogs() sorted by %s error = %v", field, err)
+		}
+	}
+
+	// 不支持的字段在到达数据库之前被拒绝
+	for _, field := range []string{"user_agent", "id; DROP TABLE click_logs", "created_at desc"} {
+		_, err := uc.List(ctx, &domain.PaginationQuery{Page: 1, PageSize: 10, Sort: &domain.ShortLinkSort{Field: field}})
+		if !errors.Is(err, domain.ErrInvalidInput) {
+			t.Errorf("List() sorted by %q error = %v, want ErrInvalidInput", field, err)
+		}
+		_, err = uc.ListClickLogs(ctx, "sorted", &domain.ClickLogQuery{Page: 1, PageSize: 10, Sort: &domain.ClickLogSort{Field: field}})
+		if !errors.Is(err, domain.ErrInvalidInput) {
+			t.Errorf("ListClickLogs() sorted by %q error = %v, want ErrInvalidInput", field, err)
+		}
+	}
+}
+
+func TestShortLinkUseCaseRuleTargetURL(t *testing.T) {
+	ctx := ownerContext()
+	uc, repo := newTestUseCase()
+	if _, err := uc.Create(ctx, &domain.CreateShortLinkInput{LongURL: "https://example.com", CustomCode: "targets"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	rule, err := uc.CreateRule(ctx, "targets", &domain.CreateRuleInput{Name: "default", Type: domain.RedirectTemporary})
+	if err != nil {
+		t.Fatalf("CreateRule() without target error = %v", err)
+	}
+
+	for _, target := range []string{"javascript:alert(1)", "data:text/html,hi", "ftp://example.com", "https://", "/relative"} {
+		if _, err := uc.CreateRule(ctx, "targets", &domain.CreateRuleInput{Name: "bad", Type: domain.RedirectTemporary, TargetURL: target}); !errors.Is(err, domain.ErrInvalidURL) {
+			t.Errorf("CreateRule(%q) error = %v, want ErrInvalidURL", target, err)
+		}
+		if _, err := uc.UpdateRule(ctx, "targets", rule.ID, &domain.CreateRuleInput{Name: "bad", Type: domain.RedirectTemporary, TargetURL: target}); !errors.Is(err, domain.ErrInvalidURL) {
+			t.Errorf("UpdateRule(%q) error = %v, want ErrInvalidURL", target, err)
+		}
+		_, err := uc.UpdateRules(ctx, "targets", []domain.CreateRuleInput{
+			{Name: "good", Type: domain.RedirectTemporary, TargetURL: "https://good.example.com"},
+			{Name: "bad", Type: domain.RedirectTemporary, TargetURL: target},
+		})
+		if !errors.Is(err, domain.ErrInvalidURL) {
+			t.Errorf("UpdateRules(%q) error = %v, want ErrInvalidURL", target, err)
+		}
+	}
+
+	// 被拒绝的写入不修改已有规则
+	link, err := repo.GetByCode(ctx, "targets")
+	if err != nil {
+		t.Fatalf("GetByCode() error = %v", err)
+	}
+	rules, err := repo.GetRules(ctx, link.ID)
+	if err != nil {
+		t.Fatalf("GetRules() error = %v", err)
+	}
+	if len(rules) != 1 || rules[0].ID != rule.ID || rules[0].TargetURL != "" {
+		t.Errorf("rules = %+v, want the original rule only", rules)
+	}
+}
+
+func TestShortLinkUseCaseRedirectPathForwardingQuery(t *testing.T) {
+	ctx := ownerContext()
+	uc, _ := newTestUseCase()
+
+	for _, input := range []domain.CreateShortLinkInput{
+		{LongURL: "https://example.com/base?src=link", CustomCode: "fwdoff", PathForwarding: true},
+		{LongURL: "https://example.com/base?src=link", CustomCode: "fwdover", PathForwarding: true, QueryPassthrough: domain.PassthroughOverride},
+		{LongURL: "https://example.com/base?src=link", CustomCode: "nofwd"},
+	} {
+		if _, err := uc.Create(ctx, &input); err != nil {
+			t.Fatalf("Create(%s) error = %v", input.CustomCode, err)
+		}
+	}
+
+	tests := []struct {
+		code string
+		req  domain.RedirectRequest
+		want string
+	}{
+		// 开启路径转发时，未设置转发方式的查询参数按 append 转发
+		{"fwdoff", domain.RedirectRequest{Path: "/docs/install", RawQuery: "x=1&src=visitor"}, "https://example.com/base/docs/install?src=link&x=1&src=visitor"},
+		{"fwdoff", domain.RedirectRequest{RawQuery: "x=1"}, "https://example.com/base?src=link&x=1"},
+		{"fwdover", domain.RedirectRequest{Path: "/docs", RawQuery: "src=visitor"}, "https://example.com/base/docs?src=visitor"},
+		{"nofwd", domain.RedirectRequest{RawQuery: "x=1"}, "https://example.com/base?src=link"},
+	}
+	for _, tt := range tests {
+		result, err := uc.Redirect(context.Background(), tt.code, tt.req, &domain.ClickLog{IP: "192.0.2.1", Device: domain.DeviceDesktop})
+		if err != nil {
+			t.Fatalf("Redirect(%s, %+v) error = %v", tt.code, tt.req, err)
+		}
+		if result.URL != tt.want {
+			t.Errorf("Redirect(%s, %+v) = %s, want %s", tt.code, tt.req, result.URL, tt.want)
+		}
+	}
+
+	if _, err := uc.Redirect(context.Background(), "fwdoff", domain.RedirectRequest{Path: "/%2e%2e/admin"}, &domain.ClickLog{}); !errors.Is(err, domain.ErrInvalidForwardPath) {
+		t.Errorf("Redirect() with traversal error = %v, want ErrInvalidForwardPath", err)
+	}
+}
@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/logger"
+	"linkit/internal/infrastructure/metrics"
+
+	"go.uber.org/zap"
+)
+
+// 配额名称，用于指标标签
+const (
+	quotaActiveLinks   = "active_links"
+	quotaMonthlyClicks = "monthly_clicks"
+)
+
+// QuotaLimits 单个用户或工作空间的配额，0表示不限制
+type QuotaLimits struct {
+	MaxActiveLinks   int64 // 未过期的短链接数上限
+	MaxMonthlyClicks int64 // 每月记录访问日志的点击数上限
+}
+
+// Quotas 用量配额配置，个人空间按用户计算，工作空间按空间计算
+type Quotas struct {
+	Enabled   bool        // 是否启用配额
+	User      QuotaLimits // 个人空间的配额
+	Workspace QuotaLimits // 工作空间的配额
+}
+
+// limits 获取用量归属适用的配额，未启用时不限制
+func (q Quotas) limits(owner domain.UsageOwner) QuotaLimits {
+	switch {
+	case !q.Enabled:
+		return QuotaLimits{}
+	case owner.WorkspaceID != 0:
+		return q.Workspace
+	default:
+		return q.User
+	}
+}
+
+// usageOwner 获取调用者当前空间的用量归属
+func usageOwner(p *domain.Principal) domain.UsageOwner {
+	if p.WorkspaceID != 0 {
+		return domain.UsageOwner{WorkspaceID: p.WorkspaceID}
+	}
+	return domain.UsageOwner{UserID: p.UserID}
+}
+
+// linkOwner 获取短链接的用量归属
+func linkOwner(link *domain.ShortLink) domain.UsageOwner {
+	if link.WorkspaceID != 0 {
+		return domain.UsageOwner{WorkspaceID: link.WorkspaceID}
+	}
+	return domain.UsageOwner{UserID: link.UserID}
+}
+
+// currentMonth 获取当前统计月份(UTC)的起始时间
+func currentMonth() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// maxActiveLinks 获取用量归属的有效短链接数上限，0表示不限制
+func (u *ShortLinkUseCase) maxActiveLinks(owner domain.UsageOwner) int64 {
+	return u.config.Quotas.limits(owner).MaxActiveLinks
+}
+
+// activeLinksExceeded 记录有效短链接数超出配额
+func (u *ShortLinkUseCase) activeLinksExceeded(ctx context.Context, owner domain.UsageOwner) {
+	metrics.QuotaExceeded.WithLabelValues(quotaActiveLinks).Inc()
+	u.log(ctx).Info("active links quota exceeded",
+		zap.Uint("user_id", owner.UserID), zap.Uint("workspace_id", owner.WorkspaceID), zap.Int64("limit", u.maxActiveLinks(owner)))
+}
+
+// trackClick 计入本月的点击数，返回是否应记录访问日志
+// 配额用尽后跳转不受影响，只是不再记录访问日志；计数失败时照常记录
+func (u *ShortLinkUseCase) trackClick(ctx context.Context, log *zap.Logger, owner domain.UsageOwner) bool {
+	if !u.config.Quotas.Enabled {
+		return true
+	}
+	month := currentMonth()
+	clicks, err := u.usage.IncrClicks(ctx, owner, month, 1)
+	if err != nil {
+		log.Warn("failed to track monthly clicks", zap.Error(err))
+		return true
+	}
+
+	limit := u.config.Quotas.limits(owner).MaxMonthlyClicks
+	if limit <= 0 || clicks <= limit {
+		return true
+	}
+	// 未记录的点击不计入用量
+	if _, err := u.usage.IncrClicks(ctx, owner, month, -1); err != nil {
+		log.Warn("failed to revert monthly clicks", zap.Error(err))
+	}
+	metrics.QuotaExceeded.WithLabelValues(quotaMonthlyClicks).Inc()
+	log.Debug("monthly clicks quota exceeded, click log skipped", zap.Int64("limit", limit))
+	return false
+}
+
+// UsageUseCase 实现用量查询用例接口
+type UsageUseCase struct {
+	repo     domain.UsageRepository
+	quotas   Quotas
+	timeouts Timeouts
+	logger   *zap.Logger
+}
+
+// NewUsageUseCase 创建用量查询用例实例
+func NewUsageUseCase(repo domain.UsageRepository, quotas Quotas, timeouts Timeouts, logger *zap.Logger) domain.UsageUseCase {
+	return &UsageUseCase{
+		repo:     repo,
+		quotas:   quotas,
+		timeouts: timeouts,
+		logger:   logger.Named("usage"),
+	}
+}
+
+// log 返回带有请求ID的日志实例
+func (u *UsageUseCase) log(ctx context.Context) *zap.Logger {
+	return logger.FromContext(ctx, u.logger)
+}
+
+// Get 获取调用者当前空间的用量，工作空间的所有成员均可查看
+func (u *UsageUseCase) Get(ctx context.Context) (*domain.Usage, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Read)
+	defer cancel()
+
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Can(domain.PermLinkRead) {
+		return nil, domain.ErrForbidden
+	}
+
+	owner := usageOwner(p)
+	limits := u.quotas.limits(owner)
+	month := currentMonth()
+
+	active, err := u.repo.CountActiveLinks(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	clicks, err := u.repo.GetClicks(ctx, owner, month)
+	if err != nil {
+		return nil, err
+	}
+
+	u.log(ctx).Debug("usage queried",
+		zap.Uint("user_id", owner.UserID), zap.Uint("workspace_id", owner.WorkspaceID))
+	return &domain.Usage{
+		UserID:        owner.UserID,
+		WorkspaceID:   owner.WorkspaceID,
+		Period:        month.Format("2006-01"),
+		ResetsAt:      month.AddDate(0, 1, 0),
+		ActiveLinks:   domain.UsageItem{Used: active, Limit: limits.MaxActiveLinks},
+		MonthlyClicks: domain.UsageItem{Used: clicks, Limit: limits.MaxMonthlyClicks},
+	}, nil
+}
@@ -0,0 +1,211 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"linkit/internal/domain"
+	"linkit/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// stubUsageRepository 内存中的用量计数
+type stubUsageRepository struct {
+	mu     sync.Mutex
+	active map[domain.UsageOwner]int64
+	clicks map[domain.UsageOwner]int64
+	err    error // 不为空时计数返回该错误
+}
+
+func newStubUsageRepository() *stubUsageRepository {
+	return &stubUsageRepository{active: make(map[domain.UsageOwner]int64), clicks: make(map[domain.UsageOwner]int64)}
+}
+
+func (r *stubUsageRepository) CountActiveLinks(ctx context.Context, owner domain.UsageOwner) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.active[owner], nil
+}
+
+func (r *stubUsageRepository) IncrClicks(ctx context.Context, owner domain.UsageOwner, month time.Time, n int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return 0, r.err
+	}
+	r.clicks[owner] += n
+	return r.clicks[owner], nil
+}
+
+func (r *stubUsageRepository) GetClicks(ctx context.Context, owner domain.UsageOwner, month time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.clicks[owner], nil
+}
+
+// newQuotaUseCase 创建启用了个人空间配额的短链接用例
+func newQuotaUseCase(limits QuotaLimits) (domain.ShortLinkUseCase, domain.ShortLinkRepository, *stubUsageRepository) {
+	repo := repository.NewMemoryShortLinkRepository()
+	usage := newStubUsageRepository()
+	config := Config{CodeLength: 6, Quotas: Quotas{Enabled: true, User: limits}}
+	uc := NewShortLinkUseCase(repo, stubAuditRepository{}, usage, nil, nil, nil, config, zap.NewNop())
+	return uc, repo, usage
+}
+
+func TestShortLinkUseCaseActiveLinksQuota(t *testing.T) {
+	ctx := ownerContext()
+	uc, repo, _ := newQuotaUseCase(QuotaLimits{MaxActiveLinks: 2})
+
+	for _, code := range []string{"first", "second"} {
+		if _, err := uc.Create(ctx, &domain.CreateShortLinkInput{LongURL: "https://example.com", CustomCode: code}); err != nil {
+			t.Fatalf("Create(%s) error = %v", code, err)
+		}
+	}
+	if _, err := uc.Create(ctx, &domain.CreateShortLinkInput{LongURL: "https://example.com", CustomCode: "third"}); !errors.Is(err, domain.ErrQuotaExceeded) {
+		t.Fatalf("Create() beyond the quota error = %v, want ErrQuotaExceeded", err)
+	}
+
+	// 其他用户和工作空间的配额独立计算，工作空间未配置配额
+	other := domain.WithPrincipal(context.Background(), &domain.Principal{UserID: 2, Scopes: []domain.Scope{domain.ScopeWrite}})
+	if _, err := uc.Create(other, &domain.CreateShortLinkInput{LongURL: "https://example.com"}); err != nil {
+		t.Errorf("Create() by another user error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := uc.Create(memberContext(1, 5, domain.RoleEditor), &domain.CreateShortLinkInput{LongURL: "https://example.com"}); err != nil {
+			t.Errorf("Create() in workspace error = %v", err)
+		}
+	}
+
+	// 过期的短链接不计入，重新生效时检查配额
+	link, err := repo.GetByCode(context.Background(), "first")
+	if err != nil {
+		t.Fatalf("GetByCode() error = %v", err)
+	}
+	link.ExpiresAt = time.Now().Add(-time.Minute)
+	if err := repo.Update(context.Background(), link, 0); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if _, err := uc.Create(ctx, &domain.CreateShortLinkInput{LongURL: "https://example.com", CustomCode: "third"}); err != nil {
+		t.Fatalf("Create() after a link expired error = %v", err)
+	}
+	never := true
+	if _, err := uc.Update(ctx, "first", &domain.UpdateShortLinkInput{NeverExpire: &never}); !errors.Is(err, domain.ErrQuotaExceeded) {
+		t.Errorf("Update() reactivating beyond the quota error = %v, want ErrQuotaExceeded", err)
+	}
+	// 未重新生效的更新不检查配额
+	longURL := "https://example.com/updated"
+	if _, err := uc.Update(ctx, "second", &domain.UpdateShortLinkInput{LongURL: &longURL}); err != nil {
+		t.Errorf("Update() of an active link error = %v", err)
+	}
+	if err := uc.Delete(ctx, "third"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := uc.Update(ctx, "first", &domain.UpdateShortLinkInput{NeverExpire: &never}); err != nil {
+		t.Errorf("Update() reactivating within the quota error = %v", err)
+	}
+}
+
+func TestShortLinkUseCaseActiveLinksQuotaConcurrent(t *testing.T) {
+	uc, _, _ := newQuotaUseCase(QuotaLimits{MaxActiveLinks: 3})
+
+	var (
+		wg                sync.WaitGroup
+		mu                sync.Mutex
+		created, exceeded int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := uc.Create(ownerContext(), &domain.CreateShortLinkInput{LongURL: "https://example.com", CustomCode: fmt.Sprintf("code%d", i)})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				created++
+			case errors.Is(err, domain.ErrQuotaExceeded):
+				exceeded++
+			default:
+				t.Errorf("Create() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if created != 3 || exceeded != 7 {
+		t.Errorf("created %d, exceeded %d; want 3 created within the quota", created, exceeded)
+	}
+}
+
+func TestShortLinkUseCaseMonthlyClicksQuota(t *testing.T) {
+	ctx := ownerContext()
+	uc, _, usage := newQuotaUseCase(QuotaLimits{MaxMonthlyClicks: 2})
+	if _, err := uc.Create(ctx, &domain.CreateShortLinkInput{LongURL: "https://example.com", CustomCode: "busy"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// 配额用尽后跳转不受影响，只是不再记录访问日志
+	for i := 0; i < 3; i++ {
+		if _, err := uc.Redirect(context.Background(), "busy", domain.RedirectRequest{}, &domain.ClickLog{}); err != nil {
+			t.Fatalf("Redirect() %d error = %v", i+1, err)
+		}
+	}
+	if _, err := uc.Preview(context.Background(), "busy", &domain.ClickLog{}); err != nil {
+		t.Fatalf("Preview() error = %v", err)
+	}
+	logs, err := uc.ListClickLogs(ctx, "busy", &domain.ClickLogQuery{Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("ListClickLogs() error = %v", err)
+	}
+	if logs.Total != 2 {
+		t.Errorf("click logs = %d, want 2", logs.Total)
+	}
+	// 未记录的点击不计入用量
+	owner := domain.UsageOwner{UserID: 1}
+	if clicks, _ := usage.GetClicks(context.Background(), owner, currentMonth()); clicks != 2 {
+		t.Errorf("monthly clicks = %d, want 2", clicks)
+	}
+
+	// 计数失败时照常记录
+	usage.mu.Lock()
+	usage.err = errors.New("connection refused")
+	usage.mu.Unlock()
+	if _, err := uc.Redirect(context.Background(), "busy", domain.RedirectRequest{}, &domain.ClickLog{}); err != nil {
+		t.Fatalf("Redirect() with failing counter error = %v", err)
+	}
+	if logs, err = uc.ListClickLogs(ctx, "busy", &domain.ClickLogQuery{Page: 1, PageSize: 10}); err != nil || logs.Total != 3 {
+		t.Errorf("click logs with failing counter = %d, %v; want 3", logs.Total, err)
+	}
+}
+
+func TestUsageUseCaseGet(t *testing.T) {
+	usage := newStubUsageRepository()
+	usage.active[domain.UsageOwner{UserID: 1}] = 1
+	if _, err := usage.IncrClicks(context.Background(), domain.UsageOwner{UserID: 1}, currentMonth(), 5); err != nil {
+		t.Fatalf("IncrClicks() error = %v", err)
+	}
+	quotas := Quotas{Enabled: true, User: QuotaLimits{MaxActiveLinks: 10, MaxMonthlyClicks: 100}}
+	uc := NewUsageUseCase(usage, quotas, Timeouts{}, zap.NewNop())
+
+	got, err := uc.Get(ownerContext())
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	month := currentMonth()
+	if got.UserID != 1 || got.Period != month.Format("2006-01") || !got.ResetsAt.Equal(month.AddDate(0, 1, 0)) {
+		t.Errorf("Get() = %+v, want user 1 usage for %s", got, month.Format("2006-01"))
+	}
+	if got.ActiveLinks != (domain.UsageItem{Used: 1, Limit: 10}) || got.MonthlyClicks != (domain.UsageItem{Used: 5, Limit: 100}) {
+		t.Errorf("Get() active links = %+v, monthly clicks = %+v", got.ActiveLinks, got.MonthlyClicks)
+	}
+
+	// 未启用配额时不限制
+	uc = NewUsageUseCase(usage, Quotas{User: quotas.User}, Timeouts{}, zap.NewNop())
+	if got, err = uc.Get(ownerContext()); err != nil || got.ActiveLinks.Limit != 0 || got.MonthlyClicks.Limit != 0 {
+		t.Errorf("Get() without quotas = %+v, %v; want no limits", got, err)
+	}
+}
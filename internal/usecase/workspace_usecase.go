@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"linkit/internal/domain"
+	"linkit/internal/infrastructure/logger"
+
+	"go.uber.org/zap"
+)
+
+// WorkspaceUseCase 实现工作空间用例接口
+type WorkspaceUseCase struct {
+	repo     domain.WorkspaceRepository
+	timeouts Timeouts
+	logger   *zap.Logger
+}
+
+// NewWorkspaceUseCase 创建工作空间用例实例
+func NewWorkspaceUseCase(repo domain.WorkspaceRepository, timeouts Timeouts, logger *zap.Logger) domain.WorkspaceUseCase {
+	return &WorkspaceUseCase{
+		repo:     repo,
+		timeouts: timeouts,
+		logger:   logger.Named("workspace"),
+	}
+}
+
+// log 返回带有请求ID的日志实例
+func (u *WorkspaceUseCase) log(ctx context.Context) *zap.Logger {
+	return logger.FromContext(ctx, u.logger)
+}
+
+// role 获取调用者在工作空间中的角色，管理员视为所有者
+func (u *WorkspaceUseCase) role(ctx context.Context, p *domain.Principal, workspaceID uint) (domain.Role, error) {
+	if p.IsAdmin() {
+		if _, err := u.repo.GetByID(ctx, workspaceID); err != nil {
+			return "", err
+		}
+		return domain.RoleOwner, nil
+	}
+
+	member, err := u.repo.GetMember(ctx, workspaceID, p.UserID)
+	if errors.Is(err, domain.ErrMemberNotFound) {
+		return "", domain.ErrWorkspaceNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// Resolve 返回调用者在工作空间中的角色
+func (u *WorkspaceUseCase) Resolve(ctx context.Context, workspaceID uint) (domain.Role, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Read)
+	defer cancel()
+
+	p, err := principal(ctx)
+	if err != nil {
+		return "", err
+	}
+	return u.role(ctx, p, workspaceID)
+}
+
+// Create 创建工作空间，调用者成为所有者
+func (u *WorkspaceUseCase) Create(ctx context.Context, input *domain.CreateWorkspaceInput) (*domain.Workspace, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Write)
+	defer cancel()
+
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ws := &domain.Workspace{
+		Name:      strings.TrimSpace(input.Name),
+		CreatedBy: p.UserID,
+	}
+	if err := u.repo.Create(ctx, ws); err != nil {
+		return nil, err
+	}
+
+	u.log(ctx).Info("workspace created", zap.Uint("workspace_id", ws.ID), zap.Uint("user_id", p.UserID))
+	return ws, nil
+}
+
+// List 获取调用者所在的工作空间，管理员可以查看所有工作空间
+func (u *WorkspaceUseCase) List(ctx context.Context) ([]domain.Workspace, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Read)
+	defer cancel()
+
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if p.IsAdmin() {
+		return u.repo.List(ctx, 0)
+	}
+	return u.repo.List(ctx, p.UserID)
+}
+
+// ListMembers 获取工作空间成员，所有成员均可查看
+func (u *WorkspaceUseCase) ListMembers(ctx context.Context, workspaceID uint) ([]domain.WorkspaceMember, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Read)
+	defer cancel()
+
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := u.role(ctx, p, workspaceID); err != nil {
+		return nil, err
+	}
+	return u.repo.ListMembers(ctx, workspaceID)
+}
+
+// authorizeManage 检查调用者能否管理工作空间成员
+func (u *WorkspaceUseCase) authorizeManage(ctx context.Context, workspaceID uint) (*domain.Principal, error) {
+	p, err := principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	role, err := u.role(ctx, p, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if !role.Can(domain.PermMemberManage) {
+		return nil, domain.ErrForbidden
+	}
+	return p, nil
+}
+
+// SetMember 添加成员或修改成员角色，仅所有者可以操作，工作空间至少保留一个所有者
+func (u *WorkspaceUseCase) SetMember(ctx context.Context, workspaceID, userID uint, input *domain.SetMemberInput) (*domain.WorkspaceMember, error) {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Write)
+	defer cancel()
+
+	if !domain.ValidRole(input.Role) {
+		return nil, domain.ErrInvalidRole
+	}
+	p, err := u.authorizeManage(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	member := &domain.WorkspaceMember{
+		WorkspaceID: workspaceID,
+		UserID:      userID,
+		Role:        input.Role,
+	}
+	if err := u.repo.SetMember(ctx, member); err != nil {
+		return nil, err
+	}
+
+	u.log(ctx).Info("workspace member updated",
+		zap.Uint("workspace_id", workspaceID), zap.Uint("user_id", userID),
+		zap.String("role", string(input.Role)), zap.Uint("updated_by", p.UserID))
+	return member, nil
+}
+
+// RemoveMember 移除工作空间成员，仅所有者可以操作，工作空间至少保留一个所有者
+func (u *WorkspaceUseCase) RemoveMember(ctx context.Context, workspaceID, userID uint) error {
+	ctx, cancel := withTimeout(ctx, u.timeouts.Write)
+	defer cancel()
+
+	p, err := u.authorizeManage(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	if err := u.repo.RemoveMember(ctx, workspaceID, userID); err != nil {
+		return err
+	}
+
+	u.log(ctx).Info("workspace member removed",
+		zap.Uint("workspace_id", workspaceID), zap.Uint("user_id", userID), zap.Uint("removed_by", p.UserID))
+	return nil
+}
@@ -0,0 +1,318 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"linkit/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+// stubWorkspaceRepository 内存中的工作空间仓储
+type stubWorkspaceRepository struct {
+	mu         sync.Mutex
+	workspaces map[uint]*domain.Workspace
+	members    map[uint]map[uint]domain.Role // 工作空间ID -> 用户ID -> 角色
+}
+
+func newStubWorkspaceRepository() *stubWorkspaceRepository {
+	return &stubWorkspaceRepository{
+		workspaces: make(map[uint]*domain.Workspace),
+		members:    make(map[uint]map[uint]domain.Role),
+	}
+}
+
+func (r *stubWorkspaceRepository) Create(ctx context.Context, ws *domain.Workspace) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ws.ID = uint(len(r.workspaces) + 1)
+	ws.Role = domain.RoleOwner
+	stored := *ws
+	r.workspaces[ws.ID] = &stored
+	r.members[ws.ID] = map[uint]domain.Role{ws.CreatedBy: domain.RoleOwner}
+	return nil
+}
+
+func (r *stubWorkspaceRepository) GetByID(ctx context.Context, id uint) (*domain.Workspace, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ws, ok := r.workspaces[id]
+	if !ok {
+		return nil, domain.ErrWorkspaceNotFound
+	}
+	found := *ws
+	return &found, nil
+}
+
+func (r *stubWorkspaceRepository) List(ctx context.Context, userID uint) ([]domain.Workspace, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var workspaces []domain.Workspace
+	for id, ws := range r.workspaces {
+		if role, ok := r.members[id][userID]; ok || userID == 0 {
+			found := *ws
+			found.Role = role
+			workspaces = append(workspaces, found)
+		}
+	}
+	return workspaces, nil
+}
+
+func (r *stubWorkspaceRepository) GetMember(ctx context.Context, workspaceID, userID uint) (*domain.WorkspaceMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	role, ok := r.members[workspaceID][userID]
+	if !ok {
+		return nil, domain.ErrMemberNotFound
+	}
+	return &domain.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID, Role: role}, nil
+}
+
+func (r *stubWorkspaceRepository) ListMembers(ctx context.Context, workspaceID uint) ([]domain.WorkspaceMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var members []domain.WorkspaceMember
+	for userID, role := range r.members[workspaceID] {
+		members = append(members, domain.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID, Role: role})
+	}
+	return members, nil
+}
+
+func (r *stubWorkspaceRepository) SetMember(ctx context.Context, member *domain.WorkspaceMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if member.Role != domain.RoleOwner && r.members[member.WorkspaceID][member.UserID] == domain.RoleOwner && r.owners(member.WorkspaceID) <= 1 {
+		return domain.ErrLastOwner
+	}
+	r.members[member.WorkspaceID][member.UserID] = member.Role
+	return nil
+}
+
+func (r *stubWorkspaceRepository) RemoveMember(ctx context.Context, workspaceID, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	role, ok := r.members[workspaceID][userID]
+	if !ok {
+		return domain.ErrMemberNotFound
+	}
+	if role == domain.RoleOwner && r.owners(workspaceID) <= 1 {
+		return domain.ErrLastOwner
+	}
+	delete(r.members[workspaceID], userID)
+	return nil
+}
+
+// owners 统计工作空间的所有者数量，调用方需持有锁
+func (r *stubWorkspaceRepository) owners(workspaceID uint) int {
+	n := 0
+	for _, role := range r.members[workspaceID] {
+		if role == domain.RoleOwner {
+			n++
+		}
+	}
+	return n
+}
+
+// memberContext 返回以指定角色在工作空间中调用的上下文
+func memberContext(userID, workspaceID uint, role domain.Role) context.Context {
+	p := &domain.Principal{UserID: userID, Scopes: []domain.Scope{domain.ScopeRead, domain.ScopeWrite}}
+	return domain.WithPrincipal(context.Background(), p.InWorkspace(workspaceID, role))
+}
+
+func TestShortLinkUseCaseWorkspaceRoles(t *testing.T) {
+	const workspaceID = 5
+
+	// setup 在工作空间中创建带一条规则的短链接
+	setup := func(t *testing.T) (domain.ShortLinkUseCase, uint) {
+		t.Helper()
+		uc, _ := newTestUseCase()
+		ctx := memberContext(1, workspaceID, domain.RoleOwner)
+		if _, err := uc.Create(ctx, &domain.CreateShortLinkInput{LongURL: "https://example.com", CustomCode: "team"}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		rule, err := uc.CreateRule(ctx, "team", &domain.CreateRuleInput{Name: "mobile", Type: domain.RedirectTemporary, TargetURL: "https://m.example.com"})
+		if err != nil {
+			t.Fatalf("CreateRule() error = %v", err)
+		}
+		return uc, rule.ID
+	}
+
+	longURL := "https://example.com/updated"
+	rule := domain.CreateRuleInput{Name: "desktop", Type: domain.RedirectTemporary, TargetURL: "https://d.example.com"}
+	operations := []struct {
+		name string
+		perm domain.Permission
+		call func(ctx context.Context, uc domain.ShortLinkUseCase, ruleID uint) error
+	}{
+		{"Get", domain.PermLinkRead, func(ctx context.Context, uc domain.ShortLinkUseCase, _ uint) error {
+			_, err := uc.Get(ctx, "team")
+			return err
+		}},
+		{"List", domain.PermLinkRead, func(ctx context.Context, uc domain.ShortLinkUseCase, _ uint) error {
+			_, err := uc.List(ctx, &domain.PaginationQuery{Page: 1, PageSize: 10})
+			return err
+		}},
+		{"GetRules", domain.PermLinkRead, func(ctx context.Context, uc domain.ShortLinkUseCase, _ uint) error {
+			_, err := uc.GetRules(ctx, "team")
+			return err
+		}},
+		{"Create", domain.PermLinkWrite, func(ctx context.Context, uc domain.ShortLinkUseCase, _ uint) error {
+			_, err := uc.Create(ctx, &domain.CreateShortLinkInput{LongURL: "https://example.com/new"})
+			return err
+		}},
+		{"Update", domain.PermLinkWrite, func(ctx context.Context, uc domain.ShortLinkUseCase, _ uint) error {
+			_, err := uc.Update(ctx, "team", &domain.UpdateShortLinkInput{LongURL: &longURL})
+			return err
+		}},
+		{"Delete", domain.PermLinkWrite, func(ctx context.Context, uc domain.ShortLinkUseCase, _ uint) error {
+			return uc.Delete(ctx, "team")
+		}},
+		{"CreateRule", domain.PermRuleWrite, func(ctx context.Context, uc domain.ShortLinkUseCase, _ uint) error {
+			_, err := uc.CreateRule(ctx, "team", &rule)
+			return err
+		}},
+		{"UpdateRule", domain.PermRuleWrite, func(ctx context.Context, uc domain.ShortLinkUseCase, ruleID uint) error {
+			_, err := uc.UpdateRule(ctx, "team", ruleID, &rule)
+			return err
+		}},
+		{"UpdateRules", domain.PermRuleWrite, func(ctx context.Context, uc domain.ShortLinkUseCase, _ uint) error {
+			_, err := uc.UpdateRules(ctx, "team", []domain.CreateRuleInput{rule})
+			return err
+		}},
+		{"DeleteRule", domain.PermRuleWrite, func(ctx context.Context, uc domain.ShortLinkUseCase, ruleID uint) error {
+			return uc.DeleteRule(ctx, "team", ruleID)
+		}},
+		{"ListClickLogs", domain.PermClickLogRead, func(ctx context.Context, uc domain.ShortLinkUseCase, _ uint) error {
+			_, err := uc.ListClickLogs(ctx, "team", &domain.ClickLogQuery{Page: 1, PageSize: 10})
+			return err
+		}},
+		{"CampaignStats", domain.PermClickLogRead, func(ctx context.Context, uc domain.ShortLinkUseCase, _ uint) error {
+			_, err := uc.CampaignStats(ctx, &domain.CampaignStatsQuery{})
+			return err
+		}},
+	}
+
+	for _, role := range []domain.Role{domain.RoleOwner, domain.RoleEditor, domain.RoleAnalyst, domain.RoleViewer} {
+		for _, op := range operations {
+			t.Run(string(role)+"/"+op.name, func(t *testing.T) {
+				uc, ruleID := setup(t)
+				err := op.call(memberContext(2, workspaceID, role), uc, ruleID)
+				if role.Can(op.perm) {
+					if err != nil {
+						t.Errorf("%s as %s error = %v, want nil", op.name, role, err)
+					}
+				} else if !errors.Is(err, domain.ErrForbidden) {
+					t.Errorf("%s as %s error = %v, want ErrForbidden", op.name, role, err)
+				}
+			})
+		}
+	}
+
+	// 其他工作空间的所有者和创建者的个人空间都不能访问工作空间的短链接
+	for name, ctx := range map[string]context.Context{
+		"owner of another workspace": memberContext(1, workspaceID+1, domain.RoleOwner),
+		"creator in personal space":  ownerContext(),
+	} {
+		for _, op := range operations {
+			if op.name == "Create" || op.name == "List" || op.name == "CampaignStats" {
+				continue
+			}
+			t.Run(name+"/"+op.name, func(t *testing.T) {
+				uc, ruleID := setup(t)
+				if err := op.call(ctx, uc, ruleID); !errors.Is(err, domain.ErrShortLinkNotFound) {
+					t.Errorf("%s error = %v, want ErrShortLinkNotFound", op.name, err)
+				}
+			})
+		}
+	}
+}
+
+func TestWorkspaceUseCaseMembers(t *testing.T) {
+	repo := newStubWorkspaceRepository()
+	uc := NewWorkspaceUseCase(repo, Timeouts{}, zap.NewNop())
+
+	owner := domain.WithPrincipal(context.Background(), &domain.Principal{UserID: 1, Scopes: []domain.Scope{domain.ScopeWrite}})
+	ws, err := uc.Create(owner, &domain.CreateWorkspaceInput{Name: " team "})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if ws.Name != "team" || ws.Role != domain.RoleOwner {
+		t.Errorf("Create() = %+v, want trimmed name with owner role", ws)
+	}
+	if _, err := uc.SetMember(owner, ws.ID, 2, &domain.SetMemberInput{Role: domain.RoleEditor}); err != nil {
+		t.Fatalf("SetMember() error = %v", err)
+	}
+
+	editor := domain.WithPrincipal(context.Background(), &domain.Principal{UserID: 2, Scopes: []domain.Scope{domain.ScopeWrite}})
+	stranger := domain.WithPrincipal(context.Background(), &domain.Principal{UserID: 3, Scopes: []domain.Scope{domain.ScopeWrite}})
+	tests := []struct {
+		name string
+		ctx  context.Context
+		call func(ctx context.Context) error
+		want error
+	}{
+		{"editor resolves role", editor, func(ctx context.Context) error {
+			role, err := uc.Resolve(ctx, ws.ID)
+			if err == nil && role != domain.RoleEditor {
+				return errors.New("role = " + string(role))
+			}
+			return err
+		}, nil},
+		{"editor lists members", editor, func(ctx context.Context) error {
+			_, err := uc.ListMembers(ctx, ws.ID)
+			return err
+		}, nil},
+		{"editor cannot add members", editor, func(ctx context.Context) error {
+			_, err := uc.SetMember(ctx, ws.ID, 3, &domain.SetMemberInput{Role: domain.RoleViewer})
+			return err
+		}, domain.ErrForbidden},
+		{"editor cannot remove members", editor, func(ctx context.Context) error {
+			return uc.RemoveMember(ctx, ws.ID, 1)
+		}, domain.ErrForbidden},
+		{"stranger cannot resolve", stranger, func(ctx context.Context) error {
+			_, err := uc.Resolve(ctx, ws.ID)
+			return err
+		}, domain.ErrWorkspaceNotFound},
+		{"stranger cannot list members", stranger, func(ctx context.Context) error {
+			_, err := uc.ListMembers(ctx, ws.ID)
+			return err
+		}, domain.ErrWorkspaceNotFound},
+		{"invalid role", owner, func(ctx context.Context) error {
+			_, err := uc.SetMember(ctx, ws.ID, 3, &domain.SetMemberInput{Role: "root"})
+			return err
+		}, domain.ErrInvalidRole},
+		{"last owner cannot step down", owner, func(ctx context.Context) error {
+			_, err := uc.SetMember(ctx, ws.ID, 1, &domain.SetMemberInput{Role: domain.RoleEditor})
+			return err
+		}, domain.ErrLastOwner},
+		{"last owner cannot leave", owner, func(ctx context.Context) error {
+			return uc.RemoveMember(ctx, ws.ID, 1)
+		}, domain.ErrLastOwner},
+		{"admin manages any workspace", adminContext(), func(ctx context.Context) error {
+			_, err := uc.SetMember(ctx, ws.ID, 3, &domain.SetMemberInput{Role: domain.RoleViewer})
+			return err
+		}, nil},
+		{"admin gets not found for missing workspace", adminContext(), func(ctx context.Context) error {
+			_, err := uc.ListMembers(ctx, ws.ID+1)
+			return err
+		}, domain.ErrWorkspaceNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(tt.ctx); !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// 添加第二个所有者后原所有者可以离开
+	if _, err := uc.SetMember(owner, ws.ID, 2, &domain.SetMemberInput{Role: domain.RoleOwner}); err != nil {
+		t.Fatalf("SetMember() error = %v", err)
+	}
+	if err := uc.RemoveMember(owner, ws.ID, 1); err != nil {
+		t.Errorf("RemoveMember() with another owner error = %v", err)
+	}
+}
@@ -2,40 +2,49 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	stdhttp "net/http"
+	"os/signal"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
-	"github.com/spf13/viper"
 
+	"linkit/internal/config"
 	"linkit/internal/delivery/http"
+	"linkit/internal/delivery/http/middleware"
 	"linkit/internal/domain"
 	"linkit/internal/infrastructure/cache"
 	"linkit/internal/infrastructure/database"
+	"linkit/internal/infrastructure/lifecycle"
 	"linkit/internal/infrastructure/logger"
+	"linkit/internal/infrastructure/metrics"
+	"linkit/internal/infrastructure/oidc"
 	"linkit/internal/repository"
 	"linkit/internal/usecase"
 	"linkit/pkg/utils"
 )
 
-func init() {
-	// 加载配置文件
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath("./configs")
-	if err := viper.ReadInConfig(); err != nil {
-		log.Fatalf("Error reading config file: %s", err)
+func main() {
+	configPath := flag.String("config", "", "配置文件路径，默认读取 $LINKIT_CONFIG 或 ./configs/config.yaml")
+	flag.Parse()
+
+	// 加载配置，配置缺失或不合法时立即退出
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
 	}
 
 	// 初始化IP搜索器
 	if err := utils.InitIPSearcher("ip2region/ip2region.xdb"); err != nil {
 		log.Printf("Warning: Failed to initialize IP searcher: %v", err)
 	}
-}
 
-func main() {
 	// 初始化日志
-	zapLogger, err := logger.NewLogger()
+	zapLogger, err := logger.NewLogger(cfg.Server.Mode, cfg.Log)
 	if err != nil {
 		log.Fatalf("Failed to create logger: %v", err)
 	}
@@ -44,54 +53,305 @@ func main() {
 	sugar := logger.NewSugaredLogger(zapLogger)
 	defer sugar.Sync()
 
+	// 初始化生命周期管理器，关闭钩子按注册的相反顺序执行
+	lc := lifecycle.NewManager(sugar)
+	lc.OnShutdown("ip searcher", func(ctx context.Context) error {
+		utils.CloseIPSearcher()
+		return nil
+	})
+
 	// 初始化数据库连接
-	db, err := database.NewPostgresDB()
+	db, err := database.NewPostgresDB(cfg.Database)
 	if err != nil {
 		sugar.Fatalf("Failed to connect to database: %v", err)
 	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		sugar.Fatalf("Failed to get database handle: %v", err)
+	}
+	lc.OnShutdown("database", func(ctx context.Context) error {
+		return sqlDB.Close()
+	})
 
 	// 自动迁移数据库结构
-	if err := db.AutoMigrate(&domain.ShortLink{}, &domain.RedirectRule{}, &domain.ClickLog{}); err != nil {
+	if err := db.AutoMigrate(&domain.ShortLink{}, &domain.RedirectRule{}, &domain.ClickLog{}, &repository.ClickCountFlush{}, &domain.APIKey{}, &domain.Workspace{}, &domain.WorkspaceMember{}, &domain.AuditLog{}, &domain.UsageRecord{}); err != nil {
 		sugar.Fatalf("Failed to migrate database: %v", err)
 	}
 	sugar.Info("Database migrated successfully")
 
-	// 初始化Redis连接
-	redisClient, err := cache.NewRedisClient()
+	// 初始化缓存
+	linkCache, err := cache.NewCache(cfg.Cache, cfg.Redis)
+	if err != nil {
+		sugar.Fatalf("Failed to initialize cache: %v", err)
+	}
+	lc.OnShutdown("cache", func(ctx context.Context) error {
+		return linkCache.Close()
+	})
+
+	// 清理短链接和规则缓存，保留尚未同步的点击计数
+	for _, prefix := range []string{"link:", "rules:"} {
+		if err := linkCache.DelPrefix(context.Background(), prefix); err != nil {
+			sugar.Fatalf("Failed to flush cache: %v", err)
+		}
+	}
+	sugar.Info("Cleared link cache")
+
+	// 就绪检查：数据库和缓存为跳转路径必需的依赖，IP库不可用时仅影响地区匹配
+	healthChecks := []http.HealthCheck{
+		{Name: "database", Critical: true, Check: sqlDB.PingContext},
+		{Name: "cache", Critical: true, Check: linkCache.Ping},
+		{Name: "ip2region", Check: func(ctx context.Context) error {
+			return utils.CheckIPSearcher()
+		}},
+	}
+
+	// 初始化点击计数器，每个进程一个后台协程批量同步点击数，关闭时执行最后一次同步
+	clickCounter := repository.NewClickCounter(db, linkCache,
+		cfg.Clicks.FlushInterval, cfg.Clicks.FlushBatchSize, zapLogger)
+	clickCounter.Start()
+	lc.OnShutdown("click counter", clickCounter.Close)
+
+	// 初始化点击日志写入器，关闭时写入队列中剩余的日志
+	clickLogWriter, err := repository.NewClickLogWriter(db, repository.ClickLogWriterConfig{
+		QueueSize:     cfg.ClickLog.QueueSize,
+		BatchSize:     cfg.ClickLog.BatchSize,
+		FlushInterval: cfg.ClickLog.FlushInterval,
+		Overflow:      repository.OverflowPolicy(cfg.ClickLog.Overflow),
+		BlockTimeout:  cfg.ClickLog.BlockTimeout,
+		SpillPath:     cfg.ClickLog.SpillPath,
+	}, zapLogger)
 	if err != nil {
-		sugar.Fatalf("Failed to connect to redis: %v", err)
+		sugar.Fatalf("Failed to create click log writer: %v", err)
 	}
+	clickLogWriter.Start()
+	lc.OnShutdown("click log writer", clickLogWriter.Close)
+
+	// 选择点击日志的写入方式：queue 进程内队列；stream 追加到Redis Stream，由消费者组写入数据库
+	var clickSink repository.ClickLogSink = clickLogWriter
+	var clickStreamStats http.ClickStreamStatsProvider
+	switch mode := cfg.ClickLog.Mode; mode {
+	case "", "queue":
+	case "stream":
+		redisClient, err := cache.NewRedisClient(cfg.Redis)
+		if err != nil {
+			sugar.Fatalf("Failed to connect to click stream redis: %v", err)
+		}
+		lc.OnShutdown("click stream redis", func(ctx context.Context) error {
+			return redisClient.Close()
+		})
+		// 事件流不可用时点击日志回退到进程内队列，不影响跳转
+		healthChecks = append(healthChecks, http.HealthCheck{
+			Name: "click_stream",
+			Check: func(ctx context.Context) error {
+				return redisClient.Ping(ctx).Err()
+			},
+		})
 
-	// 清理所有缓存
-	if err := redisClient.FlushDB(context.Background()).Err(); err != nil {
-		sugar.Fatalf("Failed to flush redis: %v", err)
+		// 写入Redis失败时回退到进程内队列
+		streamCfg := clickStreamConfig(cfg.ClickLog.Stream)
+		clickSink = repository.NewClickStreamProducer(redisClient, streamCfg, clickLogWriter, zapLogger)
+
+		consumer := repository.NewClickStreamConsumer(db, redisClient, streamCfg, zapLogger)
+		clickStreamStats = consumer
+		if cfg.ClickLog.Stream.RunConsumer {
+			if err := consumer.Start(context.Background()); err != nil {
+				sugar.Fatalf("Failed to start click stream consumer: %v", err)
+			}
+			lc.OnShutdown("click stream consumer", consumer.Close)
+		}
+		sugar.Infof("Click logs are written through redis stream %s", streamCfg.Stream)
+	default:
+		sugar.Fatalf("Unknown click log mode: %s", mode)
 	}
-	sugar.Info("Cleared all cache")
 
 	// 初始化仓储层
-	shortLinkRepo := repository.NewShortLinkRepository(db, redisClient)
+	shortLinkRepo := repository.NewShortLinkRepository(db, linkCache, clickCounter, clickSink, zapLogger)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	workspaceRepo := repository.NewWorkspaceRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	usageRepo := repository.NewUsageRepository(db, linkCache)
+
+	// 初始化用量计量器，跳转次数累加在缓存中，后台协程定期汇总写入 usage_records，关闭时执行最后一次汇总
+	usageMeter := repository.NewUsageMeter(db, linkCache, cfg.Metering.Interval, zapLogger)
+	var redirectMeter domain.MeteringRepository
+	if cfg.Metering.Enabled {
+		redirectMeter = usageMeter
+		usageMeter.Start()
+		lc.OnShutdown("usage meter", usageMeter.Close)
+	}
 
 	// 初始化用例层
-	shortLinkUseCase := usecase.NewShortLinkUseCase(shortLinkRepo)
+	timeouts := usecase.Timeouts{
+		Redirect: cfg.Timeouts.Redirect,
+		Read:     cfg.Timeouts.Read,
+		Write:    cfg.Timeouts.Write,
+	}
+	quotas := usecase.Quotas{
+		Enabled:   cfg.Quotas.Enabled,
+		User:      usecase.QuotaLimits(cfg.Quotas.User),
+		Workspace: usecase.QuotaLimits(cfg.Quotas.Workspace),
+	}
+	// 解锁Cookie的签名密钥未配置时随机生成，重启后或在其他实例上需要重新输入密码
+	unlockSecret := []byte(cfg.ShortLink.Password.Secret)
+	if len(unlockSecret) == 0 {
+		unlockSecret = make([]byte, 32)
+		if _, err := rand.Read(unlockSecret); err != nil {
+			sugar.Fatalf("Failed to generate unlock secret: %v", err)
+		}
+		sugar.Warn("shortlink.password.secret is not set, unlock cookies are only valid on this instance until restart")
+	}
+	// 密码尝试次数按短链接和客户端IP计数，使用Redis时多个实例共享计数
+	unlockLimiter := repository.NewAttemptLimiter(linkCache, "unlock",
+		cfg.ShortLink.Password.MaxAttempts, cfg.ShortLink.Password.Lockout)
+	shortLinkUseCase := usecase.NewShortLinkUseCase(shortLinkRepo, auditRepo, usageRepo, workspaceRepo, redirectMeter, unlockLimiter, usecase.Config{
+		CodeLength:        cfg.ShortLink.CodeLength,
+		DefaultExpireDays: cfg.ShortLink.DefaultExpireDays,
+		Timeouts:          timeouts,
+		Quotas:            quotas,
+		Password: usecase.PasswordConfig{
+			Secret:    unlockSecret,
+			UnlockTTL: cfg.ShortLink.Password.UnlockTTL,
+		},
+	}, zapLogger)
+	apiKeyUseCase := usecase.NewAPIKeyUseCase(apiKeyRepo, timeouts, zapLogger)
+	workspaceUseCase := usecase.NewWorkspaceUseCase(workspaceRepo, timeouts, zapLogger)
+	auditUseCase := usecase.NewAuditUseCase(auditRepo, timeouts, zapLogger)
+	usageUseCase := usecase.NewUsageUseCase(usageRepo, quotas, timeouts, zapLogger)
+	meteringUseCase := usecase.NewMeteringUseCase(usageMeter, timeouts, zapLogger)
 
 	// 初始化处理器
-	shortLinkHandler := http.NewShortLinkHandler(shortLinkUseCase)
+	// 限流计数保存在缓存中，使用Redis时多个实例共享限额
+	var apiLimit gin.HandlerFunc
+	var limits http.RateLimits
+	if rl := cfg.RateLimit; rl.Enabled {
+		limitLogger := zapLogger.Named("ratelimit")
+		if rl.API.Requests > 0 {
+			apiLimit = middleware.RateLimit(linkCache, "api", rl.API.Requests, rl.API.Window, middleware.ClientKey, limitLogger)
+		}
+		if rl.Create.Requests > 0 {
+			limits.Create = middleware.RateLimit(linkCache, "create", rl.Create.Requests, rl.Create.Window, middleware.ClientKey, limitLogger)
+		}
+		if rl.Redirect.Requests > 0 {
+			limits.Redirect = middleware.RateLimit(linkCache, "redirect", rl.Redirect.Requests, rl.Redirect.Window, middleware.IPKey, limitLogger)
+		}
+	}
+
+	shortLinkHandler := http.NewShortLinkHandler(shortLinkUseCase, limits, zapLogger)
+	apiKeyHandler := http.NewAPIKeyHandler(apiKeyUseCase, zapLogger)
+	workspaceHandler := http.NewWorkspaceHandler(workspaceUseCase, zapLogger)
+	auditHandler := http.NewAuditHandler(auditUseCase, zapLogger)
+	usageHandler := http.NewUsageHandler(usageUseCase, meteringUseCase, zapLogger)
+	statsHandler := http.NewStatsHandler(clickCounter, clickLogWriter, clickStreamStats)
 
 	// 设置gin模式
-	gin.SetMode(viper.GetString("server.mode"))
+	gin.SetMode(cfg.Server.Mode)
+
+	// 创建gin实例，请求ID中间件需在访问日志之前注册
+	r := gin.New()
+	// 客户端IP用于访问记录、限流、密码尝试锁定和审计，只采用可信代理传递的 X-Forwarded-For
+	// 未配置可信代理时不信任任何代理，使用连接的对端地址
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		sugar.Fatalf("Invalid server.trusted_proxies: %v", err)
+	}
+	r.Use(middleware.RequestID(), middleware.AccessLog(zapLogger.Named("http")), gin.Recovery())
+	r.Use(middleware.BodyLimit(cfg.Server.MaxBodySize << 20))
+
+	healthHandler := http.NewHealthHandler(cfg.Health.Timeout, healthChecks...)
+	handlers := []http.Handler{shortLinkHandler, apiKeyHandler, workspaceHandler, auditHandler, usageHandler, statsHandler, healthHandler}
+
+	// 注册Prometheus指标
+	if cfg.Metrics.Enabled {
+		if err := metrics.RegisterDB(sqlDB, "linkit"); err != nil {
+			sugar.Fatalf("Failed to register database metrics: %v", err)
+		}
+		if err := metrics.RegisterClickLogWriter(clickLogWriter.Stats); err != nil {
+			sugar.Fatalf("Failed to register click log metrics: %v", err)
+		}
+		if err := metrics.RegisterClickCounter(clickCounter.Stats); err != nil {
+			sugar.Fatalf("Failed to register click counter metrics: %v", err)
+		}
+		r.Use(middleware.Metrics())
+		handlers = append(handlers, http.NewMetricsHandler())
+	}
+
+	// API认证，首个管理员API Key可通过 cmd/apikey 创建，启用JWT后也接受SSO签发的令牌
+	var authenticator middleware.Authenticator = apiKeyUseCase
+	if cfg.Auth.JWT.Enabled {
+		verifier := oidc.NewVerifier(cfg.Auth.JWT, zapLogger.Named("auth"))
+		if err := verifier.Load(context.Background()); err != nil {
+			sugar.Fatalf("Failed to load JWKS: %v", err)
+		}
+		authenticator = middleware.Tokens(apiKeyUseCase, verifier)
+		sugar.Info("JWT authentication is enabled")
+	}
+	auth := middleware.Auth(authenticator, zapLogger.Named("auth"))
+	if !cfg.Auth.Enabled {
+		sugar.Warn("API authentication is disabled, all API requests are treated as admin")
+		auth = middleware.Anonymous()
+	}
 
-	// 创建gin实例
-	r := gin.Default()
+	// 工作空间中间件根据 X-Workspace-ID 切换调用者所在的工作空间
+	workspace := middleware.Workspace(workspaceUseCase, zapLogger.Named("auth"))
 
 	// 注册路由
-	http.RegisterRoutes(r, shortLinkHandler)
+	apiMiddlewares := gin.HandlersChain{auth, workspace}
+	if apiLimit != nil {
+		apiMiddlewares = append(apiMiddlewares, apiLimit)
+	}
+	http.RegisterRoutes(r, apiMiddlewares, handlers...)
 
 	// 启动服务器
-	addr := fmt.Sprintf(":%d", viper.GetInt("server.port"))
-	if err := r.Run(addr); err != nil {
-		sugar.Fatalf("Failed to start server: %v", err)
+	srv := &stdhttp.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler: r,
+	}
+	lc.OnShutdown("http server", srv.Shutdown)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		sugar.Infof("Server listening on %s", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, stdhttp.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	// 等待退出信号
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var startErr error
+	select {
+	case <-ctx.Done():
+		sugar.Info("Received shutdown signal")
+	case startErr = <-serverErr:
+		sugar.Errorf("Failed to start server: %v", startErr)
 	}
+	stop()
+
+	// 优雅关闭：停止接收新请求，等待进行中的请求完成，再刷新点击数据并关闭依赖
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
 
-	// 关闭IP搜索器
-	defer utils.CloseIPSearcher()
+	if err := lc.Shutdown(shutdownCtx); err != nil {
+		sugar.Errorf("Shutdown completed with errors: %v", err)
+	}
+	if startErr != nil {
+		sugar.Fatalf("Server stopped unexpectedly: %v", startErr)
+	}
+	sugar.Info("Server exited gracefully")
+}
+
+// clickStreamConfig 转换点击事件流配置
+func clickStreamConfig(cfg config.ClickStreamConfig) repository.ClickStreamConfig {
+	return repository.ClickStreamConfig{
+		Stream:     cfg.Name,
+		Group:      cfg.Group,
+		Consumer:   cfg.Consumer,
+		DeadLetter: cfg.DeadLetter,
+		MaxLen:     cfg.MaxLen,
+		BatchSize:  cfg.BatchSize,
+		Block:      cfg.Block,
+		MinIdle:    cfg.MinIdle,
+		MaxRetries: cfg.MaxRetries,
+	}
 }
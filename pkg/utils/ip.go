@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
 	"net"
 	"strings"
 	"sync"
@@ -113,6 +115,17 @@ func GetIPRegion(ip string) *RegionInfo {
 	return parseRegion(region)
 }
 
+// CheckIPSearcher 检查IP搜索器是否已初始化并可以查询
+func CheckIPSearcher() error {
+	if searcher == nil {
+		return errors.New("ip searcher not initialized")
+	}
+	if _, err := searcher.SearchByStr("8.8.8.8"); err != nil {
+		return fmt.Errorf("ip searcher lookup failed: %w", err)
+	}
+	return nil
+}
+
 // CloseIPSearcher 关闭IP搜索器
 func CloseIPSearcher() {
 	if searcher != nil {
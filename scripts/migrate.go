@@ -1,41 +1,32 @@
 package main
 
 import (
-	"fmt"
+	"flag"
 	"log"
-	"os"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
-	"github.com/spf13/viper"
-)
 
-func init() {
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath("../configs")
-	if err := viper.ReadInConfig(); err != nil {
-		log.Fatalf("Error reading config file: %s", err)
-	}
-}
+	"linkit/internal/config"
+)
 
 func main() {
-	if len(os.Args) < 2 {
+	configPath := flag.String("config", "../configs/config.yaml", "配置文件路径")
+	flag.Parse()
+	if flag.NArg() < 1 {
 		log.Fatal("Command required: up or down")
 	}
 
-	command := os.Args[1]
+	command := flag.Arg(0)
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
 
 	// 构建数据库连接字符串
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
-		viper.GetString("database.user"),
-		viper.GetString("database.password"),
-		viper.GetString("database.host"),
-		viper.GetInt("database.port"),
-		viper.GetString("database.dbname"),
-		viper.GetString("database.sslmode"),
-	)
+	dsn := cfg.Database.URL()
 
 	// 创建迁移实例
 	m, err := migrate.New(
@@ -0,0 +1,5 @@
+-- 删除索引
+DROP INDEX IF EXISTS idx_click_count_flushes_created_at;
+
+-- 删除表
+DROP TABLE IF EXISTS click_count_flushes;
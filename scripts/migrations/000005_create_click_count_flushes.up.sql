@@ -0,0 +1,8 @@
+-- 创建点击数同步批次表，用于保证点击数同步的精确性
+CREATE TABLE IF NOT EXISTS click_count_flushes (
+    batch_id VARCHAR(32) PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_click_count_flushes_created_at ON click_count_flushes(created_at);
@@ -0,0 +1,5 @@
+-- 删除索引
+DROP INDEX IF EXISTS idx_click_logs_event_id;
+
+-- 删除字段
+ALTER TABLE click_logs DROP COLUMN IF EXISTS event_id;
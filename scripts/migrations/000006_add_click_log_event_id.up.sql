@@ -0,0 +1,5 @@
+-- 添加点击事件ID，用于事件流消费时去重
+ALTER TABLE click_logs ADD COLUMN IF NOT EXISTS event_id VARCHAR(64);
+
+-- 创建唯一索引
+CREATE UNIQUE INDEX IF NOT EXISTS idx_click_logs_event_id ON click_logs(event_id);
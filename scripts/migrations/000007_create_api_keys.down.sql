@@ -0,0 +1,6 @@
+-- 删除索引
+DROP INDEX IF EXISTS idx_api_keys_user_id;
+DROP INDEX IF EXISTS idx_api_keys_key_hash;
+
+-- 删除表
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,10 @@
+-- 删除索引
+DROP INDEX IF EXISTS idx_short_links_workspace_id;
+DROP INDEX IF EXISTS idx_workspace_members_user_id;
+
+-- 删除字段
+ALTER TABLE short_links DROP COLUMN IF EXISTS workspace_id;
+
+-- 删除表
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
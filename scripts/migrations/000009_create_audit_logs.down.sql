@@ -0,0 +1,14 @@
+-- 删除触发器
+DROP TRIGGER IF EXISTS trg_audit_logs_append_only ON audit_logs;
+DROP FUNCTION IF EXISTS audit_logs_append_only();
+
+-- 删除索引
+DROP INDEX IF EXISTS idx_audit_logs_created_at;
+DROP INDEX IF EXISTS idx_audit_logs_action;
+DROP INDEX IF EXISTS idx_audit_logs_workspace_id;
+DROP INDEX IF EXISTS idx_audit_logs_actor_id;
+DROP INDEX IF EXISTS idx_audit_logs_short_link_id;
+DROP INDEX IF EXISTS idx_audit_logs_short_code;
+
+-- 删除表
+DROP TABLE IF EXISTS audit_logs;
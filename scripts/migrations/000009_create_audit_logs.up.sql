@@ -0,0 +1,35 @@
+-- 创建审计日志表，记录短链接和跳转规则的管理操作
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+    action VARCHAR(32) NOT NULL,
+    short_link_id INTEGER NOT NULL,
+    short_code VARCHAR(32) NOT NULL,
+    rule_id INTEGER,
+    workspace_id INTEGER NOT NULL DEFAULT 0,
+    actor_id INTEGER NOT NULL DEFAULT 0,
+    api_key_id INTEGER NOT NULL DEFAULT 0,
+    ip VARCHAR(45),
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_audit_logs_short_code ON audit_logs(short_code);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_short_link_id ON audit_logs(short_link_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs(actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_workspace_id ON audit_logs(workspace_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs(action);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at);
+
+-- 审计日志只能追加，禁止修改和删除
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_audit_logs_append_only ON audit_logs;
+CREATE TRIGGER trg_audit_logs_append_only
+    BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
@@ -0,0 +1,2 @@
+-- 删除索引
+DROP INDEX IF EXISTS idx_short_links_user_id;
@@ -0,0 +1,2 @@
+-- 创建索引，用于按用户统计个人空间的有效短链接数
+CREATE INDEX IF NOT EXISTS idx_short_links_user_id ON short_links(user_id);
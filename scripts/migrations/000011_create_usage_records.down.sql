@@ -0,0 +1,5 @@
+-- 删除索引
+DROP INDEX IF EXISTS idx_usage_records_day;
+
+-- 删除表
+DROP TABLE IF EXISTS usage_records;
@@ -0,0 +1,13 @@
+-- 创建用量计量表，按工作空间和日期(UTC)汇总，workspace_id 为 0 的记录汇总所有个人空间
+CREATE TABLE IF NOT EXISTS usage_records (
+    workspace_id INTEGER NOT NULL,
+    day DATE NOT NULL,
+    links_created BIGINT NOT NULL DEFAULT 0,
+    redirects BIGINT NOT NULL DEFAULT 0,
+    click_logs BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (workspace_id, day)
+);
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_usage_records_day ON usage_records(day);
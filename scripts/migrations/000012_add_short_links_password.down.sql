@@ -0,0 +1,2 @@
+-- 删除访问密码列
+ALTER TABLE short_links DROP COLUMN IF EXISTS password_hash;
@@ -0,0 +1,2 @@
+-- 添加访问密码列，保存bcrypt哈希，为空表示不需要密码
+ALTER TABLE short_links ADD COLUMN IF NOT EXISTS password_hash VARCHAR(100) NOT NULL DEFAULT '';
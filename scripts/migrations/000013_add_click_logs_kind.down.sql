@@ -0,0 +1,2 @@
+-- 删除访问记录类型列
+ALTER TABLE click_logs DROP COLUMN IF EXISTS kind;
@@ -0,0 +1,2 @@
+-- 添加访问记录类型列，click 为跳转，preview 为查看预览页
+ALTER TABLE click_logs ADD COLUMN IF NOT EXISTS kind VARCHAR(16) NOT NULL DEFAULT 'click';
@@ -0,0 +1,2 @@
+-- 删除查询参数转发方式列
+ALTER TABLE short_links DROP COLUMN IF EXISTS query_passthrough;
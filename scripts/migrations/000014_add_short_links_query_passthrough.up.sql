@@ -0,0 +1,2 @@
+-- 添加查询参数转发方式列，off 不转发，append 追加(目标URL优先)，override 追加(访问者优先)
+ALTER TABLE short_links ADD COLUMN IF NOT EXISTS query_passthrough VARCHAR(16) NOT NULL DEFAULT 'off';
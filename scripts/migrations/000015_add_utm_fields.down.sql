@@ -0,0 +1,16 @@
+-- 删除UTM参数相关的索引和列
+DROP INDEX IF EXISTS idx_short_links_utm_campaign;
+
+ALTER TABLE click_logs DROP COLUMN IF EXISTS campaign;
+
+ALTER TABLE redirect_rules DROP COLUMN IF EXISTS utm_content;
+ALTER TABLE redirect_rules DROP COLUMN IF EXISTS utm_term;
+ALTER TABLE redirect_rules DROP COLUMN IF EXISTS utm_campaign;
+ALTER TABLE redirect_rules DROP COLUMN IF EXISTS utm_medium;
+ALTER TABLE redirect_rules DROP COLUMN IF EXISTS utm_source;
+
+ALTER TABLE short_links DROP COLUMN IF EXISTS utm_content;
+ALTER TABLE short_links DROP COLUMN IF EXISTS utm_term;
+ALTER TABLE short_links DROP COLUMN IF EXISTS utm_campaign;
+ALTER TABLE short_links DROP COLUMN IF EXISTS utm_medium;
+ALTER TABLE short_links DROP COLUMN IF EXISTS utm_source;
@@ -0,0 +1,19 @@
+-- 添加短链接的UTM参数列，跳转时写入目标URL
+ALTER TABLE short_links ADD COLUMN IF NOT EXISTS utm_source VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE short_links ADD COLUMN IF NOT EXISTS utm_medium VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE short_links ADD COLUMN IF NOT EXISTS utm_campaign VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE short_links ADD COLUMN IF NOT EXISTS utm_term VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE short_links ADD COLUMN IF NOT EXISTS utm_content VARCHAR(255) NOT NULL DEFAULT '';
+
+-- 添加跳转规则的UTM参数列，不为空的字段覆盖短链接的UTM参数
+ALTER TABLE redirect_rules ADD COLUMN IF NOT EXISTS utm_source VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE redirect_rules ADD COLUMN IF NOT EXISTS utm_medium VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE redirect_rules ADD COLUMN IF NOT EXISTS utm_campaign VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE redirect_rules ADD COLUMN IF NOT EXISTS utm_term VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE redirect_rules ADD COLUMN IF NOT EXISTS utm_content VARCHAR(255) NOT NULL DEFAULT '';
+
+-- 添加访问记录的活动名称列，记录跳转时生效的 utm_campaign
+ALTER TABLE click_logs ADD COLUMN IF NOT EXISTS campaign VARCHAR(255) NOT NULL DEFAULT '';
+
+-- 按活动查询短链接
+CREATE INDEX IF NOT EXISTS idx_short_links_utm_campaign ON short_links(utm_campaign);
@@ -0,0 +1,5 @@
+-- 删除移动端App深度链接列
+ALTER TABLE short_links DROP COLUMN IF EXISTS app_android_fallback_url;
+ALTER TABLE short_links DROP COLUMN IF EXISTS app_android_url;
+ALTER TABLE short_links DROP COLUMN IF EXISTS app_ios_fallback_url;
+ALTER TABLE short_links DROP COLUMN IF EXISTS app_ios_url;
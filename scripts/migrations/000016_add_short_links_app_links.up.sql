@@ -0,0 +1,5 @@
+-- 添加移动端App深度链接列，访问者的系统配置了深度链接时由中间页先尝试打开App
+ALTER TABLE short_links ADD COLUMN IF NOT EXISTS app_ios_url VARCHAR(2048) NOT NULL DEFAULT '';
+ALTER TABLE short_links ADD COLUMN IF NOT EXISTS app_ios_fallback_url VARCHAR(2048) NOT NULL DEFAULT '';
+ALTER TABLE short_links ADD COLUMN IF NOT EXISTS app_android_url VARCHAR(2048) NOT NULL DEFAULT '';
+ALTER TABLE short_links ADD COLUMN IF NOT EXISTS app_android_fallback_url VARCHAR(2048) NOT NULL DEFAULT '';
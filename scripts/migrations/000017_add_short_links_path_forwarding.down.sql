@@ -0,0 +1,2 @@
+-- 删除路径转发开关列
+ALTER TABLE short_links DROP COLUMN IF EXISTS path_forwarding;
@@ -0,0 +1,2 @@
+-- 添加路径转发开关列，开启后 /<code>/<path> 将 <path> 拼接到目标URL的路径之后
+ALTER TABLE short_links ADD COLUMN IF NOT EXISTS path_forwarding BOOLEAN NOT NULL DEFAULT FALSE;